@@ -16,17 +16,23 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/go-chi/httprate"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/cache"
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/handler"
-	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/service"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/ugc"
 )
 
+// redisKeyPrefix namespaces every key this service writes, so the Redis
+// instance can be shared with other applications.
+const redisKeyPrefix = "sentryatlas:"
+
 func main() {
 	port := envOrDefault("PORT", "8080")
 	cacheTTLMin := envPositiveIntOrDefault("CACHE_TTL_MINUTES", 5)
+	cacheStaleMin := envPositiveIntOrDefault("CACHE_STALE_MINUTES", 60)
 	fetchTimeoutSec := envPositiveIntOrDefault("FETCH_TIMEOUT_SECONDS", 30)
 	rateLimitPerMin := envPositiveIntOrDefault("RATE_LIMIT_PER_MINUTE", 60)
 
@@ -43,15 +49,121 @@ func main() {
 
 	httpClient := &http.Client{Timeout: time.Duration(fetchTimeoutSec) * time.Second}
 
+	// NWS alerts without a geometry are located by their zones, from a
+	// dataset built into the binary. NWS_ZONES_FILE names a newer one,
+	// written by cmd/ugczones, and is re-read when it changes.
+	noaa := adapters.NewNOAAAdapter(httpClient, nwsUserAgent)
+	if path := os.Getenv("NWS_ZONES_FILE"); path != "" {
+		zones, err := ugc.NewFileSource(path, time.Hour)
+		if err != nil {
+			slog.Error("invalid environment variable: NWS_ZONES_FILE", "error", err)
+			os.Exit(1)
+		}
+		noaa.SetZones(zones)
+	}
+	// NWS_EVENT_TYPES_FILE adjusts how NWS event names map to event types,
+	// layered over the built-in table.
+	if path := os.Getenv("NWS_EVENT_TYPES_FILE"); path != "" {
+		c, err := adapters.LoadNOAAClassification(path)
+		if err != nil {
+			slog.Error("invalid environment variable: NWS_EVENT_TYPES_FILE", "error", err)
+			os.Exit(1)
+		}
+		noaa.SetClassification(c)
+	}
+
 	adapterList := []adapters.Adapter{
 		adapters.NewUSGSAdapter(httpClient),
+		adapters.NewEMSCAdapter(httpClient),
 		adapters.NewEONETAdapter(httpClient),
-		adapters.NewNOAAAdapter(httpClient, nwsUserAgent),
+		noaa,
+		adapters.NewNWPSAdapter(httpClient),
 		adapters.NewGDACSAdapter(httpClient),
+		adapters.NewNHCAdapter(httpClient),
+		adapters.NewTsunamiAdapter(httpClient),
+		adapters.NewHANSAdapter(httpClient),
+		adapters.NewGVPAdapter(httpClient),
+		adapters.NewSWPCAdapter(httpClient),
+	}
+	// FIRMS requires a (free) MAP_KEY, so it is only enabled when one is
+	// configured; every other source is keyless.
+	if key := os.Getenv("FIRMS_MAP_KEY"); key != "" {
+		adapterList = append(adapterList, adapters.NewFIRMSAdapter(httpClient, key))
+	}
+	// CAP_FEEDS adds other agencies' CAP 1.2 Atom indexes, each as a source
+	// of its own.
+	capFeeds, err := adapters.ParseCAPFeeds(os.Getenv("CAP_FEEDS"))
+	if err != nil {
+		slog.Error("invalid environment variable: CAP_FEEDS", "error", err)
+		os.Exit(1)
+	}
+	for _, cfg := range capFeeds {
+		adapterList = append(adapterList, adapters.NewCAPAdapter(httpClient, cfg))
+	}
+	// MAPPED_SOURCES_FILE names a JSON file of declarative feed mappings,
+	// so a simple JSON or GeoJSON feed, or an ArcGIS or WFS layer, can be
+	// added without code.
+	if path := os.Getenv("MAPPED_SOURCES_FILE"); path != "" {
+		mapped, err := adapters.LoadMappedSources(path)
+		if err != nil {
+			slog.Error("invalid environment variable: MAPPED_SOURCES_FILE", "error", err)
+			os.Exit(1)
+		}
+		for _, cfg := range mapped.Sources {
+			a, err := adapters.NewMappedAdapter(httpClient, cfg)
+			if err != nil {
+				slog.Error("invalid environment variable: MAPPED_SOURCES_FILE", "error", err)
+				os.Exit(1)
+			}
+			adapterList = append(adapterList, a)
+		}
+		for _, cfg := range mapped.Layers {
+			a, err := adapters.NewFeatureLayerAdapter(httpClient, cfg)
+			if err != nil {
+				slog.Error("invalid environment variable: MAPPED_SOURCES_FILE", "error", err)
+				os.Exit(1)
+			}
+			adapterList = append(adapterList, a)
+		}
 	}
+	// Source names key the cache and the per-source statuses, so a
+	// configured feed must not reuse a built-in one.
+	sourceNames := make(map[string]bool)
+	for _, a := range adapterList {
+		if sourceNames[a.Source()] {
+			slog.Error("duplicate source name", "source", a.Source())
+			os.Exit(1)
+		}
+		sourceNames[a.Source()] = true
+	}
+
+	// Past the TTL, entries are served stale for up to CACHE_STALE_MINUTES
+	// while one background fetch refreshes them, so an upstream outage
+	// degrades to old data instead of none.
+	softTTL := time.Duration(cacheTTLMin) * time.Minute
+	hardTTL := time.Duration(cacheTTLMin+cacheStaleMin) * time.Minute
 
-	eventsCache := cache.New[[]models.Event](time.Duration(cacheTTLMin) * time.Minute)
-	defer eventsCache.Close()
+	// With REDIS_URL set, replicas share one cache and take turns fetching
+	// each source instead of each hitting every upstream on its own.
+	var eventsCache cache.Store[service.Snapshot]
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			slog.Error("invalid environment variable: REDIS_URL", "error", err)
+			os.Exit(1)
+		}
+		redisClient := redis.NewClient(opts)
+		defer redisClient.Close()
+		// The fill lock outlives the fetch timeout, so a slow but healthy
+		// fetch is never duplicated by a replica that gave up waiting.
+		lockTTL := time.Duration(fetchTimeoutSec)*time.Second + 5*time.Second
+		eventsCache = cache.NewRedis[service.Snapshot](redisClient, redisKeyPrefix, softTTL, hardTTL, lockTTL)
+		slog.Info("using redis cache", "addr", opts.Addr)
+	} else {
+		memCache := cache.NewWithStale[service.Snapshot](softTTL, hardTTL)
+		defer memCache.Close()
+		eventsCache = memCache
+	}
 
 	eventsSvc := service.NewEventsService(
 		adapterList,
@@ -59,6 +171,15 @@ func main() {
 		time.Duration(fetchTimeoutSec)*time.Second,
 	)
 
+	// Streaming sources push into the cache as events happen, and are
+	// re-pulled at half the TTL so their entries never go stale.
+	streamCtx, stopStreams := context.WithCancel(context.Background())
+	streamsDone := make(chan struct{})
+	go func() {
+		eventsSvc.RunStreams(streamCtx, softTTL/2)
+		close(streamsDone)
+	}()
+
 	eventsHandler := handler.NewEventsHandler(eventsSvc)
 
 	r := chi.NewRouter()
@@ -67,11 +188,9 @@ func main() {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	// Types listed explicitly: application/geo+json is not in the
-	// middleware's defaults, and it is the bulkiest response we serve.
-	// text/event-stream is deliberately absent — compressing SSE buffers
-	// flushes and breaks progressive delivery.
-	r.Use(middleware.Compress(5, "application/json", "application/geo+json"))
+	// The events handler serves bodies it compressed once per snapshot;
+	// handler.Compress covers everything else and passes those through.
+	r.Use(handler.Compress)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "OPTIONS"},
@@ -101,6 +220,7 @@ func main() {
 				return httprate.CanonicalizeIP(ip), nil
 			}))
 		r.Get("/events", eventsHandler.GetEvents)
+		r.Get("/types", handler.GetTypes)
 	})
 
 	srv := &http.Server{
@@ -111,6 +231,10 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Live SSE streams never go idle on their own; end them so Shutdown
+	// can drain.
+	srv.RegisterOnShutdown(eventsSvc.CloseSubscriptions)
+
 	go func() {
 		slog.Info("server starting", "port", port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -132,6 +256,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	stopStreams()
+	<-streamsDone
 	slog.Info("server stopped")
 }
 
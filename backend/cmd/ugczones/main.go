@@ -0,0 +1,174 @@
+// Command ugczones builds the NWS zone dataset that internal/ugc embeds:
+// every forecast zone, fire weather zone and county, keyed by UGC code,
+// with simplified boundaries. Run it through go generate ./internal/ugc,
+// or with -o to write a file for NWS_ZONES_FILE.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/ugc"
+)
+
+// zoneTypes are the zone kinds alerts' UGC codes name: Z codes are forecast
+// or fire weather zones, C codes counties.
+var zoneTypes = []string{"forecast", "fire", "county"}
+
+func main() {
+	out := flag.String("o", "zones.json.gz", "output file")
+	base := flag.String("api", "https://api.weather.gov", "NWS API base URL")
+	tolerance := flag.Float64("tolerance", 0.01, "simplification tolerance in degrees")
+	userAgent := flag.String("user-agent", "SentryAtlas/1.0 (github.com/KOHANTIC/SentryAtlas)", "User-Agent sent to the NWS API")
+	flag.Parse()
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	byID := make(map[string]ugc.Zone)
+	for _, typ := range zoneTypes {
+		zones, err := fetchZones(context.Background(), client, *base, typ, *userAgent, *tolerance)
+		if err != nil {
+			slog.Error("fetch zones", "type", typ, "error", err)
+			os.Exit(1)
+		}
+		// A code is one zone whatever its type; the first type wins.
+		for _, z := range zones {
+			if _, ok := byID[z.ID]; !ok {
+				byID[z.ID] = z
+			}
+		}
+		slog.Info("fetched zones", "type", typ, "count", len(zones))
+	}
+
+	// An empty table would be embedded without complaint and leave every
+	// zone-based alert unlocated.
+	if len(byID) == 0 {
+		slog.Error("no zones fetched")
+		os.Exit(1)
+	}
+
+	zones := make([]ugc.Zone, 0, len(byID))
+	for _, z := range byID {
+		zones = append(zones, z)
+	}
+	slices.SortFunc(zones, func(a, b ugc.Zone) int { return strings.Compare(a.ID, b.ID) })
+
+	// Written aside and renamed, so a failed run leaves the old dataset
+	// and a running server never reads half a file.
+	tmp := *out + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		slog.Error("create output", "error", err)
+		os.Exit(1)
+	}
+	err = ugc.Write(f, time.Now().UTC().Truncate(time.Second), zones)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, *out)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		slog.Error("write dataset", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("wrote dataset", "path", *out, "zones", len(zones))
+}
+
+type zoneCollection struct {
+	Features []struct {
+		Geometry *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			State string `json:"state"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+func fetchZones(ctx context.Context, client *http.Client, base, typ, userAgent string, tolerance float64) ([]ugc.Zone, error) {
+	q := url.Values{"type": {typ}, "include_geometry": {"true"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/zones?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/geo+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var fc zoneCollection
+	if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("decode zones: %w", err)
+	}
+
+	var zones []ugc.Zone
+	for _, f := range fc.Features {
+		if f.Geometry == nil || f.Properties.ID == "" {
+			continue
+		}
+		var polygons [][][][]float64
+		switch f.Geometry.Type {
+		case "Polygon":
+			var p [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &p); err != nil {
+				return nil, fmt.Errorf("zone %s: %w", f.Properties.ID, err)
+			}
+			polygons = [][][][]float64{p}
+		case "MultiPolygon":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &polygons); err != nil {
+				return nil, fmt.Errorf("zone %s: %w", f.Properties.ID, err)
+			}
+		default:
+			continue
+		}
+
+		// Only outer rings are kept: holes in a zone don't change which
+		// alerts a map shows where.
+		var simplified [][][][]float64
+		for _, p := range polygons {
+			if len(p) == 0 {
+				continue
+			}
+			if ring := ugc.Simplify(p[0], tolerance, 3); len(ring) >= 4 {
+				simplified = append(simplified, [][][]float64{ring})
+			}
+		}
+		if len(simplified) == 0 {
+			continue
+		}
+		centroid := ugc.Centroid(polygons)
+		if centroid == nil {
+			continue
+		}
+		for i := range centroid {
+			centroid[i] = math.Round(centroid[i]*1000) / 1000
+		}
+		zones = append(zones, ugc.Zone{
+			ID:       f.Properties.ID,
+			Name:     f.Properties.Name,
+			State:    f.Properties.State,
+			Centroid: centroid,
+			Polygons: simplified,
+		})
+	}
+	return zones, nil
+}
@@ -13,15 +13,80 @@ type Adapter interface {
 	SupportedTypes() []string
 }
 
-// FetchParams carries a request's parameters. Adapters only ever receive
-// Types and Since: BBox and Limit are applied service-side after the merge,
-// because passing them upstream would make every viewport a distinct cache
-// key and defeat the per-source cache entirely.
+// FetchParams carries a request's parameters. The service fetches each
+// adapter's canonical snapshot with empty params and filters it locally;
+// the only upstream parameters it ever sets are Since, for a historical
+// window older than a Windowed adapter's snapshot reaches, and with it a
+// whole-number MinMagnitude floor for a MinMagnitudeFilterer. Types still
+// narrows a direct FetchEvents call.
 type FetchParams struct {
 	Types []string
 	BBox  *BBox
+	Near  *Circle
 	Since time.Time
 	Limit int
+
+	// MinMagnitude keeps events with at least this magnitude, in whatever
+	// unit their source reports it; events without one are dropped.
+	MinMagnitude *float64
+	// ReviewedOnly drops events whose models.MetaReviewStatus is
+	// "automatic". Sources that report no review status are unaffected.
+	ReviewedOnly bool
+	// Tsunami, when set, keeps only events whose models.MetaTsunami flag
+	// equals it; an event without the flag counts as false.
+	Tsunami *bool
+	// Status selects events by whether they have ended: StatusOpen, the
+	// default when empty, StatusClosed or StatusAll.
+	Status string
+	// MinSeverity keeps events at least this severe, one of
+	// models.Severities; events without a severity are dropped.
+	MinSeverity string
+	// Sort orders the results: SortNewest, the default when empty, or
+	// SortSeverity.
+	Sort string
+}
+
+// Values of FetchParams.Status.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+	StatusAll    = "all"
+)
+
+// Values of FetchParams.Sort. SortSeverity puts the most severe events
+// first, then the highest models.Event Impact, then the newest.
+const (
+	SortNewest   = "newest"
+	SortSeverity = "severity"
+)
+
+// Windowed is implemented by adapters whose default fetch only reaches back
+// a bounded time. Requests with an older Since need a historical fetch;
+// adapters without a window (current-alert feeds) have no history to ask
+// for, so their snapshot always serves every request.
+type Windowed interface {
+	Window() time.Duration
+}
+
+// MinMagnitudeFilterer is implemented by Windowed adapters that send
+// MinMagnitude upstream. A long historical window of a busy catalog can be
+// too large to fetch whole, so the service passes a requested floor along,
+// rounded down so that nearby floors share one fetch.
+type MinMagnitudeFilterer interface {
+	Windowed
+	FiltersMinMagnitude()
+}
+
+// StreamingAdapter is implemented by adapters whose upstream also pushes
+// events as they happen, over a WebSocket or server-sent events. Stream
+// holds one connection, passing each pushed event, new or updated, to
+// emit, and returns once the connection drops or ctx ends; the service
+// reconnects with backoff. FetchEvents still loads the snapshot pushes are
+// merged into, and is re-run periodically to catch whatever a dropped
+// connection missed.
+type StreamingAdapter interface {
+	Adapter
+	Stream(ctx context.Context, emit func(models.Event)) error
 }
 
 type BBox struct {
@@ -31,6 +96,14 @@ type BBox struct {
 	MaxLat float64
 }
 
+// Circle selects events within RadiusKm of a point, by great-circle
+// distance.
+type Circle struct {
+	Lon      float64
+	Lat      float64
+	RadiusKm float64
+}
+
 // SupportsAnyType returns true if the adapter supports at least one of the requested types.
 // If requested is empty, all adapters are considered matching.
 func SupportsAnyType(adapter Adapter, requested []string) bool {
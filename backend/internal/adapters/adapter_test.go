@@ -0,0 +1,66 @@
+package adapters
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// TestSeverityMappersEmitKnownLevels runs every adapter's severity mapping
+// over its inputs, known and not. A misspelled level would rank 0 and
+// silently sort and filter as if the event had no severity.
+func TestSeverityMappersEmitKnownLevels(t *testing.T) {
+	t.Parallel()
+	outputs := map[string]string{}
+	add := func(name, severity string) {
+		outputs[name] = severity
+	}
+
+	for _, s := range []string{"Extreme", "severe", " MODERATE ", "Minor", "Unknown", ""} {
+		add(fmt.Sprintf("capSeverity(%q)", s), capSeverity(s))
+	}
+	for _, l := range []string{"Red", "Orange", "Green", ""} {
+		add(fmt.Sprintf("gdacsAlertToSeverity(%q)", l), gdacsAlertToSeverity(l))
+	}
+	for _, a := range []string{"red", "orange", "yellow", "green", ""} {
+		add(fmt.Sprintf("usgsAlertToSeverity(%q)", a), usgsAlertToSeverity(a))
+	}
+	for _, c := range []string{"HU", "TS", "STS", "TD", "PTC", ""} {
+		for _, kt := range []float64{25, 50, 100} {
+			add(fmt.Sprintf("nhcSeverity(%q, %g)", c, kt), nhcSeverity(c, kt))
+		}
+	}
+	for _, s := range []string{"G1", "G2", "G3", "G4", "G5", "S1", "R3", ""} {
+		add(fmt.Sprintf("swpcScaleSeverity(%q)", s), swpcScaleSeverity(s))
+	}
+	for _, m := range []string{"warning", "threat", "advisory", "watch", "information", ""} {
+		add(fmt.Sprintf("tsunamiSeverity(%q)", m), tsunamiSeverity(m))
+	}
+	for level := range volcanoAlertLevelSeverity {
+		for color := range volcanoColorCodeSeverity {
+			add(fmt.Sprintf("volcanoSeverity(%q, %q)", level, color), volcanoSeverity(level, color))
+		}
+	}
+	for scale := 2; scale <= 5; scale++ {
+		for rank := 0; rank <= scale; rank++ {
+			add(fmt.Sprintf("volcanoRankSeverity(%d, %d)", rank, scale), volcanoRankSeverity(rank, scale))
+		}
+	}
+	for category, severity := range nwpsCategorySeverity {
+		add(fmt.Sprintf("nwpsCategorySeverity[%q]", category), severity)
+	}
+
+	for name, severity := range outputs {
+		if severity != "" && !models.IsValidSeverity(severity) {
+			t.Errorf("%s = %q, not a severity level", name, severity)
+		}
+	}
+	// The known inputs must map to something, or the table above would
+	// pass on mappers that return nothing at all.
+	for _, name := range []string{`capSeverity("Extreme")`, `gdacsAlertToSeverity("Red")`, `usgsAlertToSeverity("yellow")`, `tsunamiSeverity("warning")`, `swpcScaleSeverity("G5")`} {
+		if models.SeverityRank(outputs[name]) == 0 {
+			t.Errorf("%s = %q, want a ranked severity", name, outputs[name])
+		}
+	}
+}
@@ -0,0 +1,622 @@
+package adapters
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// capMaxBytes bounds a downloaded index or CAP message. National indexes
+// run to a few MB; single messages with detailed polygons to a few hundred
+// KB.
+const capMaxBytes = 16 << 20
+
+// capFetchConcurrency bounds the CAP messages fetched at once from one
+// feed, whose index may list hundreds.
+const capFetchConcurrency = 8
+
+// capCirclePoints is the number of vertices a CAP circle is drawn with.
+const capCirclePoints = 32
+
+// CAPConfig describes one CAP 1.2 feed: the source name its events carry
+// and the Atom index listing its messages.
+type CAPConfig struct {
+	Source   string
+	IndexURL string
+}
+
+var capSourceRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ParseCAPFeeds parses a comma-separated list of source=url pairs, as in
+// "dwd=https://example.org/dwd/atom.xml,eccc=https://example.org/eccc.xml".
+// Source names are lower-case and must be unique; URLs must be absolute
+// http(s) URLs.
+func ParseCAPFeeds(s string) ([]CAPConfig, error) {
+	var feeds []CAPConfig
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		source, rawURL, ok := strings.Cut(pair, "=")
+		source, rawURL = strings.TrimSpace(source), strings.TrimSpace(rawURL)
+		if !ok || source == "" || rawURL == "" {
+			return nil, fmt.Errorf("cap feed %q: want source=url", pair)
+		}
+		if !capSourceRe.MatchString(source) {
+			return nil, fmt.Errorf("cap feed %q: source must be lower-case letters, digits, - or _", source)
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("cap feed %q: duplicate source", source)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("cap feed %q: invalid url %q", source, rawURL)
+		}
+		seen[source] = true
+		feeds = append(feeds, CAPConfig{Source: source, IndexURL: rawURL})
+	}
+	return feeds, nil
+}
+
+// CAPAdapter reads any agency's CAP 1.2 alerts from an Atom index, as
+// published by MeteoAlarm, Environment Canada, the Australian Bureau of
+// Meteorology and DWD among others. Each index entry links one CAP
+// message, fetched and parsed in full; messages are re-fetched only when
+// their entry's updated time changes. Updates and cancellations are
+// resolved through the messages' references, so each alert is reported
+// once, as its latest version, under the ID of the message that began it.
+type CAPAdapter struct {
+	client   *http.Client
+	source   string
+	indexURL string
+	now      func() time.Time
+
+	mu       sync.Mutex
+	messages map[string]capMessage // by message URL
+}
+
+type capMessage struct {
+	updated string // the index entry's updated time when fetched
+	alert   capAlert
+}
+
+func NewCAPAdapter(client *http.Client, cfg CAPConfig) *CAPAdapter {
+	return &CAPAdapter{
+		client:   client,
+		source:   cfg.Source,
+		indexURL: cfg.IndexURL,
+		now:      time.Now,
+		messages: make(map[string]capMessage),
+	}
+}
+
+func (a *CAPAdapter) Source() string {
+	return a.source
+}
+
+// SupportedTypes is every type classifyCAPEvent can emit: the NWS event
+// name classes, which cover the English event names other agencies use too.
+func (a *CAPAdapter) SupportedTypes() []string {
+	return slices.Clone(noaaEventTypes)
+}
+
+// FetchEvents ignores params: an index lists the alerts in effect.
+func (a *CAPAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	data, err := a.get(ctx, a.indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.source, err)
+	}
+	var index capIndex
+	if err := xml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("%s: decode index: %w", a.source, err)
+	}
+
+	a.mu.Lock()
+	cached := a.messages
+	a.mu.Unlock()
+
+	alerts := make([]*capAlert, len(index.Entries))
+	fresh := make(map[string]capMessage, len(index.Entries))
+	var freshMu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(capFetchConcurrency)
+	for i, entry := range index.Entries {
+		if alert, ok := entry.inlineAlert(); ok {
+			alerts[i] = &alert
+			continue
+		}
+		link := entry.capLink()
+		if link == "" {
+			continue
+		}
+		if m, ok := cached[link]; ok && entry.Updated != "" && m.updated == entry.Updated {
+			alerts[i] = &m.alert
+			fresh[link] = m
+			continue
+		}
+		g.Go(func() error {
+			alert, err := a.fetchMessage(gctx, link)
+			if err != nil {
+				if gctx.Err() != nil {
+					return gctx.Err()
+				}
+				// One broken message should not hide the rest; an earlier
+				// copy, if any, stands in until it can be read again.
+				slog.Warn("cap: message unavailable", "source", a.source, "url", link, "error", err)
+				m, ok := cached[link]
+				if !ok {
+					return nil
+				}
+				alert = m.alert
+			}
+			alerts[i] = &alert
+			freshMu.Lock()
+			fresh[link] = capMessage{updated: entry.Updated, alert: alert}
+			freshMu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("%s: %w", a.source, err)
+	}
+
+	a.mu.Lock()
+	a.messages = fresh
+	a.mu.Unlock()
+
+	var parsed []capAlert
+	for _, alert := range alerts {
+		if alert != nil {
+			parsed = append(parsed, *alert)
+		}
+	}
+	return capEvents(a.source, parsed, a.now()), nil
+}
+
+func (a *CAPAdapter) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, capMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if len(data) > capMaxBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", capMaxBytes)
+	}
+	return data, nil
+}
+
+func (a *CAPAdapter) fetchMessage(ctx context.Context, url string) (capAlert, error) {
+	data, err := a.get(ctx, url)
+	if err != nil {
+		return capAlert{}, err
+	}
+	var alert capAlert
+	if err := xml.Unmarshal(data, &alert); err != nil {
+		return capAlert{}, fmt.Errorf("decode CAP: %w", err)
+	}
+	if alert.Identifier == "" {
+		return capAlert{}, errors.New("not a CAP alert")
+	}
+	return alert, nil
+}
+
+// capEvents resolves a feed's messages into the alerts in effect at now.
+// A message referenced by another has been updated or cancelled and is
+// dropped; cancellations themselves report nothing; expired alerts and
+// exercises, tests and drafts are left out.
+func capEvents(source string, alerts []capAlert, now time.Time) []models.Event {
+	superseded := make(map[string]bool)
+	for _, a := range alerts {
+		for _, ref := range a.references() {
+			superseded[ref.key()] = true
+		}
+	}
+
+	byID := make(map[string]models.Event)
+	for _, a := range alerts {
+		if !strings.EqualFold(a.Status, "Actual") || superseded[a.key()] {
+			continue
+		}
+		switch strings.ToLower(a.MsgType) {
+		case "alert", "update":
+		default:
+			continue // Cancel, Ack and Error carry no alert of their own
+		}
+		info, ok := a.preferredInfo()
+		if !ok {
+			continue
+		}
+		if expires, err := time.Parse(time.RFC3339, strings.TrimSpace(info.Expires)); err == nil && expires.Before(now) {
+			continue
+		}
+		e := capEvent(source, a, info)
+		if prev, ok := byID[e.ID]; ok && !e.UpdatedAt.After(prev.UpdatedAt) {
+			continue
+		}
+		byID[e.ID] = e
+	}
+
+	events := make([]models.Event, 0, len(byID))
+	for _, id := range slices.Sorted(maps.Keys(byID)) {
+		events = append(events, byID[id])
+	}
+	return events
+}
+
+func capEvent(source string, a capAlert, info capInfo) models.Event {
+	// The alert keeps the identifier of the message that first issued it,
+	// the earliest one referenced, so updates replace it in place.
+	rootID := strings.TrimSpace(a.Identifier)
+	rootSent, _ := time.Parse(time.RFC3339, strings.TrimSpace(a.Sent))
+	for _, ref := range a.references() {
+		if sent, err := time.Parse(time.RFC3339, ref.sent); err == nil && sent.Before(rootSent) {
+			rootID, rootSent = ref.identifier, sent
+		}
+	}
+
+	var areaDescs []string
+	var shapes []models.Shape
+	var anchor []float64
+	for _, area := range info.Areas {
+		if d := strings.TrimSpace(area.Desc); d != "" {
+			areaDescs = append(areaDescs, d)
+		}
+		for _, p := range area.Polygons {
+			ring := parseCAPPolygon(p)
+			if len(ring) < 4 {
+				continue
+			}
+			if anchor == nil {
+				anchor = ringCentroid(ring)
+			}
+			shapes = append(shapes, models.Shape{Type: "Polygon", Rings: [][][]float64{ring}})
+		}
+		for _, c := range area.Circles {
+			lat, lon, radiusKm, ok := parseCAPCircle(c)
+			if !ok {
+				continue
+			}
+			if anchor == nil {
+				anchor = []float64{lon, lat}
+			}
+			if radiusKm > 0 {
+				shapes = append(shapes, models.Shape{Type: "Polygon", Rings: [][][]float64{circleRing(lat, lon, radiusKm)}})
+			}
+		}
+	}
+	areaDesc := strings.Join(areaDescs, "; ")
+
+	metadata := map[string]any{
+		"event":      info.Event,
+		"urgency":    info.Urgency,
+		"certainty":  info.Certainty,
+		"msg_type":   a.MsgType,
+		"identifier": a.Identifier,
+		"sender":     a.Sender,
+	}
+	if areaDesc != "" {
+		metadata["area_desc"] = areaDesc
+	}
+	if len(info.Categories) > 0 {
+		metadata["category"] = info.Categories
+	}
+	if info.SenderName != "" {
+		metadata["sender_name"] = info.SenderName
+	}
+	if info.Language != "" {
+		metadata["language"] = info.Language
+	}
+	if info.Instruction != "" {
+		metadata["instruction"] = strings.TrimSpace(info.Instruction)
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(info.Expires)); err == nil {
+		metadata["expires"] = t.UTC().Format(time.RFC3339)
+	}
+	if len(shapes) > 0 {
+		names := make([]string, len(shapes))
+		for i := range names {
+			names[i] = "area"
+		}
+		metadata["shapes"] = names
+	}
+
+	sent, _ := time.Parse(time.RFC3339, strings.TrimSpace(a.Sent))
+	startedAt := sent
+	for _, s := range []string{info.Onset, info.Effective} {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(s)); err == nil {
+			startedAt = t
+			break
+		}
+	}
+
+	title := strings.TrimSpace(info.Headline)
+	if title == "" {
+		title = joinNonEmpty(" - ", strings.TrimSpace(info.Event), areaDesc)
+	}
+
+	var geom models.Geometry
+	geom.Type = "Point"
+	if anchor != nil {
+		geom.Coordinates = anchor
+		geom.Shapes = shapes
+	}
+
+	return models.Event{
+		ID:          source + "-" + rootID,
+		Title:       title,
+		Description: strings.TrimSpace(info.Description),
+		EventType:   classifyCAPEvent(info),
+		Source:      source,
+		Geometry:    geom,
+		Severity:    capSeverity(info.Severity),
+		StartedAt:   startedAt,
+		UpdatedAt:   sent,
+		URL:         strings.TrimSpace(info.Web),
+		Metadata:    metadata,
+	}
+}
+
+// classifyCAPEvent classifies by event name, with the CAP category as a
+// fallback for names the keywords miss, such as those not in English.
+func classifyCAPEvent(info capInfo) string {
+	t := classifyNOAAEvent(info.Event)
+	if t != "weather" {
+		return t
+	}
+	for _, c := range info.Categories {
+		if strings.EqualFold(c, "Fire") {
+			return "wildfire"
+		}
+	}
+	return t
+}
+
+// capSeverity maps CAP's severity onto ours, which uses the same four
+// levels; "Unknown" and anything else is left empty.
+func capSeverity(s string) string {
+	if s := strings.ToLower(strings.TrimSpace(s)); models.IsValidSeverity(s) {
+		return s
+	}
+	return ""
+}
+
+// parseCAPPolygon parses a CAP polygon, "lat,lon lat,lon ...", into a
+// GeoJSON ring of [lon, lat] positions, closing it if needed.
+func parseCAPPolygon(s string) [][]float64 {
+	var ring [][]float64
+	for _, pair := range strings.Fields(s) {
+		latStr, lonStr, ok := strings.Cut(pair, ",")
+		if !ok {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(latStr, 64)
+		lon, err2 := strconv.ParseFloat(lonStr, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		ring = append(ring, []float64{lon, lat})
+	}
+	if n := len(ring); n >= 3 && (ring[0][0] != ring[n-1][0] || ring[0][1] != ring[n-1][1]) {
+		ring = append(ring, ring[0])
+	}
+	return ring
+}
+
+// parseCAPCircle parses a CAP circle, "lat,lon radius" with the radius in
+// km.
+func parseCAPCircle(s string) (lat, lon, radiusKm float64, ok bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, 0, 0, false
+	}
+	lat, lon, ok = parseCAPLatLon(fields[0])
+	if !ok {
+		return 0, 0, 0, false
+	}
+	radiusKm, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || radiusKm < 0 {
+		return 0, 0, 0, false
+	}
+	return lat, lon, radiusKm, true
+}
+
+// ringCentroid is the mean of a closed ring's vertices, counting the
+// closing vertex once.
+func ringCentroid(ring [][]float64) []float64 {
+	n := len(ring) - 1
+	var sumLon, sumLat float64
+	for _, p := range ring[:n] {
+		sumLon += p[0]
+		sumLat += p[1]
+	}
+	return []float64{sumLon / float64(n), sumLat / float64(n)}
+}
+
+// circleRing approximates a circle on the sphere with a closed ring of
+// points at radiusKm from the center, by destination-point bearing.
+func circleRing(lat, lon, radiusKm float64) [][]float64 {
+	const earthRadiusKm = 6371.0
+	const rad = math.Pi / 180
+	d := radiusKm / earthRadiusKm
+	lat1, lon1 := lat*rad, lon*rad
+	ring := make([][]float64, 0, capCirclePoints+1)
+	for i := range capCirclePoints {
+		bearing := 2 * math.Pi * float64(i) / capCirclePoints
+		lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(bearing))
+		lon2 := lon1 + math.Atan2(math.Sin(bearing)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+		lonDeg := math.Mod(lon2/rad+540, 360) - 180
+		ring = append(ring, []float64{roundTo(lonDeg, 5), roundTo(lat2/rad, 5)})
+	}
+	return append(ring, ring[0])
+}
+
+// CAP Atom index types
+
+type capIndex struct {
+	Entries []capIndexEntry `xml:"entry"`
+}
+
+type capIndexEntry struct {
+	ID      string     `xml:"id"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Content struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"content"`
+}
+
+// capLink picks the entry's link to its CAP message: one typed as CAP if
+// there is one, else the alternate link, else the first.
+func (e capIndexEntry) capLink() string {
+	for _, l := range e.Links {
+		if strings.Contains(l.Type, "cap") {
+			return l.Href
+		}
+	}
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(e.Links) > 0 {
+		return e.Links[0].Href
+	}
+	return ""
+}
+
+// inlineAlert returns the CAP message embedded in the entry's content, as
+// some indexes carry the messages themselves.
+func (e capIndexEntry) inlineAlert() (capAlert, bool) {
+	if !strings.Contains(e.Content.Inner, "<alert") {
+		return capAlert{}, false
+	}
+	var alert capAlert
+	if err := xml.Unmarshal([]byte(e.Content.Inner), &alert); err != nil || alert.Identifier == "" {
+		return capAlert{}, false
+	}
+	return alert, true
+}
+
+// CAP 1.2 types. Only the fields mapped onto events are modelled.
+
+type capAlert struct {
+	Identifier string    `xml:"identifier"`
+	Sender     string    `xml:"sender"`
+	Sent       string    `xml:"sent"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	References string    `xml:"references"`
+	Info       []capInfo `xml:"info"`
+}
+
+type capInfo struct {
+	Language    string         `xml:"language"`
+	Categories  []string       `xml:"category"`
+	Event       string         `xml:"event"`
+	Urgency     string         `xml:"urgency"`
+	Severity    string         `xml:"severity"`
+	Certainty   string         `xml:"certainty"`
+	Effective   string         `xml:"effective"`
+	Onset       string         `xml:"onset"`
+	Expires     string         `xml:"expires"`
+	SenderName  string         `xml:"senderName"`
+	Headline    string         `xml:"headline"`
+	Description string         `xml:"description"`
+	Instruction string         `xml:"instruction"`
+	Web         string         `xml:"web"`
+	Parameters  []capParameter `xml:"parameter"`
+	Areas       []capArea      `xml:"area"`
+}
+
+type capParameter struct {
+	Name  string `xml:"valueName"`
+	Value string `xml:"value"`
+}
+
+type capArea struct {
+	Desc     string   `xml:"areaDesc"`
+	Polygons []string `xml:"polygon"`
+	Circles  []string `xml:"circle"`
+}
+
+// capReference is one "sender,identifier,sent" triple of a references
+// list.
+type capReference struct {
+	sender, identifier, sent string
+}
+
+func (r capReference) key() string {
+	return r.sender + "," + r.identifier
+}
+
+func (a capAlert) key() string {
+	return strings.TrimSpace(a.Sender) + "," + strings.TrimSpace(a.Identifier)
+}
+
+func (a capAlert) references() []capReference {
+	var refs []capReference
+	for _, triple := range strings.Fields(a.References) {
+		parts := strings.Split(triple, ",")
+		if len(parts) != 3 {
+			continue
+		}
+		refs = append(refs, capReference{sender: parts[0], identifier: parts[1], sent: parts[2]})
+	}
+	return refs
+}
+
+// preferredInfo picks the English info block of a multilingual message,
+// else the first.
+func (a capAlert) preferredInfo() (capInfo, bool) {
+	if len(a.Info) == 0 {
+		return capInfo{}, false
+	}
+	for _, info := range a.Info {
+		if strings.HasPrefix(strings.ToLower(info.Language), "en") {
+			return info, true
+		}
+	}
+	return a.Info[0], true
+}
+
+// parameters returns the info's parameters by name, first value winning.
+func (i capInfo) parameters() map[string]string {
+	out := make(map[string]string, len(i.Parameters))
+	for _, p := range i.Parameters {
+		name := strings.TrimSpace(p.Name)
+		if _, seen := out[name]; !seen {
+			out[name] = strings.TrimSpace(p.Value)
+		}
+	}
+	return out
+}
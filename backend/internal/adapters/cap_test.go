@@ -0,0 +1,243 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capTestNow is within every fixture alert except the expired one.
+var capTestNow = time.Date(2026, 8, 14, 12, 0, 0, 0, time.UTC)
+
+// serveCAP serves the CAP index, with message links pointing back at the
+// test server, and its messages; missing.xml 404s. Message requests are
+// counted by path in hits.
+func serveCAP(t *testing.T, hits map[string]int, mu *sync.Mutex) *httptest.Server {
+	t.Helper()
+	files := map[string][]byte{
+		"/atom.xml":             readFixture(t, "cap_index.xml"),
+		"/cap/flood-1.xml":      readFixture(t, "cap_flood_1.xml"),
+		"/cap/flood-2.xml":      readFixture(t, "cap_flood_2.xml"),
+		"/cap/storm.xml":        readFixture(t, "cap_storm.xml"),
+		"/cap/storm-cancel.xml": readFixture(t, "cap_storm_cancel.xml"),
+		"/cap/expired.xml":      readFixture(t, "cap_expired.xml"),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil && strings.HasPrefix(r.URL.Path, "/cap/") {
+			mu.Lock()
+			hits[r.URL.Path]++
+			mu.Unlock()
+		}
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(bytes.ReplaceAll(data, []byte("{{BASE}}"), []byte(srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestCAP(srv *httptest.Server) *CAPAdapter {
+	a := NewCAPAdapter(srv.Client(), CAPConfig{Source: "dwd", IndexURL: srv.URL + "/atom.xml"})
+	a.now = func() time.Time { return capTestNow }
+	return a
+}
+
+func TestCAPSourceAndSupportedTypes(t *testing.T) {
+	t.Parallel()
+	a := NewCAPAdapter(nil, CAPConfig{Source: "eccc", IndexURL: "https://example.org/atom.xml"})
+	if got := a.Source(); got != "eccc" {
+		t.Errorf("Source() = %q, want the configured eccc", got)
+	}
+	if got := a.SupportedTypes(); !slices.Equal(got, noaaEventTypes) {
+		t.Errorf("SupportedTypes() = %v, want %v", got, noaaEventTypes)
+	}
+}
+
+func TestCAPFetchEvents(t *testing.T) {
+	t.Parallel()
+	a := newTestCAP(serveCAP(t, nil, nil))
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	// The flood as updated and the inline fire alert. The storm was
+	// cancelled, the heat alert expired and the test message is not real.
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2; IDs: %v", len(events), eventIDs(events))
+	}
+
+	t.Run("update replaces the alert it references", func(t *testing.T) {
+		e := eventByID(t, events, "dwd-urn:oid:2.49.0.1.276.0.flood.1")
+		if e.Title != "Severe flood warning for the Elbe" {
+			t.Errorf("Title = %q, want the update's English headline", e.Title)
+		}
+		if e.EventType != "flood" || e.Source != "dwd" || e.Severity != "severe" {
+			t.Errorf("EventType, Source, Severity = %q, %q, %q", e.EventType, e.Source, e.Severity)
+		}
+		if e.Description != "The Elbe has risen above the warning level." || e.URL != "https://example.org/warnings/flood" {
+			t.Errorf("Description, URL = %q, %q", e.Description, e.URL)
+		}
+		if want := time.Date(2026, 8, 14, 8, 0, 0, 0, time.UTC); !e.StartedAt.Equal(want) {
+			t.Errorf("StartedAt = %v, want onset %v", e.StartedAt, want)
+		}
+		if want := time.Date(2026, 8, 14, 9, 30, 0, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want %v", e.UpdatedAt, want)
+		}
+		if c := e.Geometry.Coordinates; len(c) != 2 || math.Abs(c[0]-13.8) > 1e-9 || math.Abs(c[1]-51.0333) > 1e-4 {
+			t.Errorf("anchor = %v, want the first polygon's centroid [13.8 51.033]", c)
+		}
+		if len(e.Geometry.Shapes) != 2 {
+			t.Fatalf("got %d shapes, want 2 polygons", len(e.Geometry.Shapes))
+		}
+		ring := e.Geometry.Shapes[0].Rings[0]
+		if len(ring) != 4 || !slices.Equal(ring[0], []float64{13.6, 51.0}) || !slices.Equal(ring[3], ring[0]) {
+			t.Errorf("first ring = %v, want [lon lat] positions, closed", ring)
+		}
+		m := e.Metadata
+		if m["area_desc"] != "Elbe near Dresden; Elbe near Meissen" || m["urgency"] != "Immediate" || m["certainty"] != "Observed" {
+			t.Errorf("area_desc, urgency, certainty = %v, %v, %v", m["area_desc"], m["urgency"], m["certainty"])
+		}
+		if m["msg_type"] != "Update" || m["identifier"] != "urn:oid:2.49.0.1.276.0.flood.2" || m["language"] != "en-GB" {
+			t.Errorf("msg_type, identifier, language = %v, %v, %v", m["msg_type"], m["identifier"], m["language"])
+		}
+		if m["instruction"] != "Keep away from the river banks." || m["expires"] != "2026-08-15T12:00:00Z" {
+			t.Errorf("instruction, expires = %v, %v", m["instruction"], m["expires"])
+		}
+	})
+
+	t.Run("inline message classified by category", func(t *testing.T) {
+		e := eventByID(t, events, "dwd-urn:oid:2.49.0.1.276.0.fire.1")
+		if e.EventType != "wildfire" || e.Title != "Hohe Waldbrandgefahr" {
+			t.Errorf("EventType, Title = %q, %q", e.EventType, e.Title)
+		}
+		if e.Geometry.Coordinates != nil || len(e.Geometry.Shapes) != 0 {
+			t.Errorf("geometry = %+v, want unlocated for a geocode-only area", e.Geometry)
+		}
+	})
+}
+
+func TestCAPFetchesOnlyChangedMessages(t *testing.T) {
+	t.Parallel()
+	hits := make(map[string]int)
+	var mu sync.Mutex
+	a := newTestCAP(serveCAP(t, hits, &mu))
+
+	for range 2 {
+		if _, err := a.FetchEvents(context.Background(), FetchParams{}); err != nil {
+			t.Fatalf("FetchEvents: %v", err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for path, n := range hits {
+		want := 1
+		if path == "/cap/missing.xml" {
+			want = 2 // never read, so retried
+		}
+		if n != want {
+			t.Errorf("%s fetched %d times, want %d", path, n, want)
+		}
+	}
+}
+
+func TestCAPCancelledAndSuperseded(t *testing.T) {
+	t.Parallel()
+	alert := func(id, msgType, refs string) capAlert {
+		return capAlert{
+			Identifier: id, Sender: "s", Sent: "2026-08-14T10:00:00Z", Status: "Actual",
+			MsgType: msgType, References: refs,
+			Info: []capInfo{{Event: "Flood warning"}},
+		}
+	}
+	events := capEvents("x", []capAlert{
+		alert("a", "Alert", ""),
+		alert("b", "Update", "s,a,2026-08-14T08:00:00Z"),
+		alert("c", "Update", "s,a,2026-08-14T08:00:00Z s,b,2026-08-14T09:00:00Z"),
+		alert("d", "Alert", ""),
+		alert("e", "Cancel", "s,d,2026-08-14T08:00:00Z"),
+		alert("g", "Alert", "other,g,2026-08-14T08:00:00Z"),
+	}, capTestNow)
+
+	// c supersedes a and b, keeping a's ID; e cancels d. A reference is
+	// to a sender's message, so g citing another sender's g stands.
+	if got := eventIDs(events); !slices.Equal(got, []string{"x-a", "x-g"}) {
+		t.Fatalf("IDs = %v, want [x-a x-g]", got)
+	}
+	if got := events[0].Metadata["identifier"]; got != "c" {
+		t.Errorf("x-a carries message %v, want the latest, c", got)
+	}
+}
+
+func TestCAPIndexNon200(t *testing.T) {
+	t.Parallel()
+	srv := serveRaw(t, 502, "bad gateway")
+	a := NewCAPAdapter(srv.Client(), CAPConfig{Source: "bom", IndexURL: srv.URL})
+
+	_, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err == nil || !strings.Contains(err.Error(), "bom: unexpected status 502") {
+		t.Errorf("error = %v, want the source and status 502", err)
+	}
+}
+
+func TestCircleRing(t *testing.T) {
+	t.Parallel()
+	for _, center := range [][2]float64{{51.34, 12.37}, {-70, 179.9}} {
+		ring := circleRing(center[0], center[1], 25)
+		if len(ring) != capCirclePoints+1 || !slices.Equal(ring[0], ring[len(ring)-1]) {
+			t.Fatalf("ring has %d points, want %d, closed", len(ring), capCirclePoints+1)
+		}
+		for _, p := range ring {
+			if d := distanceKm(center[0], center[1], p[1], p[0]); math.Abs(d-25) > 0.01 {
+				t.Errorf("vertex %v is %.3f km from %v, want 25", p, d, center)
+			}
+			if p[0] < -180 || p[0] > 180 {
+				t.Errorf("vertex %v has longitude out of range", p)
+			}
+		}
+	}
+}
+
+func TestParseCAPFeeds(t *testing.T) {
+	t.Parallel()
+	got, err := ParseCAPFeeds(" dwd=https://example.org/dwd.xml, eccc = http://example.org/eccc.xml ,")
+	if err != nil {
+		t.Fatalf("ParseCAPFeeds: %v", err)
+	}
+	want := []CAPConfig{
+		{Source: "dwd", IndexURL: "https://example.org/dwd.xml"},
+		{Source: "eccc", IndexURL: "http://example.org/eccc.xml"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseCAPFeeds = %v, want %v", got, want)
+	}
+
+	if feeds, err := ParseCAPFeeds(""); err != nil || len(feeds) != 0 {
+		t.Errorf("empty list = %v, %v; want none", feeds, err)
+	}
+
+	for _, bad := range []string{
+		"dwd",
+		"=https://example.org/a.xml",
+		"DWD=https://example.org/a.xml",
+		"dwd=ftp://example.org/a.xml",
+		"dwd=/relative.xml",
+		"dwd=https://example.org/a.xml,dwd=https://example.org/b.xml",
+	} {
+		if _, err := ParseCAPFeeds(bad); err == nil {
+			t.Errorf("ParseCAPFeeds(%q) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const (
+	emscBaseURL = "https://www.seismicportal.eu/fdsnws/event/1/query"
+	emscWSURL   = "wss://www.seismicportal.eu/standing_order/websocket"
+)
+
+// emscDefaultWindow is how far back a fetch without Since reaches.
+const emscDefaultWindow = 7 * 24 * time.Hour
+
+// emscPingInterval is how often an idle stream is pinged. Quiet hours
+// without a quake are normal, so silence alone says nothing about the
+// connection.
+const emscPingInterval = 30 * time.Second
+
+// EMSCAdapter reports earthquakes from the European-Mediterranean
+// Seismological Centre's seismicportal, which pushes each new or revised
+// event over a WebSocket within seconds of it being published. The FDSN
+// event service provides the snapshot the pushes update.
+type EMSCAdapter struct {
+	client  *http.Client
+	baseURL string
+	wsURL   string
+}
+
+func NewEMSCAdapter(client *http.Client) *EMSCAdapter {
+	return &EMSCAdapter{client: client, baseURL: emscBaseURL, wsURL: emscWSURL}
+}
+
+func (a *EMSCAdapter) Source() string {
+	return "emsc"
+}
+
+func (a *EMSCAdapter) SupportedTypes() []string {
+	return []string{"earthquake"}
+}
+
+func (a *EMSCAdapter) Window() time.Duration {
+	return emscDefaultWindow
+}
+
+func (a *EMSCAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("emsc: build request: %w", err)
+	}
+
+	since := params.Since
+	if since.IsZero() {
+		since = time.Now().Add(-emscDefaultWindow)
+	}
+	q := req.URL.Query()
+	q.Set("format", "json")
+	q.Set("starttime", since.UTC().Format("2006-01-02T15:04:05"))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("emsc: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// FDSN services answer an empty result with 204.
+	if resp.StatusCode == http.StatusNoContent {
+		return []models.Event{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("emsc: unexpected status %d", resp.StatusCode)
+	}
+
+	var result emscResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("emsc: decode response: %w", err)
+	}
+
+	events := make([]models.Event, 0, len(result.Features))
+	for _, f := range result.Features {
+		if e, ok := parseEMSCFeature(f); ok {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// Stream reads the seismicportal WebSocket until it drops or ctx ends.
+func (a *EMSCAdapter) Stream(ctx context.Context, emit func(models.Event)) error {
+	conn, _, err := websocket.Dial(ctx, a.wsURL, &websocket.DialOptions{HTTPClient: a.client})
+	if err != nil {
+		return fmt.Errorf("emsc: dial: %w", err)
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(1 << 20)
+
+	// A half-open connection never errors on its own: ping it, and end the
+	// read by cancelling when a ping goes unanswered.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		ticker := time.NewTicker(emscPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pingCtx, pingCancel := context.WithTimeout(ctx, emscPingInterval)
+				err := conn.Ping(pingCtx)
+				pingCancel()
+				if err != nil {
+					cancel(fmt.Errorf("ping: %w", err))
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+				err = cause
+			}
+			return fmt.Errorf("emsc: read: %w", err)
+		}
+		var msg emscMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue // not an event message
+		}
+		if msg.Action != "create" && msg.Action != "update" {
+			continue
+		}
+		if e, ok := parseEMSCFeature(msg.Data); ok {
+			emit(e)
+		}
+	}
+}
+
+func parseEMSCFeature(f emscFeature) (models.Event, bool) {
+	p := f.Properties
+	unid := p.Unid
+	if unid == "" {
+		unid = f.ID
+	}
+	if unid == "" {
+		return models.Event{}, false
+	}
+
+	var coords []float64
+	if p.Lon != nil && p.Lat != nil {
+		coords = []float64{*p.Lon, *p.Lat}
+	} else if len(f.Geometry.Coordinates) >= 2 {
+		coords = f.Geometry.Coordinates[:2]
+	}
+
+	startedAt := parseEMSCTime(p.Time)
+	updatedAt := parseEMSCTime(p.LastUpdate)
+	if updatedAt.IsZero() {
+		updatedAt = startedAt
+	}
+
+	region := titleCaseWords(p.FlynnRegion)
+	title := region
+	if p.Mag != nil {
+		title = fmt.Sprintf("M %.1f - %s", *p.Mag, region)
+	}
+
+	metadata := map[string]any{
+		"place": region,
+		"depth": p.Depth,
+	}
+	if p.MagType != "" {
+		metadata["magtype"] = strings.ToLower(p.MagType)
+	}
+	if p.Auth != "" {
+		metadata["auth"] = p.Auth
+	}
+	if p.EvType != "" {
+		metadata["evtype"] = p.EvType
+	}
+
+	return models.Event{
+		ID:        "emsc-" + unid,
+		Title:     title,
+		EventType: "earthquake",
+		Source:    "emsc",
+		Geometry: models.Geometry{
+			Type:        "Point",
+			Coordinates: coords,
+		},
+		Magnitude: p.Mag,
+		StartedAt: startedAt,
+		UpdatedAt: updatedAt,
+		URL:       "https://www.seismicportal.eu/eventdetails.html?unid=" + url.QueryEscape(unid),
+		Metadata:  metadata,
+	}, true
+}
+
+// parseEMSCTime parses seismicportal's times, RFC 3339 with or without a
+// zone (always UTC).
+func parseEMSCTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC()
+	}
+	t, _ := time.Parse("2006-01-02T15:04:05.999999999", s)
+	return t
+}
+
+// titleCaseWords title-cases each word of an upper-case Flinn-Engdahl
+// region name: "CENTRAL ITALY" reads as "Central Italy".
+func titleCaseWords(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = titleCase(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// EMSC seismicportal types
+
+type emscResponse struct {
+	Features []emscFeature `json:"features"`
+}
+
+type emscMessage struct {
+	Action string      `json:"action"`
+	Data   emscFeature `json:"data"`
+}
+
+type emscFeature struct {
+	ID       string `json:"id"`
+	Geometry struct {
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties emscProperties `json:"properties"`
+}
+
+type emscProperties struct {
+	Unid        string   `json:"unid"`
+	Time        string   `json:"time"`
+	LastUpdate  string   `json:"lastupdate"`
+	Lat         *float64 `json:"lat"`
+	Lon         *float64 `json:"lon"`
+	Depth       float64  `json:"depth"`
+	Mag         *float64 `json:"mag"`
+	MagType     string   `json:"magtype"`
+	FlynnRegion string   `json:"flynn_region"`
+	Auth        string   `json:"auth"`
+	EvType      string   `json:"evtype"`
+}
@@ -0,0 +1,141 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+func TestEMSCFetchEvents(t *testing.T) {
+	t.Parallel()
+	capture := &reqCapture{}
+	srv := serveFixture(t, "emsc.json", capture)
+	a := &EMSCAdapter{client: http.DefaultClient, baseURL: srv.URL}
+
+	since := time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC)
+	events, err := a.FetchEvents(context.Background(), FetchParams{Since: since})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	q := capture.Query()
+	if q.Get("format") != "json" || q.Get("starttime") != "2026-08-10T00:00:00" {
+		t.Errorf("query = %v", q)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events", len(events))
+	}
+
+	e := eventByID(t, events, "emsc-20260814_0000091")
+	if e.Title != "M 4.6 - Central Italy" || e.EventType != "earthquake" || e.Source != "emsc" {
+		t.Errorf("title/type/source = %q/%q/%q", e.Title, e.EventType, e.Source)
+	}
+	if e.Geometry.Coordinates[0] != 13.39 || e.Geometry.Coordinates[1] != 42.35 {
+		t.Errorf("coordinates = %v", e.Geometry.Coordinates)
+	}
+	if e.Magnitude == nil || *e.Magnitude != 4.6 {
+		t.Errorf("magnitude = %v", e.Magnitude)
+	}
+	if want := time.Date(2026, 8, 14, 9, 12, 44, 100_000_000, time.UTC); !e.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", e.StartedAt, want)
+	}
+	if want := time.Date(2026, 8, 14, 9, 20, 11, 520_000_000, time.UTC); !e.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", e.UpdatedAt, want)
+	}
+	if e.Metadata["depth"] != 9.0 || e.Metadata["magtype"] != "ml" || e.Metadata["auth"] != "INGV" {
+		t.Errorf("metadata = %v", e.Metadata)
+	}
+	if e.URL != "https://www.seismicportal.eu/eventdetails.html?unid=20260814_0000091" {
+		t.Errorf("URL = %q", e.URL)
+	}
+
+	// No magnitude, zoneless UTC times.
+	chile := eventByID(t, events, "emsc-20260814_0000088")
+	if chile.Magnitude != nil || chile.Title != "Offshore Valparaiso, Chile" {
+		t.Errorf("magnitude/title = %v/%q", chile.Magnitude, chile.Title)
+	}
+	if want := time.Date(2026, 8, 14, 7, 58, 30, 0, time.UTC); !chile.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", chile.StartedAt, want)
+	}
+}
+
+func TestEMSCFetchEventsStatuses(t *testing.T) {
+	t.Parallel()
+	empty := serveRaw(t, http.StatusNoContent, "")
+	events, err := (&EMSCAdapter{client: http.DefaultClient, baseURL: empty.URL}).FetchEvents(context.Background(), FetchParams{})
+	if err != nil || len(events) != 0 {
+		t.Errorf("204: events = %v, err = %v; want none", events, err)
+	}
+
+	down := serveRaw(t, http.StatusServiceUnavailable, "")
+	if _, err := (&EMSCAdapter{client: http.DefaultClient, baseURL: down.URL}).FetchEvents(context.Background(), FetchParams{}); err == nil || !strings.Contains(err.Error(), "emsc: unexpected status 503") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEMSCStream(t *testing.T) {
+	t.Parallel()
+	messages := []string{
+		`{"action":"create","data":{"type":"Feature","id":"20260814_0000100","properties":{"unid":"20260814_0000100","time":"2026-08-14T10:00:00.0Z","lastupdate":"2026-08-14T10:01:00.0Z","lat":38.1,"lon":23.5,"depth":10,"mag":3.9,"magtype":"ml","flynn_region":"GREECE"}}}`,
+		`not json`,
+		`{"action":"delete","data":{"id":"x"}}`,
+		`{"action":"update","data":{"type":"Feature","properties":{"unid":"20260814_0000100","time":"2026-08-14T10:00:00.0Z","lastupdate":"2026-08-14T10:05:00.0Z","lat":38.1,"lon":23.5,"depth":12,"mag":4.1,"magtype":"mw","flynn_region":"GREECE"}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("accept: %v", err)
+			return
+		}
+		defer conn.CloseNow()
+		for _, m := range messages {
+			if err := conn.Write(r.Context(), websocket.MessageText, []byte(m)); err != nil {
+				return
+			}
+		}
+		conn.Close(websocket.StatusGoingAway, "restart")
+	}))
+	t.Cleanup(srv.Close)
+
+	a := &EMSCAdapter{client: http.DefaultClient, wsURL: "ws" + strings.TrimPrefix(srv.URL, "http")}
+	var got []models.Event
+	err := a.Stream(context.Background(), func(e models.Event) { got = append(got, e) })
+	if err == nil || !strings.HasPrefix(err.Error(), "emsc: read: ") {
+		t.Errorf("err = %v, want the read error of the closed connection", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("emitted %d events, want the create and the update", len(got))
+	}
+	if got[0].ID != "emsc-20260814_0000100" || got[1].ID != got[0].ID {
+		t.Errorf("IDs = %s, %s", got[0].ID, got[1].ID)
+	}
+	if *got[1].Magnitude != 4.1 || got[1].Metadata["magtype"] != "mw" {
+		t.Errorf("update = %v, %v", *got[1].Magnitude, got[1].Metadata)
+	}
+}
+
+func TestEMSCStreamEndsWithContext(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		conn.Read(r.Context()) // until the client goes
+	}))
+	t.Cleanup(srv.Close)
+
+	a := &EMSCAdapter{client: http.DefaultClient, wsURL: "ws" + strings.TrimPrefix(srv.URL, "http")}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Stream(ctx, func(models.Event) {}); err == nil {
+		t.Error("Stream returned nil after its context ended")
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
@@ -36,6 +39,10 @@ var eventTypeToEONET = map[string]string{
 	"drought":    "drought",
 }
 
+// eonetClosedDays is how many days back closed events are fetched, so that
+// recently ended ones can be shown with status=closed.
+const eonetClosedDays = 30
+
 type EONETAdapter struct {
 	client  *http.Client
 	baseURL string
@@ -55,20 +62,63 @@ func (a *EONETAdapter) SupportedTypes() []string {
 	return []string{"wildfire", "volcano", "storm", "iceberg", "earthquake", "flood", "landslide", "drought", "other"}
 }
 
+// FetchEvents fetches every open event and those closed in the last
+// eonetClosedDays, in two queries: a days limit on the open query would
+// drop long-lived events, such as an iceberg tracked for years. Closed
+// events only serve status=closed, so if their query fails the open ones
+// are still returned.
 func (a *EONETAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	var open, closed []eonetEvent
+	var closedErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		closed, closedErr = a.fetch(ctx, StatusClosed, params.Types)
+	}()
+	open, err := a.fetch(ctx, StatusOpen, params.Types)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if closedErr != nil {
+		slog.Warn("eonet: closed events unavailable", "error", closedErr)
+	}
+
+	// An event closing between the two queries is in both; the open copy
+	// is kept.
+	seen := make(map[string]bool)
+	events := make([]models.Event, 0, len(open)+len(closed))
+	for _, e := range append(open, closed...) {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		parsed := parseEONETEvent(e)
+		if !params.Since.IsZero() && parsed.StartedAt.Before(params.Since) {
+			continue
+		}
+		events = append(events, parsed)
+	}
+
+	return events, nil
+}
+
+// fetch queries the events of one status, in the categories of types.
+func (a *EONETAdapter) fetch(ctx context.Context, status string, types []string) ([]eonetEvent, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("eonet: build request: %w", err)
 	}
 
 	q := req.URL.Query()
-	q.Set("status", "open")
-
-	if len(params.Types) > 0 {
-		for _, t := range params.Types {
-			if cat, ok := eventTypeToEONET[t]; ok {
-				q.Add("category", cat)
-			}
+	q.Set("status", status)
+	if status == StatusClosed {
+		q.Set("days", strconv.Itoa(eonetClosedDays))
+	}
+	for _, t := range types {
+		if cat, ok := eventTypeToEONET[t]; ok {
+			q.Add("category", cat)
 		}
 	}
 
@@ -88,19 +138,13 @@ func (a *EONETAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]m
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("eonet: decode response: %w", err)
 	}
-
-	events := make([]models.Event, 0, len(result.Events))
-	for _, e := range result.Events {
-		parsed := parseEONETEvent(e)
-		if !params.Since.IsZero() && parsed.StartedAt.Before(params.Since) {
-			continue
-		}
-		events = append(events, parsed)
-	}
-
-	return events, nil
+	return result.Events, nil
 }
 
+// parseEONETEvent anchors the event at its latest observation and keeps the
+// whole series: a storm's successive positions become a LineString track,
+// listed with their dates and magnitudes in metadata, and the latest
+// polygon of an event observed as an area is kept as its shape.
 func parseEONETEvent(e eonetEvent) models.Event {
 	eventType := "other"
 	if len(e.Categories) > 0 {
@@ -109,21 +153,84 @@ func parseEONETEvent(e eonetEvent) models.Event {
 		}
 	}
 
-	var coords []float64
-	var startedAt time.Time
-	if len(e.Geometry) > 0 {
-		last := e.Geometry[len(e.Geometry)-1]
-		if len(last.Coordinates) >= 2 {
-			coords = last.Coordinates[:2]
+	var geom models.Geometry
+	var startedAt, updatedAt time.Time
+	var line [][]float64
+	var track []map[string]any
+	var polygon [][][]float64
+	mag, unit := e.MagnitudeValue, e.MagnitudeUnit
+	var lastMag *float64
+	lastUnit := ""
+	for _, g := range e.Geometry {
+		var coords []float64
+		switch g.Type {
+		case "Point":
+			var p []float64
+			if json.Unmarshal(g.Coordinates, &p) == nil && len(p) >= 2 {
+				coords = p[:2]
+				line = append(line, coords)
+			}
+		case "Polygon":
+			var rings [][][]float64
+			if json.Unmarshal(g.Coordinates, &rings) == nil && len(rings) > 0 && len(rings[0]) >= 4 {
+				polygon = rings
+				coords = ringCentroid(rings[0])
+			}
 		}
-		if t, err := time.Parse(time.RFC3339, last.Date); err == nil {
-			startedAt = t
+		if coords != nil {
+			geom.Type, geom.Coordinates = "Point", coords
 		}
+
+		point := map[string]any{"date": g.Date}
+		if t, err := time.Parse(time.RFC3339, g.Date); err == nil {
+			if startedAt.IsZero() || t.Before(startedAt) {
+				startedAt = t
+			}
+			if t.After(updatedAt) {
+				updatedAt = t
+			}
+		}
+		if g.Type == "Point" && coords != nil {
+			point["coordinates"] = coords
+		}
+		if g.MagnitudeValue != nil {
+			point["magnitude"] = *g.MagnitudeValue
+			lastMag, lastUnit = g.MagnitudeValue, g.MagnitudeUnit
+		}
+		track = append(track, point)
+	}
+	if len(line) >= 2 {
+		geom.Shapes = append(geom.Shapes, models.Shape{Type: "LineString", Positions: line})
+	}
+	if polygon != nil {
+		geom.Shapes = append(geom.Shapes, models.Shape{Type: "Polygon", Rings: polygon})
+	}
+	if mag == nil {
+		mag, unit = lastMag, lastUnit
 	}
 
-	var mag *float64
-	if e.MagnitudeValue != nil {
-		mag = e.MagnitudeValue
+	metadata := map[string]any{}
+	if mag != nil && unit != "" {
+		metadata["magnitude_unit"] = unit
+	}
+	if len(track) >= 2 {
+		metadata["track"] = track
+	}
+	if len(e.Sources) > 0 {
+		sources := make([]map[string]any, 0, len(e.Sources))
+		for _, src := range e.Sources {
+			sources = append(sources, map[string]any{"id": src.ID, "url": src.URL})
+		}
+		metadata["sources"] = sources
+	}
+	var endedAt time.Time
+	if e.Closed != nil {
+		if t, err := time.Parse(time.RFC3339, *e.Closed); err == nil {
+			endedAt = t
+			if t.After(updatedAt) {
+				updatedAt = t
+			}
+		}
 	}
 
 	return models.Event{
@@ -132,14 +239,13 @@ func parseEONETEvent(e eonetEvent) models.Event {
 		Description: e.Description,
 		EventType:   eventType,
 		Source:      "eonet",
-		Geometry: models.Geometry{
-			Type:        "Point",
-			Coordinates: coords,
-		},
-		Magnitude: mag,
-		StartedAt: startedAt,
-		UpdatedAt: startedAt,
-		URL:       e.Link,
+		Geometry:    geom,
+		Magnitude:   mag,
+		StartedAt:   startedAt,
+		UpdatedAt:   updatedAt,
+		EndedAt:     endedAt,
+		URL:         e.Link,
+		Metadata:    metadata,
 	}
 }
 
@@ -156,18 +262,28 @@ type eonetEvent struct {
 	Link           string          `json:"link"`
 	Closed         *string         `json:"closed"`
 	Categories     []eonetCategory `json:"categories"`
+	Sources        []eonetSource   `json:"sources"`
 	Geometry       []eonetGeometry `json:"geometry"`
 	MagnitudeValue *float64        `json:"magnitudeValue"`
 	MagnitudeUnit  string          `json:"magnitudeUnit"`
 }
 
+type eonetSource struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
 type eonetCategory struct {
 	ID    string `json:"id"`
 	Title string `json:"title"`
 }
 
+// eonetGeometry is one observation. Coordinates are a Point's position or
+// a Polygon's rings, so they are decoded by Type.
 type eonetGeometry struct {
-	Date        string    `json:"date"`
-	Type        string    `json:"type"`
-	Coordinates []float64 `json:"coordinates"`
+	Date           string          `json:"date"`
+	Type           string          `json:"type"`
+	Coordinates    json.RawMessage `json:"coordinates"`
+	MagnitudeValue *float64        `json:"magnitudeValue"`
+	MagnitudeUnit  string          `json:"magnitudeUnit"`
 }
@@ -2,9 +2,12 @@ package adapters
 
 import (
 	"context"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -56,12 +59,15 @@ func TestEONETFetchEventsParsing(t *testing.T) {
 		if !slices.Equal(e.Geometry.Coordinates, []float64{-119.31, 37.22}) {
 			t.Errorf("Coordinates = %v, want last geometry [-119.31 37.22]", e.Geometry.Coordinates)
 		}
-		want := time.Date(2026, 8, 6, 10, 0, 0, 0, time.UTC)
-		if !e.StartedAt.Equal(want) {
-			t.Errorf("StartedAt = %v, want last geometry date %v", e.StartedAt, want)
+		// The series runs from the first observation to the last.
+		if want := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC); !e.StartedAt.Equal(want) {
+			t.Errorf("StartedAt = %v, want first geometry date %v", e.StartedAt, want)
 		}
-		if !e.UpdatedAt.Equal(e.StartedAt) {
-			t.Errorf("UpdatedAt = %v, want same as StartedAt", e.UpdatedAt)
+		if want := time.Date(2026, 8, 6, 10, 0, 0, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want last geometry date %v", e.UpdatedAt, want)
+		}
+		if e.Closed() {
+			t.Errorf("open event has EndedAt %v", e.EndedAt)
 		}
 		if e.Magnitude != nil {
 			t.Errorf("Magnitude = %v, want nil", *e.Magnitude)
@@ -112,10 +118,40 @@ func TestEONETSinceFiltering(t *testing.T) {
 	}
 }
 
+// serveEONET answers the open and closed queries with their fixtures,
+// recording each query by status.
+func serveEONET(t *testing.T, closedStatus int) (*httptest.Server, func(status string) url.Values) {
+	t.Helper()
+	var mu sync.Mutex
+	queries := make(map[string]url.Values)
+	open, closed := readFixture(t, "eonet.json"), readFixture(t, "eonet_closed.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		queries[q.Get("status")] = q
+		mu.Unlock()
+		data := open
+		if q.Get("status") == "closed" {
+			if closedStatus != http.StatusOK {
+				w.WriteHeader(closedStatus)
+				return
+			}
+			data = closed
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(status string) url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return queries[status]
+	}
+}
+
 func TestEONETCategoryQuery(t *testing.T) {
 	t.Parallel()
-	var capture reqCapture
-	srv := serveFixture(t, "eonet.json", &capture)
+	srv, query := serveEONET(t, http.StatusOK)
 	a := newTestEONET(t, srv)
 
 	// "weather" has no EONET category and must not be forwarded.
@@ -124,13 +160,83 @@ func TestEONETCategoryQuery(t *testing.T) {
 		t.Fatalf("FetchEvents: %v", err)
 	}
 
-	q := capture.Query()
-	if got := q.Get("status"); got != "open" {
-		t.Errorf("status = %q, want open", got)
+	for _, status := range []string{"open", "closed"} {
+		q := query(status)
+		if q == nil {
+			t.Fatalf("no %s query", status)
+		}
+		cats := q["category"]
+		if !slices.Equal(cats, []string{"wildfires", "severeStorms"}) {
+			t.Errorf("%s: category = %v, want [wildfires severeStorms]", status, cats)
+		}
+	}
+	// Only closed events are limited to recent days.
+	if got := query("open").Get("days"); got != "" {
+		t.Errorf("open: days = %q, want unset", got)
+	}
+	if got := query("closed").Get("days"); got != "30" {
+		t.Errorf("closed: days = %q, want 30", got)
+	}
+}
+
+func TestEONETTracksAndClosedEvents(t *testing.T) {
+	t.Parallel()
+	srv, _ := serveEONET(t, http.StatusOK)
+	events, err := newTestEONET(t, srv).FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	// The closed copy of EONET_10001 is dropped for the open one.
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5; IDs: %v", len(events), eventIDs(events))
+	}
+	if e := eventByID(t, events, "eonet-EONET_10001"); e.Closed() {
+		t.Errorf("EONET_10001 closed at %v, want the open copy", e.EndedAt)
+	}
+
+	storm := eventByID(t, events, "eonet-EONET_10002")
+	shapes := storm.Geometry.Shapes
+	if len(shapes) != 1 || shapes[0].Type != "LineString" || len(shapes[0].Positions) != 3 {
+		t.Fatalf("storm shapes = %+v, want a 3-point track", shapes)
+	}
+	if !slices.Equal(storm.Geometry.Coordinates, []float64{-108.9, 17.3}) {
+		t.Errorf("storm anchor = %v, want the latest position", storm.Geometry.Coordinates)
+	}
+	if storm.Metadata["magnitude_unit"] != "kts" {
+		t.Errorf("magnitude_unit = %v, want kts", storm.Metadata["magnitude_unit"])
+	}
+	track, _ := storm.Metadata["track"].([]map[string]any)
+	if len(track) != 3 || track[0]["date"] != "2026-08-07T00:00:00Z" || track[0]["magnitude"] != 45.0 {
+		t.Errorf("track = %v", track)
+	}
+	sources, _ := storm.Metadata["sources"].([]map[string]any)
+	if len(sources) != 1 || sources[0]["id"] != "NOAA_NHC" || sources[0]["url"] != "https://www.nhc.noaa.gov/" {
+		t.Errorf("sources = %v", storm.Metadata["sources"])
+	}
+
+	// Closed: ended at its closed date, measured in acres from its last
+	// observation, and outlined by its latest polygon.
+	fire := eventByID(t, events, "eonet-EONET_10005")
+	if want := time.Date(2026, 8, 12, 0, 0, 0, 0, time.UTC); !fire.EndedAt.Equal(want) || !fire.UpdatedAt.Equal(want) {
+		t.Errorf("EndedAt/UpdatedAt = %v/%v, want %v", fire.EndedAt, fire.UpdatedAt, want)
+	}
+	if fire.Magnitude == nil || *fire.Magnitude != 5400 || fire.Metadata["magnitude_unit"] != "acres" {
+		t.Errorf("magnitude = %v %v, want 5400 acres", fire.Magnitude, fire.Metadata["magnitude_unit"])
+	}
+	if len(fire.Geometry.Shapes) != 1 || fire.Geometry.Shapes[0].Type != "Polygon" {
+		t.Fatalf("fire shapes = %+v, want its polygon", fire.Geometry.Shapes)
 	}
-	cats := q["category"]
-	if !slices.Equal(cats, []string{"wildfires", "severeStorms"}) {
-		t.Errorf("category = %v, want [wildfires severeStorms]", cats)
+	if c := fire.Geometry.Coordinates; len(c) != 2 || c[0] != -120.5 || c[1] != 38.5 {
+		t.Errorf("fire anchor = %v, want the polygon's centroid", c)
+	}
+}
+
+func TestEONETClosedQueryFailureDegrades(t *testing.T) {
+	t.Parallel()
+	srv, _ := serveEONET(t, http.StatusServiceUnavailable)
+	events, err := newTestEONET(t, srv).FetchEvents(context.Background(), FetchParams{})
+	if err != nil || len(events) != 3 {
+		t.Errorf("got %d events, err = %v; want the 3 open events", len(events), err)
 	}
 }
 
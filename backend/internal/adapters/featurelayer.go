@@ -0,0 +1,235 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const (
+	featureLayerPageSize    = 1000
+	featureLayerMaxFeatures = 10000
+)
+
+// FeatureLayerConfig describes an ArcGIS REST FeatureServer layer or an
+// OGC WFS feature type, the form many authoritative hazard layers (fire
+// perimeters, flood zones, civil-protection incidents) are published in.
+// Features are requested as GeoJSON and mapped as in MappedConfig, with
+// paths relative to each feature: "properties.IncidentName". Items
+// defaults to "features", fields.id to the feature's "id" and the position
+// to its geometry, so perimeters keep their polygons.
+type FeatureLayerConfig struct {
+	MappedConfig
+	// Service is "arcgis", with URL the layer (".../FeatureServer/0"), or
+	// "wfs", with URL the service endpoint and TypeName the feature type.
+	Service  string `json:"service"`
+	TypeName string `json:"type_name,omitempty"`
+	// Where filters an ArcGIS layer server-side; "1=1" if unset.
+	Where string `json:"where,omitempty"`
+	// PageSize is how many features each request asks for; a server may
+	// return fewer. MaxFeatures bounds the whole fetch.
+	PageSize    int `json:"page_size,omitempty"`
+	MaxFeatures int `json:"max_features,omitempty"`
+}
+
+// spec validates the config and compiles its mapping, with the layer
+// defaults applied.
+func (c FeatureLayerConfig) spec() (*mappedSpec, error) {
+	fail := func(format string, args ...any) (*mappedSpec, error) {
+		return nil, fmt.Errorf("mapped source %q: %s", c.Source, fmt.Sprintf(format, args...))
+	}
+	switch c.Service {
+	case "arcgis":
+		if c.TypeName != "" {
+			return fail("type_name is for wfs layers")
+		}
+	case "wfs":
+		if c.TypeName == "" {
+			return fail("wfs layers need type_name")
+		}
+		if c.Where != "" {
+			return fail("where is for arcgis layers")
+		}
+	default:
+		return fail("service must be arcgis or wfs, not %q", c.Service)
+	}
+	if c.PageSize < 0 || c.MaxFeatures < 0 {
+		return fail("page_size and max_features must not be negative")
+	}
+
+	cfg := c.MappedConfig
+	if cfg.Items == "" {
+		cfg.Items = "features"
+	}
+	if cfg.Fields.ID == "" {
+		cfg.Fields.ID = "id"
+	}
+	if cfg.Fields.Coordinates == "" && cfg.Fields.Lon == "" && cfg.Fields.Lat == "" {
+		cfg.Fields.Coordinates = "geometry"
+	}
+	return newMappedSpec(cfg)
+}
+
+// FeatureLayerAdapter pages through a FeatureLayerConfig's layer.
+type FeatureLayerAdapter struct {
+	client      *http.Client
+	spec        *mappedSpec
+	cfg         FeatureLayerConfig
+	pageSize    int
+	maxFeatures int
+}
+
+// NewFeatureLayerAdapter returns an adapter for cfg, which must be valid,
+// as LoadMappedSources ensures.
+func NewFeatureLayerAdapter(client *http.Client, cfg FeatureLayerConfig) (*FeatureLayerAdapter, error) {
+	spec, err := cfg.spec()
+	if err != nil {
+		return nil, err
+	}
+	a := &FeatureLayerAdapter{
+		client:      client,
+		spec:        spec,
+		cfg:         cfg,
+		pageSize:    cfg.PageSize,
+		maxFeatures: cfg.MaxFeatures,
+	}
+	if a.pageSize == 0 {
+		a.pageSize = featureLayerPageSize
+	}
+	if a.maxFeatures == 0 {
+		a.maxFeatures = featureLayerMaxFeatures
+	}
+	return a, nil
+}
+
+func (a *FeatureLayerAdapter) Source() string {
+	return a.cfg.Source
+}
+
+func (a *FeatureLayerAdapter) SupportedTypes() []string {
+	return a.spec.supportedTypes()
+}
+
+// FetchEvents ignores params and pages through the whole layer, up to
+// MaxFeatures. A feature seen on two pages, which unordered paging can
+// cause, is kept once.
+func (a *FeatureLayerAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	source := a.cfg.Source
+	var events []models.Event
+	seen := make(map[string]bool)
+	offset := 0
+	for {
+		pageURL, err := a.pageURL(offset)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		doc, err := a.spec.fetch(ctx, a.client, pageURL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: page at %d: %w", source, offset, err)
+		}
+		if msg := arcgisError(doc); msg != "" {
+			return nil, fmt.Errorf("%s: page at %d: %s", source, offset, msg)
+		}
+		items, err := a.spec.itemList(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: page at %d: %w", source, offset, err)
+		}
+
+		for _, item := range items {
+			e, ok := a.spec.event(item)
+			if !ok || seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			events = append(events, e)
+		}
+		offset += len(items)
+
+		if len(items) == 0 || !a.hasMore(doc, offset, len(items)) {
+			break
+		}
+		if offset >= a.maxFeatures {
+			slog.Warn("feature layer truncated", "source", source, "max_features", a.maxFeatures)
+			break
+		}
+	}
+	return events, nil
+}
+
+// pageURL is the query for the page starting at offset. ArcGIS pages with
+// resultOffset and resultRecordCount, WFS 2.0 with startIndex and count.
+func (a *FeatureLayerAdapter) pageURL(offset int) (string, error) {
+	base := a.cfg.URL
+	if a.cfg.Service == "arcgis" {
+		base = strings.TrimSuffix(base, "/") + "/query"
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("build request: %w", err)
+	}
+	q := u.Query()
+	count := min(a.pageSize, a.maxFeatures-offset)
+	switch a.cfg.Service {
+	case "arcgis":
+		where := a.cfg.Where
+		if where == "" {
+			where = "1=1"
+		}
+		q.Set("where", where)
+		q.Set("outFields", "*")
+		q.Set("outSR", "4326")
+		q.Set("f", "geojson")
+		q.Set("resultOffset", strconv.Itoa(offset))
+		q.Set("resultRecordCount", strconv.Itoa(count))
+	case "wfs":
+		q.Set("service", "WFS")
+		q.Set("version", "2.0.0")
+		q.Set("request", "GetFeature")
+		q.Set("typeNames", a.cfg.TypeName)
+		q.Set("outputFormat", "application/json")
+		// CRS84 is lon/lat by definition; EPSG:4326 is lat/lon in WFS 2.0.
+		q.Set("srsName", "urn:ogc:def:crs:OGC:1.3:CRS84")
+		q.Set("startIndex", strconv.Itoa(offset))
+		q.Set("count", strconv.Itoa(count))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// hasMore reports whether another page follows. ArcGIS flags a truncated
+// page with exceededTransferLimit, and may cap pages below the size asked
+// for; WFS reports numberMatched, or "unknown", in which case a full page
+// means there may be more.
+func (a *FeatureLayerAdapter) hasMore(doc any, offset, got int) bool {
+	obj, _ := doc.(map[string]any)
+	if a.cfg.Service == "arcgis" {
+		if exceeded, _ := obj["exceededTransferLimit"].(bool); exceeded {
+			return true
+		}
+		props, _ := obj["properties"].(map[string]any)
+		exceeded, _ := props["exceededTransferLimit"].(bool)
+		return exceeded
+	}
+	if matched, ok := jsonFloat(obj["numberMatched"]); ok {
+		return offset < int(matched)
+	}
+	return got >= a.pageSize
+}
+
+// arcgisError reads the error ArcGIS returns with status 200.
+func arcgisError(doc any) string {
+	obj, _ := doc.(map[string]any)
+	e, ok := obj["error"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	code, _ := jsonString(e["code"])
+	msg, _ := e["message"].(string)
+	return strings.TrimSpace("arcgis error " + code + ": " + msg)
+}
@@ -0,0 +1,262 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serveLayer serves one fixture per page, keyed by the value of the
+// paging parameter, and records every request's path and query.
+func serveLayer(t *testing.T, offsetParam string, pages map[string]string) (*httptest.Server, func() []*url.URL) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []*url.URL
+	fixtures := make(map[string][]byte, len(pages))
+	for offset, name := range pages {
+		fixtures[offset] = readFixture(t, name)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL)
+		mu.Unlock()
+		data, ok := fixtures[r.URL.Query().Get(offsetParam)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []*url.URL {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+// loadTestLayer loads the named layer from testdata/mapped_sources.json
+// and points it at url.
+func loadTestLayer(t *testing.T, source, url string) *FeatureLayerAdapter {
+	t.Helper()
+	file, err := LoadMappedSources(filepath.Join("testdata", "mapped_sources.json"))
+	if err != nil {
+		t.Fatalf("LoadMappedSources: %v", err)
+	}
+	for _, cfg := range file.Layers {
+		if cfg.Source == source {
+			cfg.URL = url
+			a, err := NewFeatureLayerAdapter(http.DefaultClient, cfg)
+			if err != nil {
+				t.Fatalf("NewFeatureLayerAdapter: %v", err)
+			}
+			return a
+		}
+	}
+	t.Fatalf("no layer %q", source)
+	return nil
+}
+
+func TestFeatureLayerArcGIS(t *testing.T) {
+	t.Parallel()
+	srv, requests := serveLayer(t, "resultOffset", map[string]string{
+		"0": "featurelayer_arcgis_1.json",
+		"2": "featurelayer_arcgis_2.json",
+	})
+	a := loadTestLayer(t, "perimeters", srv.URL+"/arcgis/rest/services/Perimeters/FeatureServer/0")
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+
+	// The server capped the first page at 2 of the 3 asked for, so the
+	// second page starts at 2.
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	q := reqs[0].Query()
+	if reqs[0].Path != "/arcgis/rest/services/Perimeters/FeatureServer/0/query" {
+		t.Errorf("path = %q", reqs[0].Path)
+	}
+	for k, want := range map[string]string{
+		"where": "poly_GISAcres > 10", "outFields": "*", "outSR": "4326", "f": "geojson",
+		"resultOffset": "0", "resultRecordCount": "3",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+
+	if got := eventIDs(events); !slices.Equal(got, []string{"perimeters-101", "perimeters-102", "perimeters-103"}) {
+		t.Fatalf("IDs = %v (a feature repeated across pages is kept once)", got)
+	}
+
+	ridge := eventByID(t, events, "perimeters-101")
+	if ridge.EventType != "wildfire" || ridge.Title != "Ridge Fire" {
+		t.Errorf("type/title = %q/%q", ridge.EventType, ridge.Title)
+	}
+	if !slices.Equal(ridge.Geometry.Coordinates, []float64{-120.4, 38.1}) {
+		t.Errorf("anchor = %v, want the polygon's centroid", ridge.Geometry.Coordinates)
+	}
+	if len(ridge.Geometry.Shapes) != 1 || ridge.Geometry.Shapes[0].Type != "Polygon" {
+		t.Errorf("shapes = %+v", ridge.Geometry.Shapes)
+	}
+	if ridge.Magnitude == nil || *ridge.Magnitude != 5230.4 {
+		t.Errorf("Magnitude = %v", ridge.Magnitude)
+	}
+	if want := time.UnixMilli(1786694400000).UTC(); !ridge.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", ridge.StartedAt, want)
+	}
+	if ridge.Metadata["irwin_id"] != "{A1B2}" {
+		t.Errorf("irwin_id = %v", ridge.Metadata["irwin_id"])
+	}
+	if got, _ := ridge.Metadata["shapes"].([]string); !slices.Equal(got, []string{"extent"}) {
+		t.Errorf("shapes metadata = %v", ridge.Metadata["shapes"])
+	}
+
+	// An unclosed ring is closed.
+	canyon := eventByID(t, events, "perimeters-102")
+	if ring := canyon.Geometry.Shapes[0].Rings[0]; len(ring) != 4 || !slices.Equal(ring[0], ring[3]) {
+		t.Errorf("ring = %v, want it closed", ring)
+	}
+	if canyon.Title != "Canyon Fire" {
+		t.Errorf("Title = %q, want the first page's", canyon.Title)
+	}
+
+	// A multi-polygon keeps every part, holes included, and is anchored
+	// at its largest.
+	lake := eventByID(t, events, "perimeters-103")
+	if len(lake.Geometry.Shapes) != 2 || len(lake.Geometry.Shapes[1].Rings) != 2 {
+		t.Fatalf("shapes = %+v", lake.Geometry.Shapes)
+	}
+	if !slices.Equal(lake.Geometry.Coordinates, []float64{-120.5, 41.5}) {
+		t.Errorf("anchor = %v, want the larger part's centroid", lake.Geometry.Coordinates)
+	}
+}
+
+func TestFeatureLayerWFS(t *testing.T) {
+	t.Parallel()
+	srv, requests := serveLayer(t, "startIndex", map[string]string{
+		"0": "featurelayer_wfs_1.json",
+		"2": "featurelayer_wfs_2.json",
+	})
+	a := loadTestLayer(t, "flood-zones", srv.URL+"/geoserver/wfs")
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+
+	// numberMatched says the second page is the last.
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	q := reqs[0].Query()
+	for k, want := range map[string]string{
+		"service": "WFS", "request": "GetFeature", "typeNames": "civil:flood_zones",
+		"outputFormat": "application/json", "srsName": "urn:ogc:def:crs:OGC:1.3:CRS84",
+		"startIndex": "0", "count": "2",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if got := reqs[1].Query().Get("startIndex"); got != "2" {
+		t.Errorf("second startIndex = %q", got)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events: %v", len(events), eventIDs(events))
+	}
+	zone := eventByID(t, events, "flood-zones-flood_zones.1")
+	if zone.EventType != "flood" || zone.Severity != "extreme" || zone.Title != "Adige basso" {
+		t.Errorf("zone = %q, %q, %q", zone.EventType, zone.Severity, zone.Title)
+	}
+	river := eventByID(t, events, "flood-zones-flood_zones.2")
+	if !slices.Equal(river.Geometry.Coordinates, []float64{12.1, 44.1}) || river.Geometry.Shapes[0].Type != "LineString" {
+		t.Errorf("line geometry = %+v, want anchored at its middle vertex", river.Geometry)
+	}
+	point := eventByID(t, events, "flood-zones-flood_zones.3")
+	if len(point.Geometry.Shapes) != 0 || !slices.Equal(point.Geometry.Coordinates, []float64{13, 43}) {
+		t.Errorf("point geometry = %+v", point.Geometry)
+	}
+}
+
+func TestFeatureLayerMaxFeatures(t *testing.T) {
+	t.Parallel()
+	srv, requests := serveLayer(t, "startIndex", map[string]string{
+		"0": "featurelayer_wfs_1.json",
+		"2": "featurelayer_wfs_2.json",
+	})
+	a := loadTestLayer(t, "flood-zones", srv.URL)
+	a.maxFeatures = 2
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 2 || len(requests()) != 1 {
+		t.Errorf("got %d events in %d requests, want 2 in 1", len(events), len(requests()))
+	}
+}
+
+func TestFeatureLayerErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"status", http.StatusServiceUnavailable, "", "page at 0: unexpected status 503"},
+		{"arcgis error", http.StatusOK, `{"error": {"code": 400, "message": "Invalid query"}}`, "arcgis error 400: Invalid query"},
+		{"no features", http.StatusOK, `{"type": "FeatureCollection"}`, `no item list at "features"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := serveRaw(t, tt.status, tt.body)
+			a := loadTestLayer(t, "perimeters", srv.URL)
+			_, err := a.FetchEvents(context.Background(), FetchParams{})
+			if err == nil || !strings.HasPrefix(err.Error(), "perimeters: ") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want perimeters: ...%s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFeatureLayerConfigRejects(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		layer   map[string]any
+		wantErr string
+	}{
+		{"no service", map[string]any{}, "service must be arcgis or wfs"},
+		{"wfs without type", map[string]any{"service": "wfs"}, "need type_name"},
+		{"arcgis with type", map[string]any{"service": "arcgis", "type_name": "x"}, "type_name is for wfs"},
+		{"wfs with where", map[string]any{"service": "wfs", "type_name": "x", "where": "1=1"}, "where is for arcgis"},
+		{"negative page", map[string]any{"service": "arcgis", "page_size": -1}, "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.layer["source"] = "layer"
+			tt.layer["url"] = "https://example.org/FeatureServer/0"
+			_, err := LoadMappedSources(writeMappedLayers(t, tt.layer))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
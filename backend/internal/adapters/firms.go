@@ -0,0 +1,403 @@
+package adapters
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const firmsBaseURL = "https://firms.modaps.eosdis.nasa.gov/api/area/csv"
+
+const (
+	// firmsDefaultWindow is how far back a fetch without Since reaches. Two
+	// days keeps yesterday's overpasses, as each satellite sees a given
+	// fire only a couple of times a day.
+	firmsDefaultWindow = 2 * 24 * time.Hour
+	// firmsMaxDayRange is the most days the area API returns per request.
+	firmsMaxDayRange = 10
+	// firmsClusterKm is how close two detections must be to belong to one
+	// fire complex. VIIRS pixels are 375 m and MODIS pixels 1 km at nadir,
+	// growing toward the swath edge, so 2 km links adjacent pixels of
+	// either without merging separate fires.
+	firmsClusterKm = 2.0
+)
+
+// firmsProducts are the near-real-time FIRMS products fetched, all
+// clustered together: one fire is usually seen by several satellites.
+var firmsProducts = []string{"VIIRS_NOAA20_NRT", "VIIRS_SNPP_NRT", "MODIS_NRT"}
+
+// FIRMSAdapter reports satellite active-fire detections from NASA FIRMS.
+// The feed holds one row per hot pixel, tens of thousands a day, so
+// adjacent detections are clustered into one wildfire event per fire
+// complex. Requests need a free FIRMS MAP_KEY.
+type FIRMSAdapter struct {
+	client  *http.Client
+	baseURL string
+	mapKey  string
+}
+
+func NewFIRMSAdapter(client *http.Client, mapKey string) *FIRMSAdapter {
+	return &FIRMSAdapter{client: client, baseURL: firmsBaseURL, mapKey: mapKey}
+}
+
+func (a *FIRMSAdapter) Source() string {
+	return "firms"
+}
+
+func (a *FIRMSAdapter) SupportedTypes() []string {
+	return []string{"wildfire"}
+}
+
+func (a *FIRMSAdapter) Window() time.Duration {
+	return firmsDefaultWindow
+}
+
+func (a *FIRMSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	now := time.Now().UTC()
+	start := now.Add(-firmsDefaultWindow)
+	if !params.Since.IsZero() {
+		start = params.Since.UTC()
+	}
+
+	var detections []firmsDetection
+	for _, product := range firmsProducts {
+		// The area API serves at most firmsMaxDayRange days per request,
+		// each range counted forward from its start date.
+		for day := start.Truncate(24 * time.Hour); !day.After(now); day = day.AddDate(0, 0, firmsMaxDayRange) {
+			days := min(firmsMaxDayRange, int(now.Sub(day)/(24*time.Hour))+1)
+			got, err := a.fetchProduct(ctx, product, day, days)
+			if err != nil {
+				return nil, err
+			}
+			detections = append(detections, got...)
+		}
+	}
+
+	// Day ranges are whole days; drop what precedes the window itself.
+	detections = slices.DeleteFunc(detections, func(d firmsDetection) bool {
+		return d.acquired.Before(start)
+	})
+	return clusterFIRMSDetections(detections), nil
+}
+
+func (a *FIRMSAdapter) fetchProduct(ctx context.Context, product string, day time.Time, days int) ([]firmsDetection, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/world/%d/%s", a.baseURL, a.mapKey, product, days, day.Format("2006-01-02"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("firms: build request: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		// The URL embeds the MAP_KEY; keep it out of logs and responses.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("firms: %s: request failed: %w", product, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("firms: %s: unexpected status %d", product, resp.StatusCode)
+	}
+
+	detections, err := parseFIRMSCSV(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("firms: %s: %w", product, err)
+	}
+	return detections, nil
+}
+
+// firmsDetection is one hot pixel from a FIRMS CSV row.
+type firmsDetection struct {
+	lat, lon   float64
+	acquired   time.Time
+	frp        float64 // fire radiative power, MW
+	brightness float64 // brightness temperature, K
+	scan       float64 // pixel size along scan, km
+	track      float64 // pixel size along track, km
+	confidence string  // low, nominal or high
+	satellite  string
+	instrument string
+	daynight   string
+}
+
+// parseFIRMSCSV reads a FIRMS area CSV. Columns are looked up by header
+// name because VIIRS and MODIS products order and name them differently
+// (bright_ti4 versus brightness, for one). Malformed rows are skipped: a
+// single bad line should not hide every other fire.
+func parseFIRMSCSV(r io.Reader) ([]firmsDetection, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"latitude", "longitude", "acq_date", "acq_time"} {
+		if _, ok := col[required]; !ok {
+			// FIRMS answers a bad MAP_KEY or product with 200 and a
+			// plain-text message instead of CSV.
+			return nil, fmt.Errorf("unexpected response: no %s column", required)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	number := func(rec []string, names ...string) float64 {
+		for _, name := range names {
+			if v, err := strconv.ParseFloat(field(rec, name), 64); err == nil {
+				return v
+			}
+		}
+		return 0
+	}
+
+	var detections []firmsDetection
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				continue
+			}
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+
+		lat, errLat := strconv.ParseFloat(field(rec, "latitude"), 64)
+		lon, errLon := strconv.ParseFloat(field(rec, "longitude"), 64)
+		acquired, errTime := parseFIRMSTime(field(rec, "acq_date"), field(rec, "acq_time"))
+		if errLat != nil || errLon != nil || errTime != nil {
+			continue
+		}
+		detections = append(detections, firmsDetection{
+			lat:        lat,
+			lon:        lon,
+			acquired:   acquired,
+			frp:        number(rec, "frp"),
+			brightness: number(rec, "bright_ti4", "brightness"),
+			scan:       number(rec, "scan"),
+			track:      number(rec, "track"),
+			confidence: firmsConfidence(field(rec, "confidence")),
+			satellite:  field(rec, "satellite"),
+			instrument: field(rec, "instrument"),
+			daynight:   field(rec, "daynight"),
+		})
+	}
+	return detections, nil
+}
+
+// parseFIRMSTime combines acq_date and acq_time, an HHMM UTC time that
+// FIRMS writes without leading zeros ("312" for 03:12).
+func parseFIRMSTime(date, hhmm string) (time.Time, error) {
+	if len(hhmm) < 4 {
+		hhmm = strings.Repeat("0", 4-len(hhmm)) + hhmm
+	}
+	return time.Parse("2006-01-02 1504", date+" "+hhmm)
+}
+
+// firmsConfidence maps both products' confidence onto VIIRS's classes.
+// VIIRS reports l, n or h; MODIS a percentage, which FIRMS documents as
+// low below 30 and high from 80.
+func firmsConfidence(v string) string {
+	switch strings.ToLower(v) {
+	case "l", "low":
+		return "low"
+	case "n", "nominal":
+		return "nominal"
+	case "h", "high":
+		return "high"
+	}
+	pct, err := strconv.ParseFloat(v, 64)
+	switch {
+	case err != nil:
+		return ""
+	case pct < 30:
+		return "low"
+	case pct < 80:
+		return "nominal"
+	default:
+		return "high"
+	}
+}
+
+var firmsConfidenceRank = map[string]int{"": 0, "low": 1, "nominal": 2, "high": 3}
+
+// clusterFIRMSDetections groups detections into fire complexes: connected
+// components of detections within firmsClusterKm of one another. A grid
+// of roughly firmsClusterKm cells limits each comparison to neighbours.
+func clusterFIRMSDetections(detections []firmsDetection) []models.Event {
+	const cellDeg = firmsClusterKm / 111.32
+
+	parent := make([]int, len(detections))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	type cell struct{ x, y int }
+	grid := make(map[cell][]int)
+	for i, d := range detections {
+		c := cell{int(math.Floor(d.lon / cellDeg)), int(math.Floor(d.lat / cellDeg))}
+		// A degree of longitude shrinks toward the poles, so a cluster
+		// radius spans more cells east–west there.
+		dx := int(math.Ceil(1 / math.Max(math.Cos(d.lat*math.Pi/180), 0.05)))
+		for x := c.x - dx; x <= c.x+dx; x++ {
+			for y := c.y - 1; y <= c.y+1; y++ {
+				for _, j := range grid[cell{x, y}] {
+					if distanceKm(d.lat, d.lon, detections[j].lat, detections[j].lon) <= firmsClusterKm {
+						parent[find(i)] = find(j)
+					}
+				}
+			}
+		}
+		grid[c] = append(grid[c], i)
+	}
+
+	members := make(map[int][]firmsDetection)
+	var roots []int
+	for i, d := range detections {
+		r := find(i)
+		if _, seen := members[r]; !seen {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], d)
+	}
+
+	events := make([]models.Event, 0, len(roots))
+	for _, r := range roots {
+		events = append(events, firmsClusterEvent(members[r]))
+	}
+	return events
+}
+
+func firmsClusterEvent(ds []firmsDetection) models.Event {
+	first, last := ds[0], ds[0]
+	var frpTotal, frpMax, brightMax, scanSum, trackSum, wLat, wLon float64
+	confidence := ""
+	satellites := map[string]struct{}{}
+	instruments := map[string]struct{}{}
+	for _, d := range ds {
+		if d.acquired.Before(first.acquired) ||
+			(d.acquired.Equal(first.acquired) && (d.lat < first.lat || (d.lat == first.lat && d.lon < first.lon))) {
+			first = d
+		}
+		if d.acquired.After(last.acquired) {
+			last = d
+		}
+		frpTotal += d.frp
+		frpMax = math.Max(frpMax, d.frp)
+		brightMax = math.Max(brightMax, d.brightness)
+		scanSum += d.scan
+		trackSum += d.track
+		if firmsConfidenceRank[d.confidence] > firmsConfidenceRank[confidence] {
+			confidence = d.confidence
+		}
+		if d.satellite != "" {
+			satellites[d.satellite] = struct{}{}
+		}
+		if d.instrument != "" {
+			instruments[d.instrument] = struct{}{}
+		}
+		// Weighted by FRP so the point sits on the most intense burning;
+		// the +1 keeps zero-FRP detections from dropping out entirely.
+		w := d.frp + 1
+		wLat += d.lat * w
+		wLon += d.lon * w
+	}
+	weight := frpTotal + float64(len(ds))
+	lat, lon := roundTo(wLat/weight, 4), roundTo(wLon/weight, 4)
+	n := float64(len(ds))
+
+	title := "Active fire detection"
+	if len(ds) > 1 {
+		title = fmt.Sprintf("Fire complex (%d detections)", len(ds))
+	}
+	frp := roundTo(frpTotal, 1)
+
+	return models.Event{
+		// Keyed on the earliest detection, which stays in the cluster for
+		// as long as it is within the window.
+		ID: fmt.Sprintf("firms-%s-%.3f-%.3f",
+			first.acquired.Format("200601021504"), first.lat, first.lon),
+		Title:     title,
+		EventType: "wildfire",
+		Source:    "firms",
+		Geometry: models.Geometry{
+			Type:        "Point",
+			Coordinates: []float64{lon, lat},
+		},
+		Magnitude: &frp,
+		StartedAt: first.acquired,
+		UpdatedAt: last.acquired,
+		URL:       fmt.Sprintf("https://firms.modaps.eosdis.nasa.gov/map/#d:24hrs;@%.4f,%.4f,10.0z", lon, lat),
+		Metadata: map[string]any{
+			"detections":     len(ds),
+			"frp_total_mw":   frp,
+			"frp_max_mw":     roundTo(frpMax, 1),
+			"brightness_max": roundTo(brightMax, 1),
+			"confidence":     confidence,
+			"satellites":     sortedKeys(satellites),
+			"instruments":    sortedKeys(instruments),
+			"scan_km":        roundTo(scanSum/n, 2),
+			"track_km":       roundTo(trackSum/n, 2),
+			"daynight":       last.daynight,
+		},
+	}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+// distanceKm is the great-circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
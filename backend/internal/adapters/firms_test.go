@@ -0,0 +1,287 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const firmsTestKey = "secret-map-key"
+
+// firmsFixtureDay is the acquisition date of every fixture row.
+var firmsFixtureDay = time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+
+// serveFIRMS answers area requests for the fixture day with the fixture of
+// the requested product, and every other request with an empty CSV. The
+// paths of all requests are recorded in paths.
+func serveFIRMS(t *testing.T, paths *[]string) *httptest.Server {
+	t.Helper()
+	fixtures := map[string][]byte{
+		"VIIRS_NOAA20_NRT": readFixture(t, "firms_viirs.csv"),
+		"MODIS_NRT":        readFixture(t, "firms_modis.csv"),
+	}
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if paths != nil {
+			*paths = append(*paths, r.URL.Path)
+		}
+		mu.Unlock()
+
+		// /<key>/<product>/world/<days>/<date>
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		w.Header().Set("Content-Type", "text/csv")
+		if len(parts) == 5 && parts[4] == firmsFixtureDay.Format("2006-01-02") {
+			if data, ok := fixtures[parts[1]]; ok {
+				w.Write(data)
+				return
+			}
+		}
+		w.Write([]byte("latitude,longitude,acq_date,acq_time\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestFIRMS(t *testing.T, srv *httptest.Server) *FIRMSAdapter {
+	t.Helper()
+	a := NewFIRMSAdapter(srv.Client(), firmsTestKey)
+	a.baseURL = srv.URL
+	return a
+}
+
+func TestFIRMSSourceAndSupportedTypes(t *testing.T) {
+	t.Parallel()
+	a := NewFIRMSAdapter(nil, firmsTestKey)
+	if got := a.Source(); got != "firms" {
+		t.Errorf("Source() = %q, want %q", got, "firms")
+	}
+	if got := a.SupportedTypes(); !slices.Equal(got, []string{"wildfire"}) {
+		t.Errorf("SupportedTypes() = %v, want [wildfire]", got)
+	}
+}
+
+func TestFIRMSFetchEventsClustersDetections(t *testing.T) {
+	t.Parallel()
+	a := newTestFIRMS(t, serveFIRMS(t, nil))
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{Since: firmsFixtureDay})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3 (one complex, two lone fires); IDs: %v", len(events), eventIDs(events))
+	}
+
+	t.Run("adjacent detections across satellites form one complex", func(t *testing.T) {
+		e := eventByID(t, events, "firms-202608090912-38.500--120.500")
+		if e.Title != "Fire complex (4 detections)" {
+			t.Errorf("Title = %q", e.Title)
+		}
+		if e.EventType != "wildfire" || e.Source != "firms" {
+			t.Errorf("EventType, Source = %q, %q; want wildfire, firms", e.EventType, e.Source)
+		}
+		if e.Magnitude == nil || *e.Magnitude != 70 {
+			t.Errorf("Magnitude = %v, want 70 (total FRP)", e.Magnitude)
+		}
+		if want := time.Date(2026, 8, 9, 9, 12, 0, 0, time.UTC); !e.StartedAt.Equal(want) {
+			t.Errorf("StartedAt = %v, want %v (earliest detection)", e.StartedAt, want)
+		}
+		if want := time.Date(2026, 8, 9, 20, 30, 0, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want %v (latest detection)", e.UpdatedAt, want)
+		}
+		// FRP-weighted, so pulled toward the 30 MW MODIS pixel to the north.
+		if lon, lat := e.Geometry.Coordinates[0], e.Geometry.Coordinates[1]; lat <= 38.504 || lat >= 38.51 || lon > -120.49 || lon < -120.51 {
+			t.Errorf("Coordinates = %v, want a point inside the complex weighted north", e.Geometry.Coordinates)
+		}
+		m := e.Metadata
+		if m["detections"] != 4 {
+			t.Errorf("detections = %v, want 4", m["detections"])
+		}
+		if m["frp_max_mw"] != 30.0 {
+			t.Errorf("frp_max_mw = %v, want 30", m["frp_max_mw"])
+		}
+		if m["confidence"] != "high" {
+			t.Errorf("confidence = %v, want high (the best of the members)", m["confidence"])
+		}
+		if got, _ := m["satellites"].([]string); !slices.Equal(got, []string{"N20", "Terra"}) {
+			t.Errorf("satellites = %v, want [N20 Terra]", m["satellites"])
+		}
+		if got, _ := m["instruments"].([]string); !slices.Equal(got, []string{"MODIS", "VIIRS"}) {
+			t.Errorf("instruments = %v, want [MODIS VIIRS]", m["instruments"])
+		}
+		if m["scan_km"] != 0.55 || m["track_km"] != 0.53 {
+			t.Errorf("scan_km, track_km = %v, %v; want means 0.55, 0.53", m["scan_km"], m["track_km"])
+		}
+		if m["brightness_max"] != 355.2 {
+			t.Errorf("brightness_max = %v, want 355.2", m["brightness_max"])
+		}
+	})
+
+	t.Run("lone detection", func(t *testing.T) {
+		e := eventByID(t, events, "firms-202608090312--33.900-150.100")
+		if e.Title != "Active fire detection" {
+			t.Errorf("Title = %q", e.Title)
+		}
+		if !slices.Equal(e.Geometry.Coordinates, []float64{150.1, -33.9}) {
+			t.Errorf("Coordinates = %v, want [150.1 -33.9]", e.Geometry.Coordinates)
+		}
+		if e.Metadata["confidence"] != "nominal" || e.Metadata["daynight"] != "D" {
+			t.Errorf("confidence, daynight = %v, %v; want nominal, D", e.Metadata["confidence"], e.Metadata["daynight"])
+		}
+	})
+
+	t.Run("MODIS percentage confidence is classed", func(t *testing.T) {
+		e := eventByID(t, events, "firms-202608091845-38.600--120.500")
+		if e.Metadata["confidence"] != "low" {
+			t.Errorf("confidence = %v, want low for 20%%", e.Metadata["confidence"])
+		}
+	})
+}
+
+func TestFIRMSSinceDropsEarlierDetections(t *testing.T) {
+	t.Parallel()
+	a := newTestFIRMS(t, serveFIRMS(t, nil))
+
+	since := firmsFixtureDay.Add(10 * time.Hour)
+	events, err := a.FetchEvents(context.Background(), FetchParams{Since: since})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	for _, e := range events {
+		if e.StartedAt.Before(since) {
+			t.Errorf("event %s started %v, before since %v", e.ID, e.StartedAt, since)
+		}
+	}
+	e := eventByID(t, events, "firms-202608091845-38.510--120.500")
+	if e.Metadata["detections"] != 2 {
+		t.Errorf("detections = %v, want 2 (only the complex's later pixels)", e.Metadata["detections"])
+	}
+}
+
+func TestFIRMSRequestPaths(t *testing.T) {
+	t.Parallel()
+	var paths []string
+	a := newTestFIRMS(t, serveFIRMS(t, &paths))
+
+	since := time.Now().UTC().AddDate(0, 0, -12)
+	if _, err := a.FetchEvents(context.Background(), FetchParams{Since: since}); err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	// 13 days per product, split into ranges of at most 10.
+	if len(paths) != 2*len(firmsProducts) {
+		t.Fatalf("got %d requests, want %d: %v", len(paths), 2*len(firmsProducts), paths)
+	}
+	first := "/" + firmsTestKey + "/VIIRS_NOAA20_NRT/world/10/" + since.Format("2006-01-02")
+	if paths[0] != first {
+		t.Errorf("first request = %q, want %q", paths[0], first)
+	}
+	second := "/" + firmsTestKey + "/VIIRS_NOAA20_NRT/world/3/" + since.AddDate(0, 0, 10).Format("2006-01-02")
+	if paths[1] != second {
+		t.Errorf("second request = %q, want %q", paths[1], second)
+	}
+}
+
+func TestFIRMSErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("non-200", func(t *testing.T) {
+		a := newTestFIRMS(t, serveRaw(t, 500, "internal error"))
+		_, err := a.FetchEvents(context.Background(), FetchParams{})
+		if err == nil || !strings.Contains(err.Error(), "unexpected status 500") {
+			t.Errorf("error = %v, want mention of status 500", err)
+		}
+	})
+
+	t.Run("plain-text answer instead of CSV", func(t *testing.T) {
+		a := newTestFIRMS(t, serveRaw(t, 200, "Invalid MAP_KEY."))
+		_, err := a.FetchEvents(context.Background(), FetchParams{})
+		if err == nil || !strings.Contains(err.Error(), "unexpected response") {
+			t.Errorf("error = %v, want unexpected response", err)
+		}
+	})
+
+	t.Run("connection error hides the map key", func(t *testing.T) {
+		srv := serveRaw(t, 200, "")
+		a := newTestFIRMS(t, srv)
+		srv.Close()
+		_, err := a.FetchEvents(context.Background(), FetchParams{})
+		if err == nil {
+			t.Fatal("expected error for a closed server")
+		}
+		if strings.Contains(err.Error(), firmsTestKey) {
+			t.Errorf("error %q leaks the map key", err)
+		}
+	})
+}
+
+func TestFIRMSConfidence(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		in, want string
+	}{
+		{"l", "low"},
+		{"n", "nominal"},
+		{"h", "high"},
+		{"0", "low"},
+		{"29", "low"},
+		{"30", "nominal"},
+		{"79", "nominal"},
+		{"80", "high"},
+		{"100", "high"},
+		{"", ""},
+		{"x", ""},
+	}
+	for _, tc := range cases {
+		if got := firmsConfidence(tc.in); got != tc.want {
+			t.Errorf("firmsConfidence(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseFIRMSTime(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		hhmm string
+		want time.Time
+	}{
+		{"1845", time.Date(2026, 8, 9, 18, 45, 0, 0, time.UTC)},
+		{"312", time.Date(2026, 8, 9, 3, 12, 0, 0, time.UTC)},
+		{"5", time.Date(2026, 8, 9, 0, 5, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		got, err := parseFIRMSTime("2026-08-09", tc.hhmm)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("parseFIRMSTime(%q) = %v, %v; want %v", tc.hhmm, got, err, tc.want)
+		}
+	}
+}
+
+func TestClusterFIRMSDetectionsChainsAndHighLatitudes(t *testing.T) {
+	t.Parallel()
+	at := firmsFixtureDay
+	d := func(lat, lon float64) firmsDetection {
+		return firmsDetection{lat: lat, lon: lon, acquired: at, confidence: "nominal"}
+	}
+	events := clusterFIRMSDetections([]firmsDetection{
+		// A chain of 1.5 km steps: ends 4.5 km apart, still one fire.
+		d(10, 20), d(10.0135, 20), d(10.027, 20), d(10.0405, 20),
+		// At 70°N 0.04° of longitude is about 1.5 km, two grid cells apart.
+		d(70, 30), d(70, 30.04),
+		// 3 km from the chain's start: a separate fire.
+		d(9.973, 20),
+	})
+	var sizes []int
+	for _, e := range events {
+		sizes = append(sizes, e.Metadata["detections"].(int))
+	}
+	slices.Sort(sizes)
+	if !slices.Equal(sizes, []int{1, 2, 4}) {
+		t.Errorf("cluster sizes = %v, want [1 2 4]", sizes)
+	}
+}
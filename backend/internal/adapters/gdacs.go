@@ -1,18 +1,68 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
 )
 
-const gdacsBaseURL = "https://www.gdacs.org/gdacsapi/api/events/geteventlist/SEARCH"
+const (
+	gdacsBaseURL     = "https://www.gdacs.org/gdacsapi/api/events/geteventlist/SEARCH"
+	gdacsDetailsURL  = "https://www.gdacs.org/gdacsapi/api/events/geteventdata"
+	gdacsGeometryURL = "https://www.gdacs.org/gdacsapi/api/polygons/getgeometry"
+)
+
+// gdacsPageSize is the number of events asked for per page of the list,
+// the most GDACS returns; gdacsMaxPages bounds the pages of one fetch.
+const (
+	gdacsPageSize = 100
+	gdacsMaxPages = 50
+)
+
+// gdacsMaxBytes bounds an episode's details or geometry; flood extents
+// are the largest, at a few MB.
+const gdacsMaxBytes = 32 << 20
+
+// gdacsFetchConcurrency bounds the episode requests in flight.
+const gdacsFetchConcurrency = 4
+
+// Enrichment is best-effort and bounded, so that a cold cache — a request
+// or two for every listed event, and one per older episode — never costs
+// the list itself. gdacsEnrichTimeout caps the time spent on episodes in
+// one fetch, within the caller's deadline; gdacsMaxGeometryFetches and
+// gdacsMaxHistoryFetches cap the geometry and older-episode requests one
+// fetch may make. What is skipped is fetched by later polls, since
+// everything fetched is cached.
+const (
+	gdacsEnrichTimeout      = 20 * time.Second
+	gdacsMaxGeometryFetches = 40
+	gdacsMaxHistoryFetches  = 100
+)
+
+// gdacsEpisodeRetention is how long an episode no fetch has listed stays
+// cached. An episode never changes once issued, so entries are only
+// dropped for memory; the day outlasts a historical query's events being
+// asked for again.
+const gdacsEpisodeRetention = 24 * time.Hour
+
+// gdacsDefaultWindow is how far back a fetch without Since reaches.
+const gdacsDefaultWindow = 30 * 24 * time.Hour
 
 // GDACS event type codes -> our event type
 var gdacsEventTypeMap = map[string]string{
@@ -34,13 +84,43 @@ var eventTypeToGDACS = map[string]string{
 	"wildfire":   "WF",
 }
 
+// GDACSAdapter reports GDACS events, each enriched from its current
+// episode: affected countries, exposure figures, the alert history of its
+// episodes, and the episode's geometry, such as a cyclone's wind buffers
+// or a flood's extent.
 type GDACSAdapter struct {
-	client  *http.Client
-	baseURL string
+	client      *http.Client
+	baseURL     string
+	detailsURL  string
+	geometryURL string
+	pageSize    int
+	now         func() time.Time
+
+	enrichTimeout      time.Duration
+	maxGeometryFetches int32
+	maxHistoryFetches  int32
+
+	// episodes caches fetched episodes by gdacsEpisodeKey. An episode is
+	// a fixed report, so each is fetched once however often the list is
+	// polled; only a new episode costs requests.
+	mu       sync.Mutex
+	episodes map[string]*gdacsEpisode
 }
 
 func NewGDACSAdapter(client *http.Client) *GDACSAdapter {
-	return &GDACSAdapter{client: client, baseURL: gdacsBaseURL}
+	return &GDACSAdapter{
+		client:      client,
+		baseURL:     gdacsBaseURL,
+		detailsURL:  gdacsDetailsURL,
+		geometryURL: gdacsGeometryURL,
+		pageSize:    gdacsPageSize,
+		now:         time.Now,
+		episodes:    make(map[string]*gdacsEpisode),
+
+		enrichTimeout:      gdacsEnrichTimeout,
+		maxGeometryFetches: gdacsMaxGeometryFetches,
+		maxHistoryFetches:  gdacsMaxHistoryFetches,
+	}
 }
 
 func (a *GDACSAdapter) Source() string {
@@ -53,13 +133,14 @@ func (a *GDACSAdapter) SupportedTypes() []string {
 	return []string{"earthquake", "cyclone", "flood", "volcano", "drought", "wildfire", "other"}
 }
 
-func (a *GDACSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("gdacs: build request: %w", err)
-	}
+func (a *GDACSAdapter) Window() time.Duration {
+	return gdacsDefaultWindow
+}
 
-	q := req.URL.Query()
+// FetchEvents pages through the event list, since GDACS returns at most
+// a page of events per request and a busy month lists more.
+func (a *GDACSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	q := url.Values{}
 
 	if len(params.Types) > 0 {
 		var codes []string
@@ -73,18 +154,75 @@ func (a *GDACSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]m
 		}
 	}
 
+	// GDACS dates are UTC days; a local date would shift the window by
+	// a day for servers far from UTC.
+	now := a.now().UTC()
+	since := now.Add(-gdacsDefaultWindow)
 	if !params.Since.IsZero() {
-		q.Set("fromdate", params.Since.Format("2006-01-02"))
-	} else {
-		q.Set("fromdate", time.Now().AddDate(0, 0, -30).Format("2006-01-02"))
+		since = params.Since.UTC()
+	}
+	q.Set("fromdate", since.Format("2006-01-02"))
+	q.Set("todate", now.Format("2006-01-02"))
+	q.Set("pagesize", strconv.Itoa(a.pageSize))
+
+	var features []gdacsFeature
+	seen := make(map[string]bool)
+	noContent := false
+	for page := 1; ; page++ {
+		if page > gdacsMaxPages {
+			slog.Warn("gdacs: event list truncated", "max_pages", gdacsMaxPages, "page_size", a.pageSize)
+			break
+		}
+		q.Set("pagenumber", strconv.Itoa(page))
+		result, err := a.fetchPage(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("gdacs: page %d: %w", page, err)
+		}
+		if result == nil {
+			// No content: the previous page was the last, or there
+			// were no events at all.
+			noContent = page == 1
+			break
+		}
+		// The list can shift while it is paged, repeating an event
+		// across a page boundary.
+		for _, f := range result.Features {
+			key := f.Properties.EventType + "-" + gdacsID(f.Properties.EventID)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			features = append(features, f)
+		}
+		if len(result.Features) < a.pageSize {
+			break
+		}
+	}
+	if noContent {
+		return nil, nil
 	}
-	q.Set("todate", time.Now().Format("2006-01-02"))
 
-	req.URL.RawQuery = q.Encode()
+	events := make([]models.Event, 0, len(features))
+	for _, f := range features {
+		e := parseGDACSFeature(f)
+		events = append(events, e)
+	}
+	a.enrich(ctx, features, events)
+
+	return events, nil
+}
+
+// fetchPage fetches one page of the event list, nil when GDACS answers
+// No Content, as it does past the last page.
+func (a *GDACSAdapter) fetchPage(ctx context.Context, q url.Values) (*gdacsResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
 
 	resp, err := a.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("gdacs: request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -93,21 +231,297 @@ func (a *GDACSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]m
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("gdacs: unexpected status %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
 	}
 
 	var result gdacsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("gdacs: decode response: %w", err)
+		return nil, fmt.Errorf("decode response: %w", err)
 	}
+	return &result, nil
+}
 
-	events := make([]models.Event, 0, len(result.Features))
-	for _, f := range result.Features {
-		e := parseGDACSFeature(f)
-		events = append(events, e)
+// gdacsEpisode is what an episode adds to its event. Geometry is fetched
+// only for an event's current episode; earlier ones contribute their
+// alert to the history.
+type gdacsEpisode struct {
+	details gdacsDetails
+	// shapes and shapeNames are the episode's geometry, if loaded.
+	shapes     []models.Shape
+	shapeNames []string
+	geometry   bool
+	used       time.Time
+}
+
+func gdacsEpisodeKey(eventType, eventID, episodeID string) string {
+	return eventType + "-" + eventID + "-" + episodeID
+}
+
+// gdacsRefresh is one enrichment pass: its time, and how many geometry
+// and older-episode requests it may still make.
+type gdacsRefresh struct {
+	now        time.Time
+	geometries atomic.Int32
+	history    atomic.Int32
+}
+
+// take reports whether budget allows one more request, using it up.
+func (r *gdacsRefresh) take(budget *atomic.Int32) bool {
+	return budget.Add(-1) >= 0
+}
+
+// errGDACSOverBudget is an older episode left for a later poll.
+var errGDACSOverBudget = errors.New("over this fetch's request budget")
+
+// enrich adds each event's episode details and geometry, best-effort: an
+// episode that cannot be read in time or within budget is logged and its
+// event reported as the list has it, or with what is cached. The list is
+// what matters, and the next poll picks up where this one stopped.
+// Current episodes are all fetched before any older one, so that history
+// only uses what time the events themselves leave.
+func (a *GDACSAdapter) enrich(ctx context.Context, features []gdacsFeature, events []models.Event) {
+	ctx, cancel := context.WithTimeout(ctx, a.enrichTimeout)
+	defer cancel()
+	r := &gdacsRefresh{now: a.now()}
+	r.geometries.Store(a.maxGeometryFetches)
+	r.history.Store(a.maxHistoryFetches)
+
+	current := make([]*gdacsEpisode, len(features))
+	var g errgroup.Group
+	g.SetLimit(gdacsFetchConcurrency)
+	for i, f := range features {
+		p := f.Properties
+		eventID, episodeID := gdacsID(p.EventID), gdacsID(p.EpisodeID)
+		if p.EventType == "" || eventID == "" || episodeID == "" {
+			continue
+		}
+		g.Go(func() error {
+			ep, err := a.episode(ctx, r, p.EventType, eventID, episodeID, true)
+			if err != nil {
+				slog.Warn("gdacs: episode unavailable", "event", events[i].ID, "episode", episodeID, "error", err)
+				return nil
+			}
+			current[i] = ep
+			return nil
+		})
 	}
+	g.Wait()
 
-	return events, nil
+	var skipped atomic.Int32
+	for i, f := range features {
+		if current[i] == nil {
+			continue
+		}
+		p := f.Properties
+		eventID, episodeID := gdacsID(p.EventID), gdacsID(p.EpisodeID)
+		g.Go(func() error {
+			history := []gdacsHistoryEntry{{episodeID, current[i]}}
+			for _, id := range current[i].details.Properties.episodeIDs() {
+				if id == episodeID {
+					continue
+				}
+				ep, err := a.episode(ctx, r, p.EventType, eventID, id, false)
+				if errors.Is(err, errGDACSOverBudget) {
+					skipped.Add(1)
+					continue
+				}
+				if err != nil {
+					slog.Warn("gdacs: episode unavailable", "event", events[i].ID, "episode", id, "error", err)
+					continue
+				}
+				history = append(history, gdacsHistoryEntry{id, ep})
+			}
+			applyGDACSEpisode(&events[i], episodeID, current[i], history)
+			return nil
+		})
+	}
+	g.Wait()
+	if n := skipped.Load(); n > 0 {
+		slog.Info("gdacs: older episodes left for later polls", "count", n)
+	}
+
+	a.mu.Lock()
+	for key, ep := range a.episodes {
+		if r.now.Sub(ep.used) > gdacsEpisodeRetention {
+			delete(a.episodes, key)
+		}
+	}
+	a.mu.Unlock()
+}
+
+// episode returns an episode from the cache, fetching what it lacks: for
+// the current episode, its details and, budget permitting, its geometry;
+// for an older one, its details if the budget allows. A current episode
+// whose geometry is over budget is returned and cached without it, and
+// the geometry is fetched by a later poll.
+func (a *GDACSAdapter) episode(ctx context.Context, r *gdacsRefresh, eventType, eventID, episodeID string, current bool) (*gdacsEpisode, error) {
+	key := gdacsEpisodeKey(eventType, eventID, episodeID)
+	a.mu.Lock()
+	cached, ok := a.episodes[key]
+	if ok {
+		cached.used = r.now
+	}
+	a.mu.Unlock()
+	if ok && (cached.geometry || !current) {
+		return cached, nil
+	}
+	if !current && !r.take(&r.history) {
+		return nil, errGDACSOverBudget
+	}
+	withGeometry := current && r.take(&r.geometries)
+	if ok && !withGeometry {
+		return cached, nil
+	}
+
+	q := url.Values{"eventtype": {eventType}, "eventid": {eventID}, "episodeid": {episodeID}}
+	ep := &gdacsEpisode{used: r.now}
+	if ok {
+		ep.details = cached.details
+	} else if err := a.getJSON(ctx, a.detailsURL, q, &ep.details); err != nil {
+		return nil, fmt.Errorf("details: %w", err)
+	}
+	if withGeometry {
+		var fc struct {
+			Features []struct {
+				Geometry   map[string]any `json:"geometry"`
+				Properties struct {
+					Class string `json:"Class"`
+				} `json:"properties"`
+			} `json:"features"`
+		}
+		// Not every episode has polygons; GDACS answers those with no
+		// content or not found.
+		if err := a.getJSON(ctx, a.geometryURL, q, &fc); err != nil && !errors.Is(err, errGDACSNoData) {
+			return nil, fmt.Errorf("geometry: %w", err)
+		}
+		for _, f := range fc.Features {
+			name := gdacsShapeName(f.Properties.Class)
+			if f.Geometry == nil || name == "" {
+				continue
+			}
+			for _, s := range geoJSONToGeometry(f.Geometry).Shapes {
+				ep.shapes = append(ep.shapes, s)
+				ep.shapeNames = append(ep.shapeNames, name)
+			}
+		}
+		ep.geometry = true
+	}
+
+	a.mu.Lock()
+	a.episodes[key] = ep
+	a.mu.Unlock()
+	return ep, nil
+}
+
+// gdacsShapeName names a geometry feature by its class: Poly_Green,
+// Poly_Orange and Poly_Red are a cyclone's wind buffers or an
+// earthquake's intensity areas, Poly_Cones a cyclone's forecast cone,
+// Poly_Affected a flood's extent, and Line_ classes tracks. Points, the
+// event's own centroid among them, are empty: the event is already
+// anchored by the list.
+func gdacsShapeName(class string) string {
+	kind, name, ok := strings.Cut(class, "_")
+	if !ok || name == "" || strings.EqualFold(kind, "Point") {
+		return ""
+	}
+	return strings.ToLower(name)
+}
+
+// errGDACSNoData is a 204 or 404 answer to an episode request.
+var errGDACSNoData = errors.New("no data")
+
+func (a *GDACSAdapter) getJSON(ctx context.Context, base string, q url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"?"+q.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return errGDACSNoData
+	default:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, gdacsMaxBytes+1))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if len(data) > gdacsMaxBytes {
+		return fmt.Errorf("response exceeds %d bytes", gdacsMaxBytes)
+	}
+	// Numbers stay json.Number, as geoJSONToGeometry reads them.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
+
+type gdacsHistoryEntry struct {
+	id      string
+	episode *gdacsEpisode
+}
+
+// applyGDACSEpisode adds the current episode's details and geometry to e,
+// and the alert of every episode so far as metadata "episodes", oldest
+// first.
+func applyGDACSEpisode(e *models.Event, episodeID string, current *gdacsEpisode, history []gdacsHistoryEntry) {
+	p := current.details.Properties
+	e.Metadata["episode_id"] = episodeID
+	if f, err := p.AlertScore.Float64(); err == nil {
+		e.Metadata["alert_score"] = f
+	}
+	if f, err := p.EpisodeAlertScore.Float64(); err == nil {
+		e.Metadata["episode_alert_score"] = f
+	}
+	var countries []string
+	for _, c := range p.AffectedCountries {
+		if iso3 := strings.ToUpper(strings.TrimSpace(c.ISO3)); iso3 != "" && !slices.Contains(countries, iso3) {
+			countries = append(countries, iso3)
+		}
+	}
+	if len(countries) > 0 {
+		e.Metadata["countries"] = countries
+	}
+	if text := strings.TrimSpace(p.SeverityData.SeverityText); text != "" {
+		e.Metadata["severity_text"] = text
+	}
+	if len(p.Impacts) > 0 {
+		e.Metadata["exposure"] = plainJSON(p.Impacts)
+	}
+
+	slices.SortFunc(history, func(x, y gdacsHistoryEntry) int {
+		xi, _ := strconv.Atoi(x.id)
+		yi, _ := strconv.Atoi(y.id)
+		return xi - yi
+	})
+	episodes := make([]map[string]any, 0, len(history))
+	for _, h := range history {
+		hp := h.episode.details.Properties
+		entry := map[string]any{
+			"episode_id":  h.id,
+			"alert_level": hp.EpisodeAlertLevel,
+		}
+		if f, err := hp.EpisodeAlertScore.Float64(); err == nil {
+			entry["alert_score"] = f
+		}
+		if t := parseGDACSTime(hp.ToDate); !t.IsZero() {
+			entry["date"] = t.Format(time.RFC3339)
+		}
+		episodes = append(episodes, entry)
+	}
+	e.Metadata["episodes"] = episodes
+
+	if len(current.shapes) > 0 && len(e.Geometry.Coordinates) >= 2 {
+		e.Geometry.Shapes = current.shapes
+		e.Metadata["shapes"] = current.shapeNames
+	}
 }
 
 func parseGDACSFeature(f gdacsFeature) models.Event {
@@ -123,23 +537,8 @@ func parseGDACSFeature(f gdacsFeature) models.Event {
 		coords = f.Geometry.Coordinates[:2]
 	}
 
-	var startedAt time.Time
-	if f.Properties.FromDate != "" {
-		if t, err := time.Parse("2006-01-02T15:04:05", f.Properties.FromDate); err == nil {
-			startedAt = t
-		} else if t, err := time.Parse(time.RFC3339, f.Properties.FromDate); err == nil {
-			startedAt = t
-		}
-	}
-
-	var updatedAt time.Time
-	if f.Properties.ToDate != "" {
-		if t, err := time.Parse("2006-01-02T15:04:05", f.Properties.ToDate); err == nil {
-			updatedAt = t
-		} else if t, err := time.Parse(time.RFC3339, f.Properties.ToDate); err == nil {
-			updatedAt = t
-		}
-	}
+	startedAt := parseGDACSTime(f.Properties.FromDate)
+	updatedAt := parseGDACSTime(f.Properties.ToDate)
 	if updatedAt.IsZero() {
 		updatedAt = startedAt
 	}
@@ -156,13 +555,8 @@ func parseGDACSFeature(f gdacsFeature) models.Event {
 		metadata["severity_value"] = f.Properties.Severity
 	}
 
-	eventID := f.Properties.EventID.String()
-	if n, err := f.Properties.EventID.Int64(); err == nil {
-		eventID = strconv.FormatInt(n, 10)
-	}
-
 	return models.Event{
-		ID:          fmt.Sprintf("gdacs-%s-%s", f.Properties.EventType, eventID),
+		ID:          fmt.Sprintf("gdacs-%s-%s", f.Properties.EventType, gdacsID(f.Properties.EventID)),
 		Title:       f.Properties.Name,
 		Description: f.Properties.Description,
 		EventType:   eventType,
@@ -179,14 +573,48 @@ func parseGDACSFeature(f gdacsFeature) models.Event {
 	}
 }
 
+// gdacsID formats a numeric or string ID.
+func gdacsID(n json.Number) string {
+	if i, err := n.Int64(); err == nil {
+		return strconv.FormatInt(i, 10)
+	}
+	return n.String()
+}
+
+// gdacsTimeLayouts are the date forms GDACS uses. Most carry no zone
+// and are UTC; parsing them in UTC, and converting the rest to it, keeps
+// every GDACS time comparable with other sources'.
+var gdacsTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseGDACSTime parses a GDACS date as UTC, zero if empty or malformed.
+func parseGDACSTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC()
+	}
+	for _, layout := range gdacsTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
 func gdacsAlertToSeverity(level string) string {
 	switch strings.ToLower(level) {
 	case "red":
-		return "extreme"
+		return models.SeverityExtreme
 	case "orange":
-		return "severe"
+		return models.SeveritySevere
 	case "green":
-		return "minor"
+		return models.SeverityMinor
 	default:
 		return ""
 	}
@@ -211,6 +639,7 @@ type gdacsGeometry struct {
 type gdacsProperties struct {
 	EventType    string      `json:"eventtype"`
 	EventID      json.Number `json:"eventid"`
+	EpisodeID    json.Number `json:"episodeid"`
 	Name         string      `json:"name"`
 	Description  string      `json:"description"`
 	AlertLevel   string      `json:"alertlevel"`
@@ -241,3 +670,43 @@ func (u *gdacsURL) UnmarshalJSON(data []byte) error {
 	*u = gdacsURL(obj)
 	return nil
 }
+
+// gdacsDetails is an episode's geteventdata response.
+type gdacsDetails struct {
+	Properties gdacsDetailsProperties `json:"properties"`
+}
+
+type gdacsDetailsProperties struct {
+	AlertScore        json.Number `json:"alertscore"`
+	EpisodeAlertLevel string      `json:"episodealertlevel"`
+	EpisodeAlertScore json.Number `json:"episodealertscore"`
+	ToDate            string      `json:"todate"`
+	AffectedCountries []struct {
+		ISO3 string `json:"iso3"`
+	} `json:"affectedcountries"`
+	SeverityData struct {
+		SeverityText string `json:"severitytext"`
+	} `json:"severitydata"`
+	// Impacts are GDACS's exposure figures, such as the population within
+	// each wind buffer or intensity, passed through as published.
+	Impacts  []any `json:"impacts"`
+	Episodes []struct {
+		Details string `json:"details"`
+	} `json:"episodes"`
+}
+
+// episodeIDs returns the IDs of the event's episodes, read from their
+// details links.
+func (p gdacsDetailsProperties) episodeIDs() []string {
+	var ids []string
+	for _, ep := range p.Episodes {
+		u, err := url.Parse(ep.Details)
+		if err != nil {
+			continue
+		}
+		if id := u.Query().Get("episodeid"); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -3,9 +3,14 @@ package adapters
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -14,9 +19,84 @@ func newTestGDACS(t *testing.T, srv *httptest.Server) *GDACSAdapter {
 	t.Helper()
 	a := NewGDACSAdapter(srv.Client())
 	a.baseURL = srv.URL
+	a.detailsURL = srv.URL + "/details"
+	a.geometryURL = srv.URL + "/geometry"
 	return a
 }
 
+// gdacsServer answers the event list with gdacs.json, and an episode's
+// details and geometry with gdacs_details_<type>_<episode>.json and
+// gdacs_geometry_<type>_<episode>.json, 404 when there is no such file.
+type gdacsServer struct {
+	*httptest.Server
+	list reqCapture
+	// failDetails makes every details request fail; hangDetails makes
+	// them hang until the client gives up.
+	failDetails atomic.Bool
+	hangDetails atomic.Bool
+
+	mu       sync.Mutex
+	requests map[string]int // episode requests by path and query
+}
+
+func serveGDACS(t *testing.T) *gdacsServer {
+	t.Helper()
+	s := &gdacsServer{requests: make(map[string]int)}
+	fixtures := readFixtures(t, "gdacs*.json")
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "gdacs.json"
+		if kind := strings.TrimPrefix(r.URL.Path, "/"); kind != "" {
+			s.mu.Lock()
+			s.requests[r.URL.Path+"?"+r.URL.RawQuery]++
+			s.mu.Unlock()
+			if kind == "details" && s.failDetails.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if kind == "details" && s.hangDetails.Load() {
+				<-r.Context().Done()
+				return
+			}
+			q := r.URL.Query()
+			name = fmt.Sprintf("gdacs_%s_%s_%s.json", kind, strings.ToLower(q.Get("eventtype")), q.Get("episodeid"))
+		} else {
+			s.list.record(r)
+		}
+		data, ok := fixtures[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// requestsTo counts the requests made to the path, such as "/geometry".
+func (s *gdacsServer) requestsTo(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for key, c := range s.requests {
+		if strings.HasPrefix(key, path+"?") {
+			n += c
+		}
+	}
+	return n
+}
+
+func (s *gdacsServer) episodeRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for _, c := range s.requests {
+		n += c
+	}
+	return n
+}
+
 func TestGDACSSourceAndSupportedTypes(t *testing.T) {
 	t.Parallel()
 	a := NewGDACSAdapter(nil)
@@ -31,8 +111,8 @@ func TestGDACSSourceAndSupportedTypes(t *testing.T) {
 
 func TestGDACSFetchEventsParsing(t *testing.T) {
 	t.Parallel()
-	srv := serveFixture(t, "gdacs.json", nil)
-	a := newTestGDACS(t, srv)
+	srv := serveGDACS(t)
+	a := newTestGDACS(t, srv.Server)
 
 	events, err := a.FetchEvents(context.Background(), FetchParams{})
 	if err != nil {
@@ -128,6 +208,377 @@ func TestGDACSFetchEventsParsing(t *testing.T) {
 	})
 }
 
+// serveGDACSPages answers list page n with gdacs_page_<n>.json, and pages
+// past the last with No Content, recording each page's query.
+func serveGDACSPages(t *testing.T) (*httptest.Server, func() []url.Values) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []url.Values
+	pages := readFixtures(t, "gdacs_page_*.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+		data, ok := pages["gdacs_page_"+r.URL.Query().Get("pagenumber")+".json"]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(queries)
+	}
+}
+
+func TestGDACSPagination(t *testing.T) {
+	t.Parallel()
+	srv, queries := serveGDACSPages(t)
+	a := newTestGDACS(t, srv)
+	a.pageSize = 2
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	// Flood B repeats across the page 1/2 boundary and is kept once.
+	want := []string{"gdacs-EQ-2001", "gdacs-FL-2002", "gdacs-WF-2003", "gdacs-VO-2004", "gdacs-DR-2005"}
+	if got := eventIDs(events); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	// Page 3 is full, so page 4 is asked for and answers No Content.
+	var pages []string
+	for _, q := range queries() {
+		pages = append(pages, q.Get("pagenumber"))
+		if q.Get("pagesize") != "2" {
+			t.Errorf("pagesize = %q, want 2", q.Get("pagesize"))
+		}
+	}
+	if want := []string{"1", "2", "3", "4"}; !slices.Equal(pages, want) {
+		t.Errorf("pages requested = %v, want %v", pages, want)
+	}
+}
+
+func TestGDACSPaginationStopsAtShortPage(t *testing.T) {
+	t.Parallel()
+	srv, queries := serveGDACSPages(t)
+	a := newTestGDACS(t, srv)
+	a.pageSize = 3
+
+	if _, err := a.FetchEvents(context.Background(), FetchParams{}); err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if got := len(queries()); got != 1 {
+		t.Errorf("requested %d pages, want 1: a page shorter than pagesize is the last", got)
+	}
+}
+
+// Every GDACS time is UTC, whatever form it comes in.
+func TestGDACSTimesUTC(t *testing.T) {
+	t.Parallel()
+	srv, _ := serveGDACSPages(t)
+	a := newTestGDACS(t, srv)
+	a.pageSize = 2
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	cases := []struct {
+		id               string
+		started, updated time.Time
+	}{
+		{"gdacs-EQ-2001", time.Date(2026, 8, 1, 3, 0, 0, 0, time.UTC), time.Date(2026, 8, 1, 4, 0, 0, 0, time.UTC)},
+		{"gdacs-FL-2002", time.Date(2026, 8, 2, 0, 0, 0, 5e8, time.UTC), time.Date(2026, 8, 5, 12, 0, 0, 25e7, time.UTC)},
+		{"gdacs-WF-2003", time.Date(2026, 8, 3, 8, 0, 0, 0, time.UTC), time.Date(2026, 8, 3, 18, 30, 0, 0, time.UTC)},
+		{"gdacs-VO-2004", time.Date(2026, 8, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 8, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		e := eventByID(t, events, tc.id)
+		if !e.StartedAt.Equal(tc.started) || e.StartedAt.Location() != time.UTC {
+			t.Errorf("%s StartedAt = %v, want %v", tc.id, e.StartedAt, tc.started)
+		}
+		if !e.UpdatedAt.Equal(tc.updated) || e.UpdatedAt.Location() != time.UTC {
+			t.Errorf("%s UpdatedAt = %v, want %v", tc.id, e.UpdatedAt, tc.updated)
+		}
+	}
+}
+
+// The query's dates are UTC days, not the server's local ones.
+func TestGDACSQueryDatesUTC(t *testing.T) {
+	t.Parallel()
+	srv, queries := serveGDACSPages(t)
+	a := newTestGDACS(t, srv)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	a.now = func() time.Time { return time.Date(2026, 8, 12, 7, 0, 0, 0, tokyo) }
+
+	since := time.Date(2026, 8, 2, 6, 0, 0, 0, tokyo)
+	if _, err := a.FetchEvents(context.Background(), FetchParams{Since: since}); err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	q := queries()[0]
+	if got := q.Get("todate"); got != "2026-08-11" {
+		t.Errorf("todate = %q, want 2026-08-11 (UTC)", got)
+	}
+	if got := q.Get("fromdate"); got != "2026-08-01" {
+		t.Errorf("fromdate = %q, want 2026-08-01 (UTC)", got)
+	}
+}
+
+func TestGDACSEpisodeEnrichment(t *testing.T) {
+	t.Parallel()
+	srv := serveGDACS(t)
+	a := newTestGDACS(t, srv.Server)
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+
+	tc := eventByID(t, events, "gdacs-TC-1000999")
+	if got := tc.Metadata["countries"]; !slices.Equal(got.([]string), []string{"BHS", "TCA"}) {
+		t.Errorf("countries = %v, want [BHS TCA]", got)
+	}
+	if got := tc.Metadata["episode_id"]; got != "3" {
+		t.Errorf("episode_id = %v, want 3", got)
+	}
+	if got := tc.Metadata["alert_score"]; got != 3.0 {
+		t.Errorf("alert_score = %v, want 3", got)
+	}
+	if got := tc.Metadata["episode_alert_score"]; got != 2.6 {
+		t.Errorf("episode_alert_score = %v, want 2.6", got)
+	}
+	if got := tc.Metadata["severity_text"]; got != "Maximum wind speed of 213 km/h" {
+		t.Errorf("severity_text = %v", got)
+	}
+	exposure, _ := tc.Metadata["exposure"].([]any)
+	if len(exposure) != 2 {
+		t.Fatalf("exposure = %v, want the two impact figures", tc.Metadata["exposure"])
+	}
+	if red := exposure[0].(map[string]any); red["class"] != "Red" || red["value"] != 120000.0 {
+		t.Errorf("exposure[0] = %v, want the Red buffer's 120000 people", red)
+	}
+
+	history, _ := tc.Metadata["episodes"].([]map[string]any)
+	var levels []string
+	for _, h := range history {
+		levels = append(levels, fmt.Sprintf("%v:%v:%v", h["episode_id"], h["alert_level"], h["alert_score"]))
+	}
+	if want := []string{"1:Green:0.5", "2:Orange:1.8", "3:Red:2.6"}; !slices.Equal(levels, want) {
+		t.Errorf("episodes = %v, want %v", levels, want)
+	}
+	if got := history[2]["date"]; got != "2026-08-11T06:00:00Z" {
+		t.Errorf("episodes[2].date = %v", got)
+	}
+
+	// The centroid point is the event's anchor, not a shape; the orange
+	// buffer's two parts are two shapes.
+	if !slices.Equal(tc.Geometry.Coordinates, []float64{-72.3, 21.8}) {
+		t.Errorf("Coordinates = %v, want the list's point", tc.Geometry.Coordinates)
+	}
+	var kinds []string
+	for _, s := range tc.Geometry.Shapes {
+		kinds = append(kinds, s.Type)
+	}
+	if want := []string{"LineString", "Polygon", "Polygon", "Polygon", "Polygon"}; !slices.Equal(kinds, want) {
+		t.Errorf("shape types = %v, want %v", kinds, want)
+	}
+	if got, want := tc.Metadata["shapes"], []string{"line_0", "green", "orange", "orange", "red"}; !slices.Equal(got.([]string), want) {
+		t.Errorf("shapes = %v, want %v", got, want)
+	}
+
+	// An earthquake without polygons is enriched all the same.
+	eq := eventByID(t, events, "gdacs-EQ-1479624")
+	if got := eq.Metadata["countries"]; !slices.Equal(got.([]string), []string{"PHL"}) {
+		t.Errorf("EQ countries = %v, want [PHL]", got)
+	}
+	if len(eq.Geometry.Shapes) != 0 {
+		t.Errorf("EQ shapes = %v, want none", eq.Geometry.Shapes)
+	}
+	if _, ok := eq.Metadata["shapes"]; ok {
+		t.Error("EQ has shapes metadata without shapes")
+	}
+
+	// The event without an episode ID is reported from the list alone.
+	if xx := eventByID(t, events, "gdacs-XX-555"); xx.Metadata["episodes"] != nil {
+		t.Errorf("XX episodes = %v, want none", xx.Metadata["episodes"])
+	}
+
+	// TC: details for 3 episodes and geometry for the current one; EQ:
+	// details and geometry. Polling again fetches nothing new.
+	if got := srv.episodeRequests(); got != 6 {
+		t.Errorf("episode requests = %d, want 6", got)
+	}
+	again, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("second FetchEvents: %v", err)
+	}
+	if got := srv.episodeRequests(); got != 6 {
+		t.Errorf("episode requests after a second poll = %d, want still 6", got)
+	}
+	if got := eventByID(t, again, "gdacs-TC-1000999").Metadata["episodes"]; len(got.([]map[string]any)) != 3 {
+		t.Errorf("cached episodes = %v, want 3", got)
+	}
+}
+
+func TestGDACSEpisodeFailureDegrades(t *testing.T) {
+	t.Parallel()
+	srv := serveGDACS(t)
+	srv.failDetails.Store(true)
+	a := newTestGDACS(t, srv.Server)
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want all 3 from the list", len(events))
+	}
+	tc := eventByID(t, events, "gdacs-TC-1000999")
+	if tc.Severity != "extreme" || tc.Metadata["countries"] != nil {
+		t.Errorf("Severity, countries = %q, %v; want the list's data only", tc.Severity, tc.Metadata["countries"])
+	}
+
+	// A failed episode isn't cached, so the next poll retries it.
+	srv.failDetails.Store(false)
+	events, err = a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if got := eventByID(t, events, "gdacs-TC-1000999").Metadata["countries"]; got == nil {
+		t.Error("countries missing after the details recovered")
+	}
+}
+
+func TestGDACSEpisodeTimeoutKeepsList(t *testing.T) {
+	t.Parallel()
+	srv := serveGDACS(t)
+	srv.hangDetails.Store(true)
+	a := newTestGDACS(t, srv.Server)
+	a.enrichTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("FetchEvents took %v, want it bounded by the enrichment timeout", elapsed)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want all 3 from the list", len(events))
+	}
+	if tc := eventByID(t, events, "gdacs-TC-1000999"); tc.Severity != "extreme" || tc.Metadata["countries"] != nil {
+		t.Errorf("Severity, countries = %q, %v; want the list's data only", tc.Severity, tc.Metadata["countries"])
+	}
+
+	// The caller's own deadline ends enrichment too, not the fetch.
+	a.enrichTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	events, err = a.FetchEvents(ctx, FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents within a deadline: %v", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("got %d events within a deadline, want all 3", len(events))
+	}
+}
+
+func TestGDACSEpisodeFetchBudget(t *testing.T) {
+	t.Parallel()
+	srv := serveGDACS(t)
+	a := newTestGDACS(t, srv.Server)
+	a.maxGeometryFetches = 0
+	a.maxHistoryFetches = 1
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if got := srv.requestsTo("/geometry"); got != 0 {
+		t.Errorf("geometry requests = %d, want none over budget", got)
+	}
+	tc := eventByID(t, events, "gdacs-TC-1000999")
+	if tc.Metadata["countries"] == nil || len(tc.Geometry.Shapes) != 0 {
+		t.Errorf("countries, shapes = %v, %v; want details without geometry", tc.Metadata["countries"], tc.Geometry.Shapes)
+	}
+	if got := tc.Metadata["episodes"].([]map[string]any); len(got) != 2 {
+		t.Errorf("episodes = %v, want the current one and one older within budget", got)
+	}
+
+	// Later polls fetch what was skipped, and only that.
+	a.maxGeometryFetches = gdacsMaxGeometryFetches
+	a.maxHistoryFetches = gdacsMaxHistoryFetches
+	events, err = a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("second FetchEvents: %v", err)
+	}
+	tc = eventByID(t, events, "gdacs-TC-1000999")
+	if len(tc.Geometry.Shapes) == 0 || len(tc.Metadata["episodes"].([]map[string]any)) != 3 {
+		t.Errorf("after a full poll: %d shapes, episodes %v; want geometry and all 3", len(tc.Geometry.Shapes), tc.Metadata["episodes"])
+	}
+	if got := srv.requestsTo("/details"); got != 4 {
+		t.Errorf("details requests = %d, want each of the 4 episodes once", got)
+	}
+}
+
+func TestGDACSEpisodeCacheExpires(t *testing.T) {
+	t.Parallel()
+	srv := serveGDACS(t)
+	a := newTestGDACS(t, srv.Server)
+	now := time.Date(2026, 8, 11, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	if _, err := a.FetchEvents(context.Background(), FetchParams{}); err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	// An episode no longer listed is dropped once it has gone unused
+	// for the retention period.
+	a.mu.Lock()
+	a.episodes[gdacsEpisodeKey("TC", "1", "1")] = &gdacsEpisode{used: now}
+	a.mu.Unlock()
+	now = now.Add(gdacsEpisodeRetention + time.Minute)
+	if _, err := a.FetchEvents(context.Background(), FetchParams{}); err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.episodes[gdacsEpisodeKey("TC", "1", "1")]; ok {
+		t.Error("unlisted episode still cached past the retention period")
+	}
+	if _, ok := a.episodes[gdacsEpisodeKey("TC", "1000999", "3")]; !ok {
+		t.Error("listed episode evicted")
+	}
+}
+
+func TestGDACSShapeName(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"Poly_Green":     "green",
+		"Poly_Cones":     "cones",
+		"Poly_Affected":  "affected",
+		"Line_Line_0":    "line_0",
+		"Point_Centroid": "",
+		"Poly_":          "",
+		"":               "",
+	}
+	for class, want := range cases {
+		if got := gdacsShapeName(class); got != want {
+			t.Errorf("gdacsShapeName(%q) = %q, want %q", class, got, want)
+		}
+	}
+}
+
 func TestGDACSNoContent(t *testing.T) {
 	t.Parallel()
 	srv := serveRaw(t, 204, "")
@@ -144,9 +595,8 @@ func TestGDACSNoContent(t *testing.T) {
 
 func TestGDACSQueryParams(t *testing.T) {
 	t.Parallel()
-	var capture reqCapture
-	srv := serveFixture(t, "gdacs.json", &capture)
-	a := newTestGDACS(t, srv)
+	srv := serveGDACS(t)
+	a := newTestGDACS(t, srv.Server)
 
 	since := time.Date(2026, 8, 1, 15, 30, 0, 0, time.UTC)
 	// "storm" has no GDACS code and must be dropped from the eventlist.
@@ -158,7 +608,7 @@ func TestGDACSQueryParams(t *testing.T) {
 		t.Fatalf("FetchEvents: %v", err)
 	}
 
-	q := capture.Query()
+	q := srv.list.Query()
 	if got := q.Get("eventlist"); got != "EQ;TC" {
 		t.Errorf("eventlist = %q, want EQ;TC", got)
 	}
@@ -168,6 +618,9 @@ func TestGDACSQueryParams(t *testing.T) {
 	if got := q.Get("todate"); got == "" {
 		t.Error("todate not set")
 	}
+	if q.Get("pagesize") != "100" || q.Get("pagenumber") != "1" {
+		t.Errorf("pagesize, pagenumber = %q, %q, want 100, 1", q.Get("pagesize"), q.Get("pagenumber"))
+	}
 }
 
 func TestGDACSAlertToSeverity(t *testing.T) {
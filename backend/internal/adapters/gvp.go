@@ -0,0 +1,222 @@
+package adapters
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const gvpBaseURL = "https://volcano.si.edu/news/WeeklyVolcanoRSS.xml"
+
+// GVPAdapter reports the Smithsonian Global Volcanism Program's weekly
+// volcanic activity report, which covers volcanoes worldwide with a summary
+// of each observatory's bulletins for the week. The RSS items are prose;
+// the alert level and aviation color code are read out of the text where
+// the report quotes them.
+type GVPAdapter struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewGVPAdapter(client *http.Client) *GVPAdapter {
+	return &GVPAdapter{client: client, baseURL: gvpBaseURL}
+}
+
+func (a *GVPAdapter) Source() string {
+	return "gvp"
+}
+
+func (a *GVPAdapter) SupportedTypes() []string {
+	return []string{"volcano"}
+}
+
+// FetchEvents ignores params: the feed holds only the latest week's report.
+func (a *GVPAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("gvp: build request: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gvp: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gvp: unexpected status %d", resp.StatusCode)
+	}
+
+	var feed gvpRSS
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("gvp: decode response: %w", err)
+	}
+
+	events := make([]models.Event, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		events = append(events, parseGVPItem(item))
+	}
+	return events, nil
+}
+
+var (
+	// "Etna (Italy)", optionally followed by " - Report for ..." and the
+	// report section.
+	gvpTitleRe  = regexp.MustCompile(`^(.+?)\s*\(([^)]+)\)\s*(?:[-–]\s*(.*))?$`)
+	gvpPeriodRe = regexp.MustCompile(`Report for ([0-9]{1,2} [A-Z][a-z]+(?: [0-9]{4})?\s*[-–]\s*[0-9]{1,2} [A-Z][a-z]+ [0-9]{4})`)
+	gvpVNumRe   = regexp.MustCompile(`(?:vn=|-)([0-9]{6})\b`)
+	gvpColorRe  = regexp.MustCompile(`(?i)Aviation Colou?r Code[^.]*?\b(Green|Yellow|Orange|Red)\b`)
+	// Numbered levels are quoted with their scale, as in "the Alert Level
+	// remained at 3 (on a scale of 1-5)" or "was raised to Level II (on a
+	// scale of I-IV)"; USGS-style named levels without one. One sentence
+	// may step through several levels.
+	gvpLevelSentenceRe = regexp.MustCompile(`(?i)Alert Level\b[^.]*`)
+	gvpLevelRe         = regexp.MustCompile(`(?i)\b(?:at|to)\s+(?:Level\s+)?([0-9]|[IV]+|Normal|Advisory|Watch|Warning)\b(\s*\([^)]*\))?`)
+	gvpScaleRe         = regexp.MustCompile(`(?i)\b(?:[0-9]|I)\s*-\s*([0-9]|[IV]+)\b`)
+	gvpTagRe           = regexp.MustCompile(`<[^>]*>`)
+)
+
+func parseGVPItem(item gvpItem) models.Event {
+	title := strings.TrimSpace(item.Title)
+	name, country, section := title, "", ""
+	if m := gvpTitleRe.FindStringSubmatch(title); m != nil {
+		name, country, section = m[1], m[2], m[3]
+	}
+	description := strings.Join(strings.Fields(html.UnescapeString(gvpTagRe.ReplaceAllString(item.Description, " "))), " ")
+
+	metadata := map[string]any{}
+	if country != "" {
+		metadata["country"] = country
+	}
+	vnum := ""
+	for _, s := range []string{item.Link, item.GUID} {
+		if m := gvpVNumRe.FindStringSubmatch(s); m != nil {
+			vnum = m[1]
+			break
+		}
+	}
+	if vnum != "" {
+		metadata["vnum"] = vnum
+	}
+	if m := gvpPeriodRe.FindStringSubmatch(title + " " + description); m != nil {
+		metadata["report_period"] = m[1]
+	}
+	switch text := strings.ToLower(section + " " + item.Category); {
+	case strings.Contains(text, "new"):
+		metadata["activity"] = "new"
+	case strings.Contains(text, "ongoing"):
+		metadata["activity"] = "ongoing"
+	}
+
+	// A report narrates the week, so the last level it quotes is the
+	// current one.
+	var colorCode string
+	if ms := gvpColorRe.FindAllStringSubmatch(description, -1); ms != nil {
+		colorCode = strings.ToUpper(ms[len(ms)-1][1])
+		metadata["color_code"] = colorCode
+	}
+	severity := volcanoSeverity("", colorCode)
+	var last []string
+	for _, sentence := range gvpLevelSentenceRe.FindAllString(description, -1) {
+		if ms := gvpLevelRe.FindAllStringSubmatch(sentence, -1); ms != nil {
+			last = ms[len(ms)-1]
+		}
+	}
+	if last != nil {
+		level, aside := last[1], last[2]
+		if rank, ok := parseAlertRank(level); ok {
+			metadata["alert_level"] = strings.ToUpper(level)
+			metadata["alert_level_rank"] = rank
+			if m := gvpScaleRe.FindStringSubmatch(aside); m != nil {
+				if n, ok := parseAlertRank(m[1]); ok {
+					metadata["alert_level_scale"] = n
+					severity = moreSevere(severity, volcanoRankSeverity(rank, n))
+				}
+			}
+		} else {
+			level = strings.ToUpper(level)
+			metadata["alert_level"] = level
+			severity = moreSevere(severity, volcanoSeverity(level, ""))
+		}
+	}
+
+	var coords []float64
+	if lat, lon, ok := parseGeoRSSPoint(item.Point); ok {
+		coords = []float64{lon, lat}
+	}
+
+	pub, _ := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate))
+	if pub.IsZero() {
+		pub, _ = time.Parse(time.RFC1123, strings.TrimSpace(item.PubDate))
+	}
+
+	id := vnum
+	if id == "" {
+		id = strings.ToLower(strings.Join(strings.Fields(name), "-"))
+	}
+
+	return models.Event{
+		ID:          "gvp-" + id,
+		Title:       name,
+		Description: description,
+		EventType:   "volcano",
+		Source:      "gvp",
+		Geometry: models.Geometry{
+			Type:        "Point",
+			Coordinates: coords,
+		},
+		Severity:  severity,
+		StartedAt: pub,
+		UpdatedAt: pub,
+		URL:       item.Link,
+		Metadata:  metadata,
+	}
+}
+
+// parseAlertRank parses a numbered alert level, arabic or roman.
+func parseAlertRank(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	roman := map[string]int{"I": 1, "II": 2, "III": 3, "IV": 4, "V": 5}
+	n, ok := roman[strings.ToUpper(s)]
+	return n, ok
+}
+
+// parseGeoRSSPoint parses a georss:point, "lat lon".
+func parseGeoRSSPoint(s string) (lat, lon float64, ok bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	lat, err1 := strconv.ParseFloat(fields[0], 64)
+	lon, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// GVP RSS types
+
+type gvpRSS struct {
+	Items []gvpItem `xml:"channel>item"`
+}
+
+type gvpItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	GUID        string `xml:"guid"`
+	Category    string `xml:"category"`
+	Point       string `xml:"point"`
+}
@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestGVP(t *testing.T) *GVPAdapter {
+	t.Helper()
+	srv := serveFixture(t, "gvp.xml", nil)
+	a := NewGVPAdapter(srv.Client())
+	a.baseURL = srv.URL
+	return a
+}
+
+func TestGVPSourceAndSupportedTypes(t *testing.T) {
+	t.Parallel()
+	a := NewGVPAdapter(nil)
+	if got := a.Source(); got != "gvp" {
+		t.Errorf("Source() = %q, want %q", got, "gvp")
+	}
+	if got := a.SupportedTypes(); !slices.Equal(got, []string{"volcano"}) {
+		t.Errorf("SupportedTypes() = %v, want [volcano]", got)
+	}
+}
+
+func TestGVPFetchEvents(t *testing.T) {
+	t.Parallel()
+	events, err := newTestGVP(t).FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+
+	t.Run("numbered level with its scale", func(t *testing.T) {
+		e := eventByID(t, events, "gvp-282080")
+		if e.Title != "Sakurajima" || e.EventType != "volcano" || e.Source != "gvp" {
+			t.Errorf("Title, EventType, Source = %q, %q, %q", e.Title, e.EventType, e.Source)
+		}
+		if !slices.Equal(e.Geometry.Coordinates, []float64{130.657, 31.593}) {
+			t.Errorf("Coordinates = %v", e.Geometry.Coordinates)
+		}
+		if want := time.Date(2026, 8, 12, 19, 0, 0, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want %v", e.UpdatedAt, want)
+		}
+		if strings.Contains(e.Description, "<p>") {
+			t.Errorf("Description keeps markup: %q", e.Description)
+		}
+		m := e.Metadata
+		if m["country"] != "Japan" || m["report_period"] != "5 August-11 August 2026" || m["activity"] != "ongoing" {
+			t.Errorf("country, report_period, activity = %v, %v, %v", m["country"], m["report_period"], m["activity"])
+		}
+		if m["alert_level"] != "3" || m["alert_level_rank"] != 3 || m["alert_level_scale"] != 5 {
+			t.Errorf("alert level = %v, rank %v of %v; want the last quoted, 3 of 5", m["alert_level"], m["alert_level_rank"], m["alert_level_scale"])
+		}
+		if e.Severity != "moderate" {
+			t.Errorf("Severity = %q, want moderate for 3 of 5", e.Severity)
+		}
+	})
+
+	t.Run("roman level and color code", func(t *testing.T) {
+		e := eventByID(t, events, "gvp-264180")
+		m := e.Metadata
+		if m["alert_level"] != "III" || m["alert_level_rank"] != 3 || m["alert_level_scale"] != 4 || m["color_code"] != "ORANGE" {
+			t.Errorf("metadata = %v", m)
+		}
+		if m["activity"] != "new" {
+			t.Errorf("activity = %v, want new", m["activity"])
+		}
+		if e.Severity != "severe" {
+			t.Errorf("Severity = %q, want severe", e.Severity)
+		}
+	})
+
+	t.Run("USGS named level", func(t *testing.T) {
+		e := eventByID(t, events, "gvp-311120")
+		if e.Metadata["alert_level"] != "WATCH" || e.Metadata["color_code"] != "ORANGE" || e.Severity != "severe" {
+			t.Errorf("alert_level, color_code, Severity = %v, %v, %q", e.Metadata["alert_level"], e.Metadata["color_code"], e.Severity)
+		}
+	})
+
+	t.Run("item without link, location or levels", func(t *testing.T) {
+		e := eventByID(t, events, "gvp-unnamed-seamount")
+		if e.Geometry.Coordinates != nil || e.Severity != "" {
+			t.Errorf("Coordinates, Severity = %v, %q; want none", e.Geometry.Coordinates, e.Severity)
+		}
+	})
+}
+
+func TestGVPNon200(t *testing.T) {
+	t.Parallel()
+	srv := serveRaw(t, 503, "unavailable")
+	a := NewGVPAdapter(srv.Client())
+	a.baseURL = srv.URL
+
+	_, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 503") {
+		t.Errorf("error = %v, want mention of status 503", err)
+	}
+}
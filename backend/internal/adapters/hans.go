@@ -0,0 +1,200 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const hansBaseURL = "https://volcanoes.usgs.gov/hans-public/api/volcano"
+
+// hansLocationsTTL is how long the list of US volcano locations is reused.
+// Volcanoes do not move; the list only grows when one is added.
+const hansLocationsTTL = 24 * time.Hour
+
+// HANSAdapter reports US volcanoes above normal status from the USGS
+// Volcano Hazards Notification System, with each volcano's current alert
+// level and aviation color code. The elevated list carries no positions,
+// so they are joined in from the list of all US volcanoes by volcano
+// number.
+type HANSAdapter struct {
+	client  *http.Client
+	baseURL string
+
+	mu          sync.Mutex
+	locations   map[string][]float64 // vnum -> [lon, lat]
+	locationsAt time.Time
+}
+
+func NewHANSAdapter(client *http.Client) *HANSAdapter {
+	return &HANSAdapter{client: client, baseURL: hansBaseURL}
+}
+
+func (a *HANSAdapter) Source() string {
+	return "hans"
+}
+
+func (a *HANSAdapter) SupportedTypes() []string {
+	return []string{"volcano"}
+}
+
+// FetchEvents ignores params: the elevated list is current status only.
+func (a *HANSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	var elevated []hansVolcano
+	if err := a.getJSON(ctx, "/getElevatedVolcanoes", &elevated); err != nil {
+		return nil, fmt.Errorf("hans: %w", err)
+	}
+
+	locations, err := a.volcanoLocations(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("hans: %w", ctx.Err())
+		}
+		slog.Warn("hans: volcano locations unavailable", "error", err)
+	}
+
+	events := make([]models.Event, 0, len(elevated))
+	for _, v := range elevated {
+		events = append(events, parseHANSVolcano(v, locations[v.VNum]))
+	}
+	return events, nil
+}
+
+// volcanoLocations returns the cached locations, refreshing them once a
+// day. A failed refresh keeps serving the previous list; only without one
+// are events left unlocated.
+func (a *HANSAdapter) volcanoLocations(ctx context.Context) (map[string][]float64, error) {
+	a.mu.Lock()
+	locations, at := a.locations, a.locationsAt
+	a.mu.Unlock()
+	if locations != nil && time.Since(at) < hansLocationsTTL {
+		return locations, nil
+	}
+
+	var all []hansLocation
+	if err := a.getJSON(ctx, "/getUSVolcanoes", &all); err != nil {
+		return locations, err
+	}
+	fresh := make(map[string][]float64, len(all))
+	for _, l := range all {
+		if l.VNum != "" && (l.Latitude != 0 || l.Longitude != 0) {
+			fresh[l.VNum] = []float64{l.Longitude, l.Latitude}
+		}
+	}
+
+	a.mu.Lock()
+	a.locations, a.locationsAt = fresh, time.Now()
+	a.mu.Unlock()
+	return fresh, nil
+}
+
+func (a *HANSAdapter) getJSON(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func parseHANSVolcano(v hansVolcano, coords []float64) models.Event {
+	alertLevel := strings.ToUpper(strings.TrimSpace(v.AlertLevel))
+	colorCode := strings.ToUpper(strings.TrimSpace(v.ColorCode))
+
+	sent := time.Unix(v.SentUnixtime, 0).UTC()
+	if v.SentUnixtime == 0 {
+		sent, _ = time.Parse(time.DateTime, v.SentUTC)
+	}
+
+	metadata := map[string]any{
+		"vnum":             v.VNum,
+		"alert_level":      alertLevel,
+		"color_code":       colorCode,
+		"observatory":      strings.ToUpper(v.ObsAbbr),
+		"observatory_name": v.ObsFullname,
+	}
+	if v.NoticeTypeCode != "" {
+		metadata["notice_type"] = v.NoticeTypeCode
+	}
+	if v.NoticeIdentifier != "" {
+		metadata["notice_id"] = v.NoticeIdentifier
+	}
+
+	title := v.VolcanoName
+	if levels := joinNonEmpty(" / ", titleCase(alertLevel), titleCase(colorCode)); levels != "" {
+		title += ": " + levels
+	}
+
+	return models.Event{
+		ID:        "hans-" + v.VNum,
+		Title:     title,
+		EventType: "volcano",
+		Source:    "hans",
+		Geometry: models.Geometry{
+			Type:        "Point",
+			Coordinates: coords,
+		},
+		Severity: volcanoSeverity(alertLevel, colorCode),
+		// The list gives the latest notice, not when unrest began.
+		StartedAt: sent,
+		UpdatedAt: sent,
+		URL:       v.NoticeURL,
+		Metadata:  metadata,
+	}
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// USGS HANS response types
+
+type hansVolcano struct {
+	ObsFullname      string `json:"obs_fullname"`
+	ObsAbbr          string `json:"obs_abbr"`
+	VolcanoName      string `json:"volcano_name"`
+	VNum             string `json:"vnum"`
+	NoticeTypeCode   string `json:"notice_type_cd"`
+	NoticeIdentifier string `json:"notice_identifier"`
+	SentUTC          string `json:"sent_utc"`
+	SentUnixtime     int64  `json:"sent_unixtime"`
+	ColorCode        string `json:"color_code"`
+	AlertLevel       string `json:"alert_level"`
+	NoticeURL        string `json:"notice_url"`
+}
+
+type hansLocation struct {
+	VNum      string  `json:"vnum"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
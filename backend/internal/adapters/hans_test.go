@@ -0,0 +1,130 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveHANS serves the elevated list and the US volcano list from
+// testdata. Requests for the volcano list are counted in locationQueries;
+// once failLocations is set they answer 503.
+func serveHANS(t *testing.T, locationQueries *atomic.Int32, failLocations *atomic.Bool) *httptest.Server {
+	t.Helper()
+	elevated, volcanoes := readFixture(t, "hans_elevated.json"), readFixture(t, "hans_volcanoes.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/getElevatedVolcanoes":
+			w.Write(elevated)
+		case "/getUSVolcanoes":
+			locationQueries.Add(1)
+			if failLocations.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write(volcanoes)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestHANS(srv *httptest.Server) *HANSAdapter {
+	a := NewHANSAdapter(srv.Client())
+	a.baseURL = srv.URL
+	return a
+}
+
+func TestHANSSourceAndSupportedTypes(t *testing.T) {
+	t.Parallel()
+	a := NewHANSAdapter(nil)
+	if got := a.Source(); got != "hans" {
+		t.Errorf("Source() = %q, want %q", got, "hans")
+	}
+	if got := a.SupportedTypes(); !slices.Equal(got, []string{"volcano"}) {
+		t.Errorf("SupportedTypes() = %v, want [volcano]", got)
+	}
+}
+
+func TestHANSFetchEvents(t *testing.T) {
+	t.Parallel()
+	var queries atomic.Int32
+	var fail atomic.Bool
+	a := newTestHANS(serveHANS(t, &queries, &fail))
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	e := eventByID(t, events, "hans-311120")
+	if e.Title != "Great Sitkin: Watch / Orange" {
+		t.Errorf("Title = %q", e.Title)
+	}
+	if e.EventType != "volcano" || e.Source != "hans" || e.Severity != "severe" {
+		t.Errorf("EventType, Source, Severity = %q, %q, %q", e.EventType, e.Source, e.Severity)
+	}
+	if !slices.Equal(e.Geometry.Coordinates, []float64{-176.1109, 52.0765}) {
+		t.Errorf("Coordinates = %v, want Great Sitkin's location", e.Geometry.Coordinates)
+	}
+	if want := time.Date(2026, 8, 14, 19, 25, 0, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", e.UpdatedAt, want)
+	}
+	m := e.Metadata
+	if m["alert_level"] != "WATCH" || m["color_code"] != "ORANGE" || m["observatory"] != "AVO" || m["vnum"] != "311120" {
+		t.Errorf("metadata = %v", m)
+	}
+	if !strings.HasPrefix(e.URL, "https://volcanoes.usgs.gov/hans-public/notice/") {
+		t.Errorf("URL = %q", e.URL)
+	}
+
+	kilauea := eventByID(t, events, "hans-332010")
+	if kilauea.Severity != "extreme" {
+		t.Errorf("Kilauea Severity = %q, want extreme", kilauea.Severity)
+	}
+	if want := time.Date(2026, 8, 14, 18, 10, 0, 0, time.UTC); !kilauea.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v from sent_utc", kilauea.UpdatedAt, want)
+	}
+
+	if unlisted := eventByID(t, events, "hans-999999"); unlisted.Geometry.Coordinates != nil {
+		t.Errorf("Coordinates = %v, want none for a volcano missing from the list", unlisted.Geometry.Coordinates)
+	}
+
+	// The locations are reused, and a failed refresh keeps the old list.
+	a.locationsAt = time.Now().Add(-2 * hansLocationsTTL)
+	fail.Store(true)
+	events, err = a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if e := eventByID(t, events, "hans-311120"); e.Geometry.Coordinates == nil {
+		t.Error("locations lost after a failed refresh")
+	}
+	if _, err := a.FetchEvents(context.Background(), FetchParams{}); err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if got := queries.Load(); got != 3 {
+		t.Errorf("volcano list fetched %d times, want 3 (first fetch, then a failed refresh retried)", got)
+	}
+}
+
+func TestHANSNon200(t *testing.T) {
+	t.Parallel()
+	a := newTestHANS(serveRaw(t, 500, "internal error"))
+
+	_, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 500") {
+		t.Errorf("error = %v, want mention of status 500", err)
+	}
+}
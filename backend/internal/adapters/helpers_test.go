@@ -40,14 +40,39 @@ func (c *reqCapture) Header() http.Header {
 	return c.header
 }
 
-// serveFixture starts a test server that answers every request with the named
-// file from testdata/. If capture is non-nil, each request is recorded there.
-func serveFixture(t *testing.T, name string, capture *reqCapture) *httptest.Server {
+// readFixture returns the named file from testdata/, failing the test if it
+// cannot be read. Test servers read their fixtures up front with it, as a
+// handler's goroutine cannot stop the test.
+func readFixture(t *testing.T, name string) []byte {
 	t.Helper()
 	data, err := os.ReadFile(filepath.Join("testdata", name))
 	if err != nil {
 		t.Fatalf("read fixture %s: %v", name, err)
 	}
+	return data
+}
+
+// readFixtures reads every testdata/ file matching pattern, keyed by name,
+// for servers that pick a fixture by request and answer 404 without one.
+func readFixtures(t *testing.T, pattern string) map[string][]byte {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", pattern))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no fixtures match %s: %v", pattern, err)
+	}
+	fixtures := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		name := filepath.Base(p)
+		fixtures[name] = readFixture(t, name)
+	}
+	return fixtures
+}
+
+// serveFixture starts a test server that answers every request with the named
+// file from testdata/. If capture is non-nil, each request is recorded there.
+func serveFixture(t *testing.T, name string, capture *reqCapture) *httptest.Server {
+	t.Helper()
+	data := readFixture(t, name)
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if capture != nil {
 			capture.record(r)
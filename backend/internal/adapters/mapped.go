@@ -0,0 +1,723 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// mappedMaxBytes bounds a mapped feed's response.
+const mappedMaxBytes = 32 << 20
+
+// MappedConfig describes a JSON or GeoJSON feed simple enough to need no
+// code of its own: where its items are, and where in each item the event
+// fields are, as JSONPath-like expressions ("properties.mag",
+// "$.data[0]['event id']"). Each expression is evaluated against one item.
+type MappedConfig struct {
+	Source  string            `json:"source"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Items is the path from the document root to the item list; empty
+	// when the document is the list itself.
+	Items  string       `json:"items,omitempty"`
+	Fields MappedFields `json:"fields"`
+	// TimeFormat is how time fields are written: "rfc3339" (the default,
+	// also accepting a missing zone as UTC), "unix", "unix_ms", or a Go
+	// time layout.
+	TimeFormat string `json:"time_format,omitempty"`
+	// Types maps upstream type values onto our event types, like
+	// gdacsEventTypeMap. Values it lacks, and every item when there is
+	// no type field, get DefaultType, "other" if unset.
+	Types       map[string]string `json:"types,omitempty"`
+	DefaultType string            `json:"default_type,omitempty"`
+	// Severities maps upstream severity values onto ours. Values already
+	// on our scale ("minor" to "extreme") pass through.
+	Severities map[string]string `json:"severities,omitempty"`
+	// Metadata maps metadata keys to the paths of their values.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// MappedFields are the paths of an item's event fields. ID is required;
+// position comes from Coordinates ([lon, lat] or a GeoJSON Point) or from
+// Lon and Lat.
+type MappedFields struct {
+	ID          string `json:"id"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	StartedAt   string `json:"started_at,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+	Coordinates string `json:"coordinates,omitempty"`
+	Lon         string `json:"lon,omitempty"`
+	Lat         string `json:"lat,omitempty"`
+	Magnitude   string `json:"magnitude,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// MappedSources is the file MAPPED_SOURCES_FILE names: plain feeds and
+// paged feature layers, each its own source.
+type MappedSources struct {
+	Sources []MappedConfig       `json:"sources"`
+	Layers  []FeatureLayerConfig `json:"layers"`
+}
+
+// LoadMappedSources reads and validates a MappedSources file, so a bad
+// mapping fails at startup rather than at its first fetch.
+func LoadMappedSources(path string) (MappedSources, error) {
+	var file MappedSources
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MappedSources{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return MappedSources{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	check := func(source string) error {
+		if seen[source] {
+			return fmt.Errorf("mapped source %q: duplicate source", source)
+		}
+		seen[source] = true
+		return nil
+	}
+	for _, cfg := range file.Sources {
+		if _, err := newMappedSpec(cfg); err != nil {
+			return MappedSources{}, err
+		}
+		if err := check(cfg.Source); err != nil {
+			return MappedSources{}, err
+		}
+	}
+	for _, cfg := range file.Layers {
+		if _, err := cfg.spec(); err != nil {
+			return MappedSources{}, err
+		}
+		if err := check(cfg.Source); err != nil {
+			return MappedSources{}, err
+		}
+	}
+	return file, nil
+}
+
+// MappedAdapter fetches a feed described by a MappedConfig.
+type MappedAdapter struct {
+	client *http.Client
+	spec   *mappedSpec
+}
+
+// NewMappedAdapter returns an adapter for cfg, which must be valid, as
+// LoadMappedSources ensures.
+func NewMappedAdapter(client *http.Client, cfg MappedConfig) (*MappedAdapter, error) {
+	spec, err := newMappedSpec(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MappedAdapter{client: client, spec: spec}, nil
+}
+
+func (a *MappedAdapter) Source() string {
+	return a.spec.cfg.Source
+}
+
+// SupportedTypes is every type the mapping can produce.
+func (a *MappedAdapter) SupportedTypes() []string {
+	return a.spec.supportedTypes()
+}
+
+// FetchEvents ignores params: the feed is fetched as configured. Items
+// without an ID are skipped.
+func (a *MappedAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	source := a.spec.cfg.Source
+	doc, err := a.spec.fetch(ctx, a.client, a.spec.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	items, err := a.spec.itemList(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	events := make([]models.Event, 0, len(items))
+	for _, item := range items {
+		if e, ok := a.spec.event(item); ok {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// mappedSpec is a validated MappedConfig with its paths compiled.
+type mappedSpec struct {
+	cfg         MappedConfig
+	items       jsonPath
+	fields      map[string]jsonPath // by MappedFields JSON name
+	metadata    map[string]jsonPath
+	defaultType string
+}
+
+func newMappedSpec(cfg MappedConfig) (*mappedSpec, error) {
+	fail := func(format string, args ...any) (*mappedSpec, error) {
+		return nil, fmt.Errorf("mapped source %q: %s", cfg.Source, fmt.Sprintf(format, args...))
+	}
+	if !capSourceRe.MatchString(cfg.Source) {
+		return fail("source must be lower-case letters, digits, - or _")
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fail("invalid url %q", cfg.URL)
+	}
+	if cfg.Fields.ID == "" {
+		return fail("fields.id is required")
+	}
+	if (cfg.Fields.Lon == "") != (cfg.Fields.Lat == "") {
+		return fail("fields.lon and fields.lat go together")
+	}
+	switch cfg.TimeFormat {
+	case "", "rfc3339", "unix", "unix_ms":
+	default:
+		// A layout without any reference-time element formats as itself.
+		ref := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC).Format(cfg.TimeFormat)
+		if _, err := time.Parse(cfg.TimeFormat, ref); err != nil || ref == cfg.TimeFormat {
+			return fail("time_format %q is not a Go time layout", cfg.TimeFormat)
+		}
+	}
+
+	spec := &mappedSpec{
+		cfg:         cfg,
+		fields:      make(map[string]jsonPath),
+		metadata:    make(map[string]jsonPath),
+		defaultType: cfg.DefaultType,
+	}
+	if spec.defaultType == "" {
+		spec.defaultType = "other"
+	}
+	for from, to := range cfg.Types {
+		if !models.IsValidEventType(to) {
+			return fail("types[%q]: unknown event type %q", from, to)
+		}
+	}
+	if !models.IsValidEventType(spec.defaultType) {
+		return fail("unknown default_type %q", spec.defaultType)
+	}
+	for from, to := range cfg.Severities {
+		if !models.IsValidSeverity(to) {
+			return fail("severities[%q]: %q is not minor, moderate, severe or extreme", from, to)
+		}
+	}
+
+	var err error
+	if spec.items, err = compileJSONPath(cfg.Items); err != nil {
+		return fail("items: %v", err)
+	}
+	f := cfg.Fields
+	for name, expr := range map[string]string{
+		"id": f.ID, "title": f.Title, "description": f.Description, "type": f.Type,
+		"started_at": f.StartedAt, "updated_at": f.UpdatedAt, "coordinates": f.Coordinates,
+		"lon": f.Lon, "lat": f.Lat, "magnitude": f.Magnitude, "severity": f.Severity, "url": f.URL,
+	} {
+		if expr == "" {
+			continue
+		}
+		if spec.fields[name], err = compileJSONPath(expr); err != nil {
+			return fail("fields.%s: %v", name, err)
+		}
+	}
+	for key, expr := range cfg.Metadata {
+		if spec.metadata[key], err = compileJSONPath(expr); err != nil {
+			return fail("metadata[%q]: %v", key, err)
+		}
+	}
+	return spec, nil
+}
+
+func (s *mappedSpec) supportedTypes() []string {
+	types := []string{s.defaultType}
+	for _, t := range s.cfg.Types {
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// fetch GETs url with the configured headers and decodes the JSON body,
+// numbers as json.Number.
+func (s *mappedSpec) fetch(ctx context.Context, client *http.Client, url string) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, mappedMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if len(data) > mappedMaxBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", mappedMaxBytes)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return doc, nil
+}
+
+func (s *mappedSpec) itemList(doc any) ([]any, error) {
+	v, ok := s.items.eval(doc)
+	items, isList := v.([]any)
+	if !ok || !isList {
+		return nil, fmt.Errorf("no item list at %q", s.cfg.Items)
+	}
+	return items, nil
+}
+
+func (s *mappedSpec) str(item any, field string) string {
+	p, ok := s.fields[field]
+	if !ok {
+		return ""
+	}
+	v, _ := p.eval(item)
+	str, _ := jsonString(v)
+	return strings.TrimSpace(str)
+}
+
+func (s *mappedSpec) event(item any) (models.Event, bool) {
+	id := s.str(item, "id")
+	if id == "" {
+		return models.Event{}, false
+	}
+
+	eventType := s.defaultType
+	if raw := s.str(item, "type"); raw != "" {
+		if t, ok := lookupFold(s.cfg.Types, raw); ok {
+			eventType = t
+		}
+	}
+
+	severity := ""
+	if raw := s.str(item, "severity"); raw != "" {
+		if sev, ok := lookupFold(s.cfg.Severities, raw); ok {
+			severity = sev
+		} else if models.IsValidSeverity(strings.ToLower(raw)) {
+			severity = strings.ToLower(raw)
+		}
+	}
+
+	var mag *float64
+	if p, ok := s.fields["magnitude"]; ok {
+		if v, ok := p.eval(item); ok {
+			if f, ok := jsonFloat(v); ok {
+				mag = &f
+			}
+		}
+	}
+
+	startedAt := s.time(item, "started_at")
+	updatedAt := s.time(item, "updated_at")
+	if updatedAt.IsZero() {
+		updatedAt = startedAt
+	}
+	if startedAt.IsZero() {
+		startedAt = updatedAt
+	}
+
+	metadata := make(map[string]any, len(s.metadata))
+	for key, p := range s.metadata {
+		if v, ok := p.eval(item); ok && v != nil {
+			metadata[key] = plainJSON(v)
+		}
+	}
+
+	geometry := s.geometry(item)
+	if len(geometry.Shapes) > 0 {
+		names := make([]string, len(geometry.Shapes))
+		for i := range names {
+			names[i] = "extent"
+		}
+		metadata["shapes"] = names
+	}
+
+	title := s.str(item, "title")
+	if title == "" {
+		title = eventType
+	}
+
+	return models.Event{
+		ID:          s.cfg.Source + "-" + id,
+		Title:       title,
+		Description: s.str(item, "description"),
+		EventType:   eventType,
+		Source:      s.cfg.Source,
+		Geometry:    geometry,
+		Magnitude:   mag,
+		Severity:    severity,
+		StartedAt:   startedAt,
+		UpdatedAt:   updatedAt,
+		URL:         s.str(item, "url"),
+		Metadata:    metadata,
+	}, true
+}
+
+// geometry reads the item's position: a [lon, lat] array or any GeoJSON
+// geometry at the coordinates path, or separate lon and lat values.
+func (s *mappedSpec) geometry(item any) models.Geometry {
+	unlocated := models.Geometry{Type: "Point"}
+	if p, ok := s.fields["coordinates"]; ok {
+		v, _ := p.eval(item)
+		if obj, ok := v.(map[string]any); ok {
+			return geoJSONToGeometry(obj)
+		}
+		arr, _ := v.([]any)
+		if pos, ok := jsonPosition(arr); ok {
+			return models.Geometry{Type: "Point", Coordinates: pos}
+		}
+		return unlocated
+	}
+	lonPath, ok1 := s.fields["lon"]
+	latPath, ok2 := s.fields["lat"]
+	if !ok1 || !ok2 {
+		return unlocated
+	}
+	lonV, _ := lonPath.eval(item)
+	latV, _ := latPath.eval(item)
+	if pos, ok := jsonPosition([]any{lonV, latV}); ok {
+		return models.Geometry{Type: "Point", Coordinates: pos}
+	}
+	return unlocated
+}
+
+func (s *mappedSpec) time(item any, field string) time.Time {
+	p, ok := s.fields[field]
+	if !ok {
+		return time.Time{}
+	}
+	v, ok := p.eval(item)
+	if !ok || v == nil {
+		return time.Time{}
+	}
+	switch s.cfg.TimeFormat {
+	case "unix", "unix_ms":
+		f, ok := jsonFloat(v)
+		if !ok {
+			return time.Time{}
+		}
+		if s.cfg.TimeFormat == "unix" {
+			return time.UnixMilli(int64(f * 1000)).UTC()
+		}
+		return time.UnixMilli(int64(f)).UTC()
+	}
+	str, _ := jsonString(v)
+	str = strings.TrimSpace(str)
+	if s.cfg.TimeFormat != "" && s.cfg.TimeFormat != "rfc3339" {
+		t, _ := time.Parse(s.cfg.TimeFormat, str)
+		return t
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t
+	}
+	t, _ := time.Parse("2006-01-02T15:04:05", str)
+	return t
+}
+
+// jsonPosition reads a decoded [lon, lat, ...] position, dropping any
+// altitude.
+func jsonPosition(arr []any) ([]float64, bool) {
+	if len(arr) < 2 {
+		return nil, false
+	}
+	lon, ok1 := jsonFloat(arr[0])
+	lat, ok2 := jsonFloat(arr[1])
+	if !ok1 || !ok2 || lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+		return nil, false
+	}
+	return []float64{lon, lat}, true
+}
+
+// geoJSONToGeometry converts a decoded GeoJSON geometry. Lines and
+// polygons become shapes, anchored at the middle vertex of the longest
+// line or the centroid of the largest polygon; a multi-point is anchored
+// at its first point.
+func geoJSONToGeometry(obj map[string]any) models.Geometry {
+	g := models.Geometry{Type: "Point"}
+	var anchorWeight float64
+	setAnchor := func(pos []float64, weight float64) {
+		if g.Coordinates == nil || weight > anchorWeight {
+			g.Coordinates, anchorWeight = pos, weight
+		}
+	}
+	addLine := func(coords any) {
+		line := jsonPositions(coords)
+		if len(line) < 2 {
+			return
+		}
+		g.Shapes = append(g.Shapes, models.Shape{Type: "LineString", Positions: line})
+		setAnchor(line[len(line)/2], float64(len(line)))
+	}
+	addPolygon := func(coords any) {
+		raw, _ := coords.([]any)
+		var rings [][][]float64
+		for _, r := range raw {
+			ring := jsonPositions(r)
+			if len(ring) >= 3 && !slices.Equal(ring[0], ring[len(ring)-1]) {
+				ring = append(ring, ring[0])
+			}
+			if len(ring) < 4 {
+				if len(rings) == 0 {
+					return // no outer ring
+				}
+				continue
+			}
+			rings = append(rings, ring)
+		}
+		if len(rings) == 0 {
+			return
+		}
+		g.Shapes = append(g.Shapes, models.Shape{Type: "Polygon", Rings: rings})
+		setAnchor(ringCentroid(rings[0]), ringArea(rings[0]))
+	}
+
+	var walk func(obj map[string]any)
+	walk = func(obj map[string]any) {
+		coords := obj["coordinates"]
+		switch t, _ := obj["type"].(string); t {
+		case "Point":
+			arr, _ := coords.([]any)
+			if pos, ok := jsonPosition(arr); ok && g.Coordinates == nil {
+				g.Coordinates = pos
+			}
+		case "MultiPoint":
+			points := jsonPositions(coords)
+			if len(points) == 0 {
+				return
+			}
+			if g.Coordinates == nil {
+				g.Coordinates = points[0]
+			}
+			if len(points) > 1 {
+				g.Shapes = append(g.Shapes, models.Shape{Type: "MultiPoint", Positions: points})
+			}
+		case "LineString":
+			addLine(coords)
+		case "MultiLineString":
+			lines, _ := coords.([]any)
+			for _, l := range lines {
+				addLine(l)
+			}
+		case "Polygon":
+			addPolygon(coords)
+		case "MultiPolygon":
+			polygons, _ := coords.([]any)
+			for _, p := range polygons {
+				addPolygon(p)
+			}
+		case "GeometryCollection":
+			members, _ := obj["geometries"].([]any)
+			for _, m := range members {
+				if m, ok := m.(map[string]any); ok {
+					walk(m)
+				}
+			}
+		}
+	}
+	walk(obj)
+	return g
+}
+
+// jsonPositions reads a decoded list of positions, skipping invalid ones.
+func jsonPositions(v any) [][]float64 {
+	arr, _ := v.([]any)
+	positions := make([][]float64, 0, len(arr))
+	for _, p := range arr {
+		pa, _ := p.([]any)
+		if pos, ok := jsonPosition(pa); ok {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// ringArea is a closed ring's planar area in square degrees, enough to
+// rank a multi-polygon's parts.
+func ringArea(ring [][]float64) float64 {
+	var sum float64
+	for i := 0; i+1 < len(ring); i++ {
+		sum += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return math.Abs(sum) / 2
+}
+
+// lookupFold looks key up exactly, then case-insensitively.
+func lookupFold(m map[string]string, key string) (string, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// jsonString renders a decoded scalar as a string.
+func jsonString(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// jsonFloat reads a decoded number, or a string holding one.
+func jsonFloat(v any) (float64, bool) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// plainJSON converts json.Number back to float64 throughout a decoded
+// value, so metadata holds the same types as every other adapter's.
+func plainJSON(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = plainJSON(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = plainJSON(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// jsonPath is a compiled path expression: object keys and array indexes
+// from a root. The syntax is the dotted subset of JSONPath: an optional
+// leading "$", then ".key", "[n]" or "['key']" steps; a bare leading key
+// needs no dot. The empty path is the root itself.
+type jsonPath []jsonStep
+
+type jsonStep struct {
+	key   string
+	index int
+	isKey bool
+}
+
+func compileJSONPath(expr string) (jsonPath, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	var path jsonPath
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			j := i
+			for j < len(s) && s[j] != '.' && s[j] != '[' {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("empty key in %q", expr)
+			}
+			path = append(path, jsonStep{key: s[i:j], isKey: true})
+			i = j
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", expr)
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			i += end + 1
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, jsonStep{key: inner[1 : len(inner)-1], isKey: true})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index [%s] in %q", inner, expr)
+			}
+			path = append(path, jsonStep{index: n})
+		default:
+			if i > 0 {
+				return nil, errors.New("expected . or [ in " + strconv.Quote(expr))
+			}
+			s = "." + s // a bare leading key
+		}
+	}
+	return path, nil
+}
+
+// eval follows the path from v, reporting false where it leads nowhere.
+func (p jsonPath) eval(v any) (any, bool) {
+	for _, step := range p {
+		if step.isKey {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[step.key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		arr, ok := v.([]any)
+		if !ok || step.index >= len(arr) {
+			return nil, false
+		}
+		v = arr[step.index]
+	}
+	return v, true
+}
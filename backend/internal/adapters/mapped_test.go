@@ -0,0 +1,300 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTestMapped loads testdata/mapped_sources.json and points the named
+// source at srv.
+func loadTestMapped(t *testing.T, source, url string) *MappedAdapter {
+	t.Helper()
+	file, err := LoadMappedSources(filepath.Join("testdata", "mapped_sources.json"))
+	if err != nil {
+		t.Fatalf("LoadMappedSources: %v", err)
+	}
+	for _, cfg := range file.Sources {
+		if cfg.Source == source {
+			cfg.URL = url
+			a, err := NewMappedAdapter(http.DefaultClient, cfg)
+			if err != nil {
+				t.Fatalf("NewMappedAdapter: %v", err)
+			}
+			return a
+		}
+	}
+	t.Fatalf("no mapped source %q", source)
+	return nil
+}
+
+func TestMappedAdapterGeoJSON(t *testing.T) {
+	t.Parallel()
+	srv := serveFixture(t, "mapped_geojson.json", nil)
+	a := loadTestMapped(t, "regional", srv.URL)
+
+	if a.Source() != "regional" {
+		t.Errorf("Source() = %q", a.Source())
+	}
+	if got, want := a.SupportedTypes(), []string{"earthquake", "other", "wildfire"}; !slices.Equal(got, want) {
+		t.Errorf("SupportedTypes() = %v, want %v", got, want)
+	}
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3 (the item without an id is skipped): %v", len(events), eventIDs(events))
+	}
+
+	quake := eventByID(t, events, "regional-rq-1042")
+	if quake.EventType != "earthquake" || quake.Source != "regional" {
+		t.Errorf("type/source = %q/%q", quake.EventType, quake.Source)
+	}
+	if quake.Title != "M 4.6 - 5 km NE of L'Aquila" {
+		t.Errorf("Title = %q", quake.Title)
+	}
+	if !slices.Equal(quake.Geometry.Coordinates, []float64{13.39, 42.35}) {
+		t.Errorf("Coordinates = %v", quake.Geometry.Coordinates)
+	}
+	if quake.Magnitude == nil || *quake.Magnitude != 4.6 {
+		t.Errorf("Magnitude = %v", quake.Magnitude)
+	}
+	if quake.Severity != "severe" {
+		t.Errorf("Severity = %q, want severe (mapped case-insensitively)", quake.Severity)
+	}
+	if want := time.Date(2026, 8, 14, 9, 12, 44, 0, time.UTC); !quake.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", quake.StartedAt, want)
+	}
+	if want := time.Date(2026, 8, 14, 9, 40, 0, 0, time.UTC); !quake.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", quake.UpdatedAt, want)
+	}
+	if quake.URL != "https://regional.example.org/events/rq-1042" {
+		t.Errorf("URL = %q", quake.URL)
+	}
+	if quake.Metadata["region"] != "Abruzzo" || quake.Metadata["felt_reports"] != float64(212) {
+		t.Errorf("Metadata = %v", quake.Metadata)
+	}
+
+	fire := eventByID(t, events, "regional-rq-1043")
+	if fire.EventType != "wildfire" || fire.Severity != "severe" {
+		t.Errorf("fire type/severity = %q/%q (already-ours severities pass through)", fire.EventType, fire.Severity)
+	}
+	if fire.Magnitude != nil {
+		t.Errorf("null magnitude = %v, want nil", *fire.Magnitude)
+	}
+	if want := time.Date(2026, 8, 14, 7, 0, 0, 0, time.UTC); !fire.StartedAt.Equal(want) || !fire.UpdatedAt.Equal(want) {
+		t.Errorf("zoneless time = %v/%v, want %v for both", fire.StartedAt, fire.UpdatedAt, want)
+	}
+	if _, ok := fire.Metadata["felt_reports"]; ok {
+		t.Error("missing metadata path should be left out")
+	}
+
+	// A numeric ID, no geometry and an unmapped type.
+	other := eventByID(t, events, "regional-1044")
+	if other.EventType != "other" || other.Geometry.Coordinates != nil || other.Severity != "minor" {
+		t.Errorf("other = %q, %v, %q", other.EventType, other.Geometry.Coordinates, other.Severity)
+	}
+}
+
+func TestMappedAdapterListAndUnixTime(t *testing.T) {
+	t.Parallel()
+	capture := &reqCapture{}
+	srv := serveFixture(t, "mapped_list.json", capture)
+	a := loadTestMapped(t, "rivers", srv.URL)
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if got := capture.Header().Get("Accept"); got != "application/json" {
+		t.Errorf("Accept header = %q", got)
+	}
+
+	flood := eventByID(t, events, "rivers-A7")
+	if flood.EventType != "flood" {
+		t.Errorf("EventType = %q", flood.EventType)
+	}
+	if !slices.Equal(flood.Geometry.Coordinates, []float64{20.19, 47.18}) {
+		t.Errorf("Coordinates = %v (string lon/lat should parse)", flood.Geometry.Coordinates)
+	}
+	if want := time.UnixMilli(1786694400000).UTC(); !flood.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", flood.StartedAt, want)
+	}
+	if flood.Metadata["depth_m"] != 3.4 {
+		t.Errorf("depth_m = %v", flood.Metadata["depth_m"])
+	}
+
+	heat := eventByID(t, events, "rivers-A8")
+	if heat.EventType != "weather" {
+		t.Errorf("unmapped type = %q, want the default_type", heat.EventType)
+	}
+	if heat.Geometry.Coordinates != nil {
+		t.Errorf("out-of-range position = %v, want unlocated", heat.Geometry.Coordinates)
+	}
+	if heat.Title != "Unmapped category" {
+		t.Errorf("Title = %q", heat.Title)
+	}
+}
+
+func TestMappedAdapterErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"status", http.StatusBadGateway, "", "unexpected status 502"},
+		{"not json", http.StatusOK, "<html>", "decode response"},
+		{"no list", http.StatusOK, `{"features": {}}`, `no item list at "features"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := serveRaw(t, tt.status, tt.body)
+			a := loadTestMapped(t, "regional", srv.URL)
+			_, err := a.FetchEvents(context.Background(), FetchParams{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.HasPrefix(err.Error(), "regional: ") {
+				t.Errorf("err = %v, want regional: ...%s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadMappedSourcesRejects(t *testing.T) {
+	t.Parallel()
+	valid := func() map[string]any {
+		return map[string]any{
+			"source": "feed",
+			"url":    "https://example.org/feed.json",
+			"fields": map[string]any{"id": "id"},
+		}
+	}
+	tests := []struct {
+		name    string
+		edit    func(m map[string]any)
+		wantErr string
+	}{
+		{"bad source", func(m map[string]any) { m["source"] = "Feed!" }, "source must be"},
+		{"bad url", func(m map[string]any) { m["url"] = "ftp://example.org/x" }, "invalid url"},
+		{"no id", func(m map[string]any) { m["fields"] = map[string]any{"title": "t"} }, "fields.id is required"},
+		{"lon without lat", func(m map[string]any) { m["fields"] = map[string]any{"id": "id", "lon": "x"} }, "go together"},
+		{"bad path", func(m map[string]any) { m["fields"] = map[string]any{"id": "a[x]"} }, "fields.id: invalid index"},
+		{"unclosed", func(m map[string]any) { m["items"] = "data[0" }, "items: unclosed"},
+		{"bad type", func(m map[string]any) { m["types"] = map[string]any{"q": "quake"} }, `unknown event type "quake"`},
+		{"bad default type", func(m map[string]any) { m["default_type"] = "misc" }, "unknown default_type"},
+		{"bad severity", func(m map[string]any) { m["severities"] = map[string]any{"red": "critical"} }, "is not minor"},
+		{"bad time format", func(m map[string]any) { m["time_format"] = "yesterday" }, "not a Go time layout"},
+		{"unknown field", func(m map[string]any) { m["itemz"] = "x" }, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := valid()
+			tt.edit(cfg)
+			_, err := LoadMappedSources(writeMappedConfig(t, cfg))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("duplicate source", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadMappedSources(writeMappedConfig(t, valid(), valid()))
+		if err == nil || !strings.Contains(err.Error(), "duplicate source") {
+			t.Errorf("err = %v, want duplicate source", err)
+		}
+	})
+
+	t.Run("source shared with a layer", func(t *testing.T) {
+		t.Parallel()
+		layer := valid()
+		layer["service"] = "arcgis"
+		_, err := LoadMappedSources(writeMappedFile(t, map[string]any{
+			"sources": []any{valid()},
+			"layers":  []any{layer},
+		}))
+		if err == nil || !strings.Contains(err.Error(), "duplicate source") {
+			t.Errorf("err = %v, want duplicate source", err)
+		}
+	})
+}
+
+func writeMappedConfig(t *testing.T, sources ...map[string]any) string {
+	t.Helper()
+	return writeMappedFile(t, map[string]any{"sources": sources})
+}
+
+func writeMappedLayers(t *testing.T, layers ...map[string]any) string {
+	t.Helper()
+	return writeMappedFile(t, map[string]any{"layers": layers})
+}
+
+func writeMappedFile(t *testing.T, file map[string]any) string {
+	t.Helper()
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "mapped.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJSONPath(t *testing.T) {
+	t.Parallel()
+	var doc any
+	if err := json.Unmarshal([]byte(`{"a": {"b c": [10, {"d": "x"}]}, "e": null}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr   string
+		want   any
+		wantOK bool
+	}{
+		{"a['b c'][0]", float64(10), true},
+		{`$.a["b c"][1].d`, "x", true},
+		{"$.e", nil, true},
+		{"a.missing", nil, false},
+		{"a['b c'][5]", nil, false},
+		{"a[0]", nil, false},
+	}
+	for _, tt := range tests {
+		p, err := compileJSONPath(tt.expr)
+		if err != nil {
+			t.Errorf("compileJSONPath(%q): %v", tt.expr, err)
+			continue
+		}
+		got, ok := p.eval(doc)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("eval(%q) = %v, %v; want %v, %v", tt.expr, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if root, ok := mustPath(t, "").eval(doc); !ok || root == nil {
+		t.Error("empty path should be the root")
+	}
+	if _, err := compileJSONPath("a..b"); err == nil {
+		t.Error("a..b should not compile")
+	}
+}
+
+func mustPath(t *testing.T, expr string) jsonPath {
+	t.Helper()
+	p, err := compileJSONPath(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
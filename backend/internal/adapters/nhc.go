@@ -0,0 +1,590 @@
+package adapters
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const nhcBaseURL = "https://www.nhc.noaa.gov/CurrentStorms.json"
+
+// nhcMaxKMZBytes bounds a downloaded KMZ. Track and cone archives are a
+// few tens of KB; anything near this is not what we asked for.
+const nhcMaxKMZBytes = 16 << 20
+
+// NHCAdapter reports active tropical cyclones from the National Hurricane
+// Center, which covers the Atlantic and eastern and central Pacific. Each
+// storm's CurrentStorms.json entry links KMZ products for its best track
+// so far, forecast track and forecast cone; those are fetched per storm
+// and carried in the event geometry as extra shapes, in the order listed
+// by the "shapes" metadata key.
+type NHCAdapter struct {
+	client  *http.Client
+	baseURL string
+
+	// firstAdvisory remembers the earliest advisory time seen per storm
+	// ID, as the feed only carries the latest; it dates a storm that has
+	// no best track yet. Entries for storms no longer active are dropped
+	// each fetch.
+	mu            sync.Mutex
+	firstAdvisory map[string]time.Time
+}
+
+func NewNHCAdapter(client *http.Client) *NHCAdapter {
+	return &NHCAdapter{client: client, baseURL: nhcBaseURL, firstAdvisory: make(map[string]time.Time)}
+}
+
+func (a *NHCAdapter) Source() string {
+	return "nhc"
+}
+
+func (a *NHCAdapter) SupportedTypes() []string {
+	return []string{"hurricane", "cyclone"}
+}
+
+// FetchEvents ignores params: CurrentStorms.json lists only active storms,
+// which is already the whole canonical set.
+func (a *NHCAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("nhc: build request: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("nhc: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nhc: unexpected status %d", resp.StatusCode)
+	}
+
+	var result nhcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("nhc: decode response: %w", err)
+	}
+
+	events := make([]models.Event, 0, len(result.ActiveStorms))
+	first := make(map[string]time.Time, len(result.ActiveStorms))
+	a.mu.Lock()
+	for _, s := range result.ActiveStorms {
+		e := parseNHCStorm(s)
+		if seen, ok := a.firstAdvisory[e.ID]; ok && (e.StartedAt.IsZero() || seen.Before(e.StartedAt)) {
+			e.StartedAt = seen
+		}
+		first[e.ID] = e.StartedAt
+		events = append(events, e)
+	}
+	a.firstAdvisory = first
+	a.mu.Unlock()
+
+	for i, s := range result.ActiveStorms {
+		if err := a.addProducts(ctx, &events[i], s); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// addProducts attaches a storm's track and cone. A product that cannot be
+// fetched or parsed leaves the storm without that shape rather than
+// dropping it: the position and intensity are the essential part. Only
+// cancellation fails the fetch.
+func (a *NHCAdapter) addProducts(ctx context.Context, e *models.Event, s nhcStorm) error {
+	var shapes []models.Shape
+	var names []string
+	add := func(name string, shape models.Shape) {
+		shapes = append(shapes, shape)
+		names = append(names, name)
+	}
+
+	products := []struct {
+		name  string
+		url   string
+		apply func([]kmlPlacemark)
+	}{
+		{"best track", s.BestTrackGIS.KMZFile, func(marks []kmlPlacemark) {
+			if track := nhcPastTrack(marks); len(track) >= 2 {
+				add("past_track", models.Shape{Type: "LineString", Positions: track})
+			}
+			if t := nhcTrackStart(marks); !t.IsZero() && t.Before(e.StartedAt) {
+				e.StartedAt = t
+			}
+		}},
+		{"forecast track", s.ForecastTrack.KMZFile, func(marks []kmlPlacemark) {
+			points := nhcForecastPoints(marks)
+			if len(points) == 0 {
+				return
+			}
+			positions := make([][]float64, 0, len(points))
+			for _, p := range points {
+				positions = append(positions, []float64{p.lon, p.lat})
+			}
+			add("forecast_points", models.Shape{Type: "MultiPoint", Positions: positions})
+			e.Metadata["forecast"] = nhcForecastMetadata(points)
+		}},
+		{"forecast cone", s.TrackCone.KMZFile, func(marks []kmlPlacemark) {
+			for _, pm := range marks {
+				for _, rings := range pm.polygons() {
+					add("cone", models.Shape{Type: "Polygon", Rings: rings})
+				}
+			}
+		}},
+	}
+	for _, p := range products {
+		if p.url == "" {
+			continue // not published for this storm, e.g. a new depression
+		}
+		marks, err := a.fetchKMZ(ctx, p.url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("nhc: %w", ctx.Err())
+			}
+			slog.Warn("nhc: storm product unavailable", "storm", s.ID, "product", p.name, "error", err)
+			continue
+		}
+		p.apply(marks)
+	}
+
+	e.Geometry.Shapes = shapes
+	if len(names) > 0 {
+		e.Metadata["shapes"] = names
+	}
+	return nil
+}
+
+// fetchKMZ downloads a KMZ and returns the placemarks of its KML document.
+func (a *NHCAdapter) fetchKMZ(ctx context.Context, url string) ([]kmlPlacemark, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, nhcMaxKMZBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read kmz: %w", err)
+	}
+	if len(data) > nhcMaxKMZBytes {
+		return nil, fmt.Errorf("kmz exceeds %d bytes", nhcMaxKMZBytes)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open kmz: %w", err)
+	}
+	for _, f := range zr.File {
+		if !strings.HasSuffix(strings.ToLower(f.Name), ".kml") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		marks, err := parseKMLPlacemarks(io.LimitReader(rc, nhcMaxKMZBytes))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f.Name, err)
+		}
+		return marks, nil
+	}
+	return nil, errors.New("no kml document in kmz")
+}
+
+func parseNHCStorm(s nhcStorm) models.Event {
+	kind := nhcClassifications[s.Classification]
+	if kind == "" {
+		kind = "Tropical Cyclone"
+	}
+	eventType := "cyclone"
+	if s.Classification == "HU" {
+		eventType = "hurricane"
+	}
+
+	var mag *float64
+	intensity, err := strconv.ParseFloat(s.Intensity, 64)
+	if err == nil {
+		mag = &intensity
+	}
+
+	metadata := map[string]any{
+		"storm_id":       s.ID,
+		"classification": s.Classification,
+		"bin":            s.BinNumber,
+	}
+	if mag != nil {
+		metadata["max_wind_kt"] = intensity
+	}
+	if p, err := strconv.ParseFloat(s.Pressure, 64); err == nil {
+		metadata["pressure_mb"] = p
+	}
+	if s.MovementSpeed != nil {
+		metadata["movement_dir_deg"] = s.MovementDir
+		metadata["movement_speed_mph"] = *s.MovementSpeed
+	}
+	if adv := strings.TrimLeft(s.PublicAdvisory.AdvNum, "0"); adv != "" {
+		metadata["advisory"] = adv
+	}
+
+	lastUpdate, _ := time.Parse(time.RFC3339, s.LastUpdate)
+
+	return models.Event{
+		ID:        "nhc-" + strings.ToLower(s.ID),
+		Title:     kind + " " + s.Name,
+		EventType: eventType,
+		Source:    "nhc",
+		Geometry: models.Geometry{
+			Type:        "Point",
+			Coordinates: []float64{s.LongitudeNumeric, s.LatitudeNumeric},
+		},
+		Magnitude: mag,
+		Severity:  nhcSeverity(s.Classification, intensity),
+		// The feed carries no genesis time, only the latest advisory;
+		// FetchEvents moves the start back to the first advisory seen and
+		// the best track's first fix.
+		StartedAt: lastUpdate,
+		UpdatedAt: lastUpdate,
+		URL:       s.PublicAdvisory.URL,
+		Metadata:  metadata,
+	}
+}
+
+var nhcClassifications = map[string]string{
+	"HU":  "Hurricane",
+	"TS":  "Tropical Storm",
+	"TD":  "Tropical Depression",
+	"STS": "Subtropical Storm",
+	"STD": "Subtropical Depression",
+	"PTC": "Potential Tropical Cyclone",
+	"PC":  "Post-Tropical Cyclone",
+	"TY":  "Typhoon",
+}
+
+// nhcSeverity follows the Saffir-Simpson scale: major hurricanes (category
+// 3 and up, 96 kt and over) are extreme, other hurricanes severe, tropical
+// storms moderate and weaker systems minor.
+func nhcSeverity(classification string, windKt float64) string {
+	switch {
+	case classification == "HU" && windKt >= 96:
+		return models.SeverityExtreme
+	case classification == "HU":
+		return models.SeveritySevere
+	case classification == "TS" || classification == "STS":
+		return models.SeverityModerate
+	default:
+		return models.SeverityMinor
+	}
+}
+
+// nhcPastTrack joins a best-track document into one line, oldest first:
+// its line segments when it has them, otherwise its fix points in order.
+func nhcPastTrack(marks []kmlPlacemark) [][]float64 {
+	var track [][]float64
+	appendPos := func(p []float64) {
+		if n := len(track); n > 0 && track[n-1][0] == p[0] && track[n-1][1] == p[1] {
+			return // segments share their end points
+		}
+		track = append(track, p)
+	}
+	for _, pm := range marks {
+		for _, line := range pm.lines() {
+			for _, p := range line {
+				appendPos(p)
+			}
+		}
+	}
+	if len(track) > 0 {
+		return track
+	}
+	for _, pm := range marks {
+		if p := pm.point(); p != nil {
+			appendPos(p)
+		}
+	}
+	return track
+}
+
+// nhcTrackStart returns the time of a best-track document's earliest fix:
+// its KML TimeStamp, or its DTG attribute (YYYYMMDDHH, UTC). It is zero
+// when no fix is dated.
+func nhcTrackStart(marks []kmlPlacemark) time.Time {
+	var start time.Time
+	for _, pm := range marks {
+		if pm.point() == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(pm.When))
+		if err != nil {
+			t, err = time.Parse("2006010215", pm.data("dtg"))
+		}
+		if err == nil && (start.IsZero() || t.Before(start)) {
+			start = t
+		}
+	}
+	return start
+}
+
+type nhcForecastPoint struct {
+	lon, lat  float64
+	label     string
+	maxWindKt *float64
+	tauHours  *float64
+}
+
+var nhcMaxWindRe = regexp.MustCompile(`(?i)maximum wind:?\s*(\d+(?:\.\d+)?)\s*(?:knots|kt)`)
+
+// nhcForecastPoints reads the forecast positions from a track document.
+// Attribute names differ between product generations, so intensity and
+// lead time are looked up under each known name, with intensity finally
+// parsed from the description text.
+func nhcForecastPoints(marks []kmlPlacemark) []nhcForecastPoint {
+	var points []nhcForecastPoint
+	for _, pm := range marks {
+		p := pm.point()
+		if p == nil {
+			continue
+		}
+		fp := nhcForecastPoint{lon: p[0], lat: p[1], label: strings.TrimSpace(pm.Name)}
+		if v, ok := pm.number("maxwind", "maxwnd", "intensity"); ok {
+			fp.maxWindKt = &v
+		} else if m := nhcMaxWindRe.FindStringSubmatch(pm.Description); m != nil {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				fp.maxWindKt = &v
+			}
+		}
+		if v, ok := pm.number("tau", "fcstprd"); ok {
+			fp.tauHours = &v
+		}
+		if label := pm.data("fldatelbl", "datelbl", "validtime"); label != "" {
+			fp.label = label
+		}
+		points = append(points, fp)
+	}
+	return points
+}
+
+func nhcForecastMetadata(points []nhcForecastPoint) []map[string]any {
+	out := make([]map[string]any, 0, len(points))
+	for _, p := range points {
+		m := map[string]any{"lon": p.lon, "lat": p.lat}
+		if p.label != "" {
+			m["valid"] = p.label
+		}
+		if p.maxWindKt != nil {
+			m["max_wind_kt"] = *p.maxWindKt
+		}
+		if p.tauHours != nil {
+			m["tau_hours"] = *p.tauHours
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// NHC CurrentStorms.json types
+
+type nhcResponse struct {
+	ActiveStorms []nhcStorm `json:"activeStorms"`
+}
+
+type nhcStorm struct {
+	ID               string     `json:"id"`
+	BinNumber        string     `json:"binNumber"`
+	Name             string     `json:"name"`
+	Classification   string     `json:"classification"`
+	Intensity        string     `json:"intensity"`
+	Pressure         string     `json:"pressure"`
+	LatitudeNumeric  float64    `json:"latitudeNumeric"`
+	LongitudeNumeric float64    `json:"longitudeNumeric"`
+	MovementDir      float64    `json:"movementDir"`
+	MovementSpeed    *float64   `json:"movementSpeed"`
+	LastUpdate       string     `json:"lastUpdate"`
+	PublicAdvisory   nhcProduct `json:"publicAdvisory"`
+	ForecastTrack    nhcProduct `json:"forecastTrack"`
+	TrackCone        nhcProduct `json:"trackCone"`
+	BestTrackGIS     nhcProduct `json:"bestTrackGIS"`
+}
+
+type nhcProduct struct {
+	AdvNum  string `json:"advNum"`
+	URL     string `json:"url"`
+	KMZFile string `json:"kmzFile"`
+}
+
+// KML types. Only what the NHC products use is modelled.
+
+type kmlPlacemark struct {
+	Name          string         `xml:"name"`
+	Description   string         `xml:"description"`
+	When          string         `xml:"TimeStamp>when"`
+	Point         *kmlCoords     `xml:"Point"`
+	LineString    *kmlCoords     `xml:"LineString"`
+	Polygon       *kmlPolygon    `xml:"Polygon"`
+	MultiGeometry *kmlMultiGeom  `xml:"MultiGeometry"`
+	Data          []kmlData      `xml:"ExtendedData>Data"`
+	SimpleData    []kmlSimpleVal `xml:"ExtendedData>SchemaData>SimpleData"`
+}
+
+type kmlCoords struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer kmlCoords   `xml:"outerBoundaryIs>LinearRing"`
+	Inner []kmlCoords `xml:"innerBoundaryIs>LinearRing"`
+}
+
+type kmlMultiGeom struct {
+	LineStrings []kmlCoords  `xml:"LineString"`
+	Polygons    []kmlPolygon `xml:"Polygon"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlSimpleVal struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// parseKMLPlacemarks returns every Placemark in a KML document, however
+// deeply nested in Documents and Folders.
+func parseKMLPlacemarks(r io.Reader) ([]kmlPlacemark, error) {
+	dec := xml.NewDecoder(r)
+	var marks []kmlPlacemark
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return marks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		var pm kmlPlacemark
+		if err := dec.DecodeElement(&pm, &start); err != nil {
+			return nil, err
+		}
+		marks = append(marks, pm)
+	}
+}
+
+// parseKMLCoordinates parses "lon,lat[,alt] lon,lat[,alt] ...", dropping
+// altitudes and malformed tuples.
+func parseKMLCoordinates(s string) [][]float64 {
+	var out [][]float64
+	for _, tuple := range strings.Fields(s) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		lon, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		out = append(out, []float64{lon, lat})
+	}
+	return out
+}
+
+func (pm kmlPlacemark) point() []float64 {
+	if pm.Point == nil {
+		return nil
+	}
+	if c := parseKMLCoordinates(pm.Point.Coordinates); len(c) > 0 {
+		return c[0]
+	}
+	return nil
+}
+
+func (pm kmlPlacemark) lines() [][][]float64 {
+	var lines [][][]float64
+	if pm.LineString != nil {
+		lines = append(lines, parseKMLCoordinates(pm.LineString.Coordinates))
+	}
+	if pm.MultiGeometry != nil {
+		for _, l := range pm.MultiGeometry.LineStrings {
+			lines = append(lines, parseKMLCoordinates(l.Coordinates))
+		}
+	}
+	return lines
+}
+
+func (pm kmlPlacemark) polygons() [][][][]float64 {
+	var polys []kmlPolygon
+	if pm.Polygon != nil {
+		polys = append(polys, *pm.Polygon)
+	}
+	if pm.MultiGeometry != nil {
+		polys = append(polys, pm.MultiGeometry.Polygons...)
+	}
+	var out [][][][]float64
+	for _, p := range polys {
+		outer := parseKMLCoordinates(p.Outer.Coordinates)
+		if len(outer) < 4 {
+			continue // not a closed ring
+		}
+		rings := [][][]float64{outer}
+		for _, in := range p.Inner {
+			if ring := parseKMLCoordinates(in.Coordinates); len(ring) >= 4 {
+				rings = append(rings, ring)
+			}
+		}
+		out = append(out, rings)
+	}
+	return out
+}
+
+// data returns the first non-empty ExtendedData value under any of names,
+// compared case-insensitively.
+func (pm kmlPlacemark) data(names ...string) string {
+	for _, name := range names {
+		for _, d := range pm.Data {
+			if strings.EqualFold(d.Name, name) && strings.TrimSpace(d.Value) != "" {
+				return strings.TrimSpace(d.Value)
+			}
+		}
+		for _, d := range pm.SimpleData {
+			if strings.EqualFold(d.Name, name) && strings.TrimSpace(d.Value) != "" {
+				return strings.TrimSpace(d.Value)
+			}
+		}
+	}
+	return ""
+}
+
+func (pm kmlPlacemark) number(names ...string) (float64, bool) {
+	for _, name := range names {
+		if v, err := strconv.ParseFloat(pm.data(name), 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,280 @@
+package adapters
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// kmzOf zips the named KML fixture into a KMZ archive.
+func kmzOf(t *testing.T, name string) []byte {
+	t.Helper()
+	kml := readFixture(t, name)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("doc.kml")
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	w.Write(kml)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// serveNHC serves CurrentStorms.json, with product links pointing back at
+// the test server, and the KMZ products of the Atlantic storm. The Pacific
+// depression's track link 404s.
+func serveNHC(t *testing.T) *httptest.Server {
+	t.Helper()
+	index := readFixture(t, "nhc.json")
+	products := map[string][]byte{
+		"/storm_graphics/api/AL052026_019adv_TRACK.kmz": kmzOf(t, "nhc_track.kml"),
+		"/storm_graphics/api/AL052026_019adv_CONE.kmz":  kmzOf(t, "nhc_cone.kml"),
+		"/gis/best_track/al052026_best_track.kmz":       kmzOf(t, "nhc_best_track.kml"),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/CurrentStorms.json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(bytes.ReplaceAll(index, []byte("{{BASE}}"), []byte(srv.URL)))
+			return
+		}
+		if data, ok := products[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "application/vnd.google-earth.kmz")
+			w.Write(data)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestNHC(t *testing.T, srv *httptest.Server) *NHCAdapter {
+	t.Helper()
+	a := NewNHCAdapter(srv.Client())
+	a.baseURL = srv.URL + "/CurrentStorms.json"
+	return a
+}
+
+func TestNHCSourceAndSupportedTypes(t *testing.T) {
+	t.Parallel()
+	a := NewNHCAdapter(nil)
+	if got := a.Source(); got != "nhc" {
+		t.Errorf("Source() = %q, want %q", got, "nhc")
+	}
+	if got := a.SupportedTypes(); !slices.Equal(got, []string{"hurricane", "cyclone"}) {
+		t.Errorf("SupportedTypes() = %v, want [hurricane cyclone]", got)
+	}
+}
+
+func TestNHCFetchEvents(t *testing.T) {
+	t.Parallel()
+	a := newTestNHC(t, serveNHC(t))
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	t.Run("hurricane with track, forecast and cone", func(t *testing.T) {
+		e := eventByID(t, events, "nhc-al052026")
+		if e.Title != "Hurricane Ernesto" {
+			t.Errorf("Title = %q", e.Title)
+		}
+		if e.EventType != "hurricane" || e.Source != "nhc" {
+			t.Errorf("EventType, Source = %q, %q; want hurricane, nhc", e.EventType, e.Source)
+		}
+		if e.Magnitude == nil || *e.Magnitude != 100 {
+			t.Errorf("Magnitude = %v, want 100 kt", e.Magnitude)
+		}
+		if e.Severity != "extreme" {
+			t.Errorf("Severity = %q, want extreme for a 100 kt hurricane", e.Severity)
+		}
+		if want := time.Date(2026, 8, 15, 21, 0, 0, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want %v", e.UpdatedAt, want)
+		}
+		if want := time.Date(2026, 8, 12, 6, 0, 0, 0, time.UTC); !e.StartedAt.Equal(want) {
+			t.Errorf("StartedAt = %v, want the best track's first fix %v", e.StartedAt, want)
+		}
+		if e.Geometry.Type != "Point" || !slices.Equal(e.Geometry.Coordinates, []float64{-66.2, 24.1}) {
+			t.Errorf("anchor = %s %v, want Point [-66.2 24.1]", e.Geometry.Type, e.Geometry.Coordinates)
+		}
+		if got, _ := e.Metadata["shapes"].([]string); !slices.Equal(got, []string{"past_track", "forecast_points", "cone"}) {
+			t.Fatalf("shapes = %v", e.Metadata["shapes"])
+		}
+		if len(e.Geometry.Shapes) != 3 {
+			t.Fatalf("got %d shapes, want 3", len(e.Geometry.Shapes))
+		}
+
+		past := e.Geometry.Shapes[0]
+		if past.Type != "LineString" || len(past.Positions) != 5 {
+			t.Errorf("past track = %s with %d positions, want LineString with 5 (shared joints once)", past.Type, len(past.Positions))
+		} else if !slices.Equal(past.Positions[0], []float64{-60, 15}) || !slices.Equal(past.Positions[4], []float64{-66.2, 24.1}) {
+			t.Errorf("past track runs %v to %v", past.Positions[0], past.Positions[4])
+		}
+
+		fc := e.Geometry.Shapes[1]
+		if fc.Type != "MultiPoint" || len(fc.Positions) != 3 {
+			t.Errorf("forecast = %s with %d positions, want MultiPoint with 3", fc.Type, len(fc.Positions))
+		}
+		forecast, _ := e.Metadata["forecast"].([]map[string]any)
+		if len(forecast) != 3 {
+			t.Fatalf("forecast metadata = %v", e.Metadata["forecast"])
+		}
+		wantWinds := []float64{100, 110, 85}
+		for i, want := range wantWinds {
+			if got := forecast[i]["max_wind_kt"]; got != want {
+				t.Errorf("forecast[%d].max_wind_kt = %v, want %v", i, got, want)
+			}
+		}
+		if forecast[1]["tau_hours"] != 24.0 {
+			t.Errorf("forecast[1].tau_hours = %v, want 24", forecast[1]["tau_hours"])
+		}
+		if forecast[0]["valid"] != "5:00 PM AST Thu Aug 15" || forecast[2]["valid"] != "2:00 PM AST August 17" {
+			t.Errorf("valid labels = %v, %v", forecast[0]["valid"], forecast[2]["valid"])
+		}
+
+		cone := e.Geometry.Shapes[2]
+		if cone.Type != "Polygon" || len(cone.Rings) != 1 || len(cone.Rings[0]) != 6 {
+			t.Errorf("cone = %s with rings %v", cone.Type, cone.Rings)
+		}
+
+		m := e.Metadata
+		if m["pressure_mb"] != 962.0 || m["movement_speed_mph"] != 14.0 || m["advisory"] != "19" {
+			t.Errorf("metadata = %v", m)
+		}
+	})
+
+	t.Run("depression with a missing product stays a point", func(t *testing.T) {
+		e := eventByID(t, events, "nhc-ep092026")
+		if e.Title != "Tropical Depression Nine-E" || e.EventType != "cyclone" {
+			t.Errorf("Title, EventType = %q, %q", e.Title, e.EventType)
+		}
+		if e.Severity != "minor" {
+			t.Errorf("Severity = %q, want minor", e.Severity)
+		}
+		if len(e.Geometry.Shapes) != 0 {
+			t.Errorf("shapes = %v, want none", e.Geometry.Shapes)
+		}
+		if _, ok := e.Metadata["shapes"]; ok {
+			t.Error("shapes metadata set without shapes")
+		}
+	})
+}
+
+func TestNHCStartStaysAtFirstAdvisory(t *testing.T) {
+	t.Parallel()
+	var lastUpdate atomic.Value
+	lastUpdate.Store("2026-08-15T20:00:00Z")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"activeStorms":[{"id":"ep092026","name":"Nine-E","classification":"TD","intensity":"30","lastUpdate":%q}]}`, lastUpdate.Load())
+	}))
+	t.Cleanup(srv.Close)
+	a := NewNHCAdapter(srv.Client())
+	a.baseURL = srv.URL
+
+	first := time.Date(2026, 8, 15, 20, 0, 0, 0, time.UTC)
+	for _, update := range []string{"2026-08-15T20:00:00Z", "2026-08-16T02:00:00Z", "2026-08-16T08:00:00Z"} {
+		lastUpdate.Store(update)
+		events, err := a.FetchEvents(context.Background(), FetchParams{})
+		if err != nil {
+			t.Fatalf("FetchEvents: %v", err)
+		}
+		e := events[0]
+		if !e.StartedAt.Equal(first) {
+			t.Errorf("advisory of %s: StartedAt = %v, want the first advisory %v", update, e.StartedAt, first)
+		}
+		if want, _ := time.Parse(time.RFC3339, update); !e.UpdatedAt.Equal(want) {
+			t.Errorf("advisory of %s: UpdatedAt = %v, want it", update, e.UpdatedAt)
+		}
+	}
+}
+
+func TestNHCCancelledFetchFails(t *testing.T) {
+	t.Parallel()
+	srv := serveNHC(t)
+	a := newTestNHC(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.client = &http.Client{Transport: cancelOnKMZ{cancel: cancel, next: srv.Client().Transport}}
+	if _, err := a.FetchEvents(ctx, FetchParams{}); err == nil {
+		t.Fatal("expected an error when the context is cancelled mid-fetch, not storms without products")
+	}
+}
+
+// cancelOnKMZ cancels the request context when the first product is
+// requested, as a client disconnecting mid-fetch would.
+type cancelOnKMZ struct {
+	cancel context.CancelFunc
+	next   http.RoundTripper
+}
+
+func (c cancelOnKMZ) RoundTrip(r *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(r.URL.Path, ".kmz") {
+		c.cancel()
+		return nil, r.Context().Err()
+	}
+	return c.next.RoundTrip(r)
+}
+
+func TestNHCNon200(t *testing.T) {
+	t.Parallel()
+	a := newTestNHC(t, serveRaw(t, 503, "unavailable"))
+
+	_, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 503") {
+		t.Errorf("error = %v, want mention of status 503", err)
+	}
+}
+
+func TestNHCSeverity(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		class string
+		wind  float64
+		want  string
+	}{
+		{"HU", 140, "extreme"},
+		{"HU", 96, "extreme"},
+		{"HU", 95, "severe"},
+		{"TS", 50, "moderate"},
+		{"STS", 40, "moderate"},
+		{"TD", 30, "minor"},
+		{"PTC", 35, "minor"},
+	}
+	for _, tc := range cases {
+		if got := nhcSeverity(tc.class, tc.wind); got != tc.want {
+			t.Errorf("nhcSeverity(%q, %v) = %q, want %q", tc.class, tc.wind, got, tc.want)
+		}
+	}
+}
+
+func TestParseKMLCoordinates(t *testing.T) {
+	t.Parallel()
+	got := parseKMLCoordinates("  -66.2,24.1,0\n\t-67,27 bad -68.5,x,0 ")
+	want := [][]float64{{-66.2, 24.1}, {-67, 27}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("position %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
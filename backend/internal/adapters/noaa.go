@@ -5,55 +5,66 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/ugc"
 )
 
 const noaaBaseURL = "https://api.weather.gov/alerts/active"
 
-// NWS event keywords -> our event type
-var noaaEventTypeMap = map[string]string{
-	"tornado":        "tornado",
-	"flood":          "flood",
-	"flash flood":    "flood",
-	"hurricane":      "hurricane",
-	"typhoon":        "hurricane",
-	"tropical storm": "storm",
-	"severe storm":   "storm",
-	"thunderstorm":   "storm",
-	"winter storm":   "winter_storm",
-	"blizzard":       "winter_storm",
-	"ice storm":      "winter_storm",
-	"tsunami":        "tsunami",
-	"earthquake":     "earthquake",
-	"volcano":        "volcano",
-	"wildfire":       "wildfire",
-	"fire":           "wildfire",
-	"red flag":       "wildfire",
-}
-
 type NOAAAdapter struct {
 	client    *http.Client
 	userAgent string
 	baseURL   string
+	now       func() time.Time
+	zones     ugc.Source
+	types     *noaaClassifier
 }
 
 func NewNOAAAdapter(client *http.Client, userAgent string) *NOAAAdapter {
-	return &NOAAAdapter{client: client, userAgent: userAgent, baseURL: noaaBaseURL}
+	return &NOAAAdapter{
+		client:    client,
+		userAgent: userAgent,
+		baseURL:   noaaBaseURL,
+		now:       time.Now,
+		zones:     ugc.Static(ugc.Embedded()),
+		types:     defaultNOAAClassifier,
+	}
+}
+
+// SetZones replaces the embedded zone dataset that alerts without a
+// geometry are located with.
+func (a *NOAAAdapter) SetZones(zones ugc.Source) {
+	a.zones = zones
+}
+
+// SetClassification layers c over the built-in event name table.
+func (a *NOAAAdapter) SetClassification(c NOAAClassification) {
+	a.types = newNOAAClassifier(c)
 }
 
 func (a *NOAAAdapter) Source() string {
 	return "noaa"
 }
 
+// noaaEventTypes is everything classifyNOAAEvent can emit, including the
+// "weather" fallback for alerts with no more specific class.
+var noaaEventTypes = defaultNOAAClassifier.types()
+
+// SupportedTypes returns the classification table's types, computed when
+// the table was built.
 func (a *NOAAAdapter) SupportedTypes() []string {
-	// Everything classifyNOAAEvent can emit, including the "weather"
-	// fallback for alerts with no more specific class.
-	return []string{"flood", "storm", "tornado", "hurricane", "winter_storm", "tsunami", "wildfire", "earthquake", "volcano", "weather"}
+	return a.types.types()
 }
 
+// FetchEvents fetches every active message, updates and cancellations
+// included, and folds each alert's messages into one event; see
+// noaaEvents.
 func (a *NOAAAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
 	if err != nil {
@@ -65,7 +76,6 @@ func (a *NOAAAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]mo
 
 	q := req.URL.Query()
 	q.Set("status", "actual")
-	q.Set("message_type", "alert")
 	req.URL.RawQuery = q.Encode()
 
 	resp, err := a.client.Do(req)
@@ -88,9 +98,9 @@ func (a *NOAAAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]mo
 		requestedTypes[t] = struct{}{}
 	}
 
-	events := make([]models.Event, 0, len(result.Features))
-	for _, f := range result.Features {
-		e := parseNOAAFeature(f)
+	all := noaaEvents(result.Features, a.now(), a.zones.Dataset(), a.types)
+	events := make([]models.Event, 0, len(all))
+	for _, e := range all {
 		if e.EventType == "" {
 			continue
 		}
@@ -108,43 +118,215 @@ func (a *NOAAAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]mo
 	return events, nil
 }
 
-func parseNOAAFeature(f noaaFeature) models.Event {
-	eventType := classifyNOAAEvent(f.Properties.Event)
+// noaaVTEC is one P-VTEC string of an alert, such as
+// /O.CON.KFWD.TO.W.0123.260810T1400Z-260810T1500Z/. Office, phenomenon,
+// significance and event tracking number identify the hazard event across
+// its messages; the action says what a message does to it.
+type noaaVTEC struct {
+	action       string
+	office       string
+	phenomenon   string
+	significance string
+	etn          string
+}
 
-	severity := strings.ToLower(f.Properties.Severity)
-	switch severity {
-	case "extreme":
-		severity = "extreme"
-	case "severe":
-		severity = "severe"
-	case "moderate":
-		severity = "moderate"
-	case "minor":
-		severity = "minor"
-	default:
-		severity = ""
+var noaaVTECPattern = regexp.MustCompile(`^/([OTEX])\.([A-Z]{3})\.([A-Z]{4})\.([A-Z]{2})\.([A-Z])\.(\d{4})\.\d{6}T\d{4}Z-\d{6}T\d{4}Z/$`)
+
+// parseNOAAVTEC returns the operational VTEC strings among values. Test and
+// experimental ones, marked T, E or X, describe no real hazard.
+func parseNOAAVTEC(values []string) []noaaVTEC {
+	var out []noaaVTEC
+	for _, v := range values {
+		m := noaaVTECPattern.FindStringSubmatch(strings.TrimSpace(v))
+		if m == nil || m[1] != "O" {
+			continue
+		}
+		out = append(out, noaaVTEC{action: m[2], office: m[3], phenomenon: m[4], significance: m[5], etn: m[6]})
 	}
+	return out
+}
 
-	var coords []float64
-	if f.Geometry != nil {
-		coords = f.Geometry.centroid()
+// key identifies the hazard event, without the year its tracking number
+// belongs to.
+func (v noaaVTEC) key() string {
+	return v.office + "." + v.phenomenon + "." + v.significance + "." + v.etn
+}
+
+// ends reports whether the action ends the event: cancelled, expired, or
+// upgraded to another event, which the same message starts.
+func (v noaaVTEC) ends() bool {
+	return v.action == "CAN" || v.action == "EXP" || v.action == "UPG"
+}
+
+// noaaMessage is a feature assigned to the alert it belongs to, with the
+// VTEC entry that placed it there, if any.
+type noaaMessage struct {
+	feature noaaFeature
+	sent    time.Time
+	vtec    *noaaVTEC
+}
+
+// noaaEvents folds the feed's messages into one event per alert. Messages
+// with VTEC belong to each hazard event they name, by tracking number, so
+// a warning keeps its ID as it is continued, extended and updated; others
+// belong to the original message their references lead back to. The
+// latest message describes the event. An alert that is cancelled,
+// upgraded or past its end is closed, ending when that happened, so only
+// status=closed shows it. An alert sent without a geometry, as many
+// zone-based ones are, is located by its zones in the dataset.
+func noaaEvents(features []noaaFeature, now time.Time, zones *ugc.Dataset, types *noaaClassifier) []models.Event {
+	groups := make(map[string][]noaaMessage)
+	var order []string
+	add := func(key string, m noaaMessage) {
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+	for _, f := range features {
+		sent := parseNOAATime(f.Properties.Sent)
+		if vtecs := parseNOAAVTEC(f.Properties.Parameters["VTEC"]); len(vtecs) > 0 {
+			for _, v := range vtecs {
+				add("vtec:"+v.key(), noaaMessage{feature: f, sent: sent, vtec: &v})
+			}
+			continue
+		}
+		add("ref:"+f.Properties.originalID(), noaaMessage{feature: f, sent: sent})
 	}
 
-	var startedAt, updatedAt time.Time
-	if f.Properties.Onset != "" {
-		if t, err := time.Parse(time.RFC3339, f.Properties.Onset); err == nil {
-			startedAt = t
+	events := make([]models.Event, 0, len(order))
+	for _, key := range order {
+		msgs := groups[key]
+		slices.SortStableFunc(msgs, func(x, y noaaMessage) int { return x.sent.Compare(y.sent) })
+		first, latest := msgs[0], msgs[len(msgs)-1]
+		// An upgrade is sent as the new warning, and a cancellation only
+		// says the alert is over, so the event is described by its latest
+		// message that doesn't end it.
+		described := latest
+		for _, m := range slices.Backward(msgs) {
+			if !m.ends() {
+				described = m
+				break
+			}
+		}
+
+		e := parseNOAAFeature(described.feature, types)
+		if len(e.Geometry.Coordinates) < 2 {
+			if geom, ok := locateNOAAZones(described.feature.Properties.ugcCodes(), zones); ok {
+				e.Geometry = geom
+				e.Metadata["geometry_source"] = "ugc"
+			}
+		}
+		e.UpdatedAt = latest.sent
+		for _, m := range msgs {
+			if onset := parseNOAATime(m.feature.Properties.Onset); !onset.IsZero() && onset.Before(e.StartedAt) {
+				e.StartedAt = onset
+			}
+		}
+		p := latest.feature.Properties
+		if p.MessageType != "" {
+			e.Metadata["message_type"] = strings.ToLower(p.MessageType)
+		}
+		if v := latest.vtec; v != nil {
+			e.ID = fmt.Sprintf("noaa-%d-%s", first.sent.UTC().Year(), strings.ReplaceAll(v.key(), ".", "-"))
+			e.Metadata["vtec"] = v.key()
+			e.Metadata["vtec_action"] = v.action
+		} else {
+			e.ID = "noaa-" + p.originalID()
+		}
+
+		end := parseNOAATime(p.Ends)
+		if end.IsZero() {
+			end = parseNOAATime(p.Expires)
+		}
+		switch {
+		case latest.ends():
+			e.EndedAt = latest.sent
+		case !end.IsZero() && end.Before(now):
+			e.EndedAt = end
 		}
+		events = append(events, e)
 	}
-	if f.Properties.Sent != "" {
-		if t, err := time.Parse(time.RFC3339, f.Properties.Sent); err == nil {
-			updatedAt = t
+	return events
+}
+
+// locateNOAAZones builds a geometry from the zones of codes found in the
+// dataset: each zone's boundary as a shape, anchored at the mean of their
+// centroids. Codes missing from the dataset are skipped; ok is false if
+// none is found.
+func locateNOAAZones(codes []string, zones *ugc.Dataset) (geom models.Geometry, ok bool) {
+	var sumLon, sumLat float64
+	var n int
+	for _, code := range codes {
+		z, found := zones.Lookup(code)
+		if !found {
+			continue
+		}
+		sumLon += z.Centroid[0]
+		sumLat += z.Centroid[1]
+		n++
+		for _, rings := range z.Polygons {
+			geom.Shapes = append(geom.Shapes, models.Shape{Type: "Polygon", Rings: rings})
 		}
 	}
+	if n == 0 {
+		return models.Geometry{}, false
+	}
+	geom.Type = "Point"
+	geom.Coordinates = []float64{sumLon / float64(n), sumLat / float64(n)}
+	return geom, true
+}
+
+// ends reports whether the message ends its alert.
+func (m noaaMessage) ends() bool {
+	if m.vtec != nil {
+		return m.vtec.ends()
+	}
+	return strings.EqualFold(m.feature.Properties.MessageType, "cancel")
+}
+
+// parseNOAATime parses an RFC 3339 time, zero if empty or malformed.
+func parseNOAATime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func parseNOAAFeature(f noaaFeature, types *noaaClassifier) models.Event {
+	eventType := types.classify(f.Properties.Event)
+
+	// NWS alerts are CAP, whose severities are ours.
+	severity := capSeverity(f.Properties.Severity)
+
+	var coords []float64
+	if f.Geometry != nil {
+		coords = f.Geometry.centroid()
+	}
+
+	startedAt := parseNOAATime(f.Properties.Onset)
+	updatedAt := parseNOAATime(f.Properties.Sent)
 	if startedAt.IsZero() {
 		startedAt = updatedAt
 	}
 
+	metadata := map[string]any{
+		"event":       f.Properties.Event,
+		"area_desc":   f.Properties.AreaDesc,
+		"urgency":     f.Properties.Urgency,
+		"certainty":   f.Properties.Certainty,
+		"sender_name": f.Properties.SenderName,
+	}
+	if f.Properties.MessageType != "" {
+		metadata["message_type"] = strings.ToLower(f.Properties.MessageType)
+	}
+	for key, value := range map[string]string{"ends": f.Properties.Ends, "expires": f.Properties.Expires} {
+		if t := parseNOAATime(value); !t.IsZero() {
+			metadata[key] = t.UTC().Format(time.RFC3339)
+		}
+	}
+
 	return models.Event{
 		ID:          fmt.Sprintf("noaa-%s", f.Properties.ID),
 		Title:       f.Properties.Headline,
@@ -159,24 +341,8 @@ func parseNOAAFeature(f noaaFeature) models.Event {
 		StartedAt: startedAt,
 		UpdatedAt: updatedAt,
 		URL:       f.Properties.Web,
-		Metadata: map[string]any{
-			"event":       f.Properties.Event,
-			"area_desc":   f.Properties.AreaDesc,
-			"urgency":     f.Properties.Urgency,
-			"certainty":   f.Properties.Certainty,
-			"sender_name": f.Properties.SenderName,
-		},
-	}
-}
-
-func classifyNOAAEvent(event string) string {
-	lower := strings.ToLower(event)
-	for keyword, eventType := range noaaEventTypeMap {
-		if strings.Contains(lower, keyword) {
-			return eventType
-		}
+		Metadata:  metadata,
 	}
-	return "weather"
 }
 
 // NOAA GeoJSON-LD response types
@@ -231,16 +397,66 @@ func (g *noaaGeometry) centroid() []float64 {
 }
 
 type noaaProperties struct {
-	ID          string `json:"id"`
-	Event       string `json:"event"`
-	Headline    string `json:"headline"`
-	Description string `json:"description"`
-	Severity    string `json:"severity"`
-	Urgency     string `json:"urgency"`
-	Certainty   string `json:"certainty"`
-	Onset       string `json:"onset"`
-	Sent        string `json:"sent"`
-	AreaDesc    string `json:"areaDesc"`
-	SenderName  string `json:"senderName"`
-	Web         string `json:"web"`
+	ID          string              `json:"id"`
+	Event       string              `json:"event"`
+	Headline    string              `json:"headline"`
+	Description string              `json:"description"`
+	Severity    string              `json:"severity"`
+	Urgency     string              `json:"urgency"`
+	Certainty   string              `json:"certainty"`
+	Onset       string              `json:"onset"`
+	Sent        string              `json:"sent"`
+	Expires     string              `json:"expires"`
+	Ends        string              `json:"ends"`
+	MessageType string              `json:"messageType"`
+	References  []noaaReference     `json:"references"`
+	Parameters  map[string][]string `json:"parameters"`
+	Geocode     struct {
+		UGC []string `json:"UGC"`
+	} `json:"geocode"`
+	AffectedZones []string `json:"affectedZones"`
+	AreaDesc      string   `json:"areaDesc"`
+	SenderName    string   `json:"senderName"`
+	Web           string   `json:"web"`
+}
+
+// ugcCodes returns the alert's UGC zone and county codes, from its geocode
+// and the zone URLs in affectedZones, which end in the code.
+func (p noaaProperties) ugcCodes() []string {
+	var codes []string
+	seen := make(map[string]bool)
+	add := func(code string) {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	for _, code := range p.Geocode.UGC {
+		add(code)
+	}
+	for _, zone := range p.AffectedZones {
+		add(path.Base(zone))
+	}
+	return codes
+}
+
+// noaaReference names an earlier message that an update or cancellation
+// supersedes.
+type noaaReference struct {
+	Identifier string `json:"identifier"`
+	Sent       string `json:"sent"`
+}
+
+// originalID returns the identifier of the first message in the alert's
+// chain: NWS references list every message an update supersedes, so the
+// earliest sent is where the alert began.
+func (p noaaProperties) originalID() string {
+	id, first := p.ID, parseNOAATime(p.Sent)
+	for _, r := range p.References {
+		if sent := parseNOAATime(r.Sent); r.Identifier != "" && (first.IsZero() || sent.Before(first)) {
+			id, first = r.Identifier, sent
+		}
+	}
+	return id
 }
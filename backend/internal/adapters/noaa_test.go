@@ -3,11 +3,16 @@ package adapters
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/ugc"
 )
 
 const testUserAgent = "SentryAtlasTest/0.0 (test@example.com)"
@@ -25,7 +30,7 @@ func TestNOAASourceAndSupportedTypes(t *testing.T) {
 	if got := a.Source(); got != "noaa" {
 		t.Errorf("Source() = %q, want %q", got, "noaa")
 	}
-	want := []string{"flood", "storm", "tornado", "hurricane", "winter_storm", "tsunami", "wildfire", "earthquake", "volcano", "weather"}
+	want := []string{"earthquake", "flood", "hurricane", "storm", "tornado", "tsunami", "volcano", "weather", "wildfire", "winter_storm"}
 	if got := a.SupportedTypes(); !slices.Equal(got, want) {
 		t.Errorf("SupportedTypes() = %v, want %v", got, want)
 	}
@@ -144,8 +149,9 @@ func TestNOAAUserAgentAndQuery(t *testing.T) {
 	if got := q.Get("status"); got != "actual" {
 		t.Errorf("status = %q, want actual", got)
 	}
-	if got := q.Get("message_type"); got != "alert" {
-		t.Errorf("message_type = %q, want alert", got)
+	// Updates and cancellations are needed to follow each alert.
+	if q.Has("message_type") {
+		t.Errorf("message_type = %q, want every message type", q.Get("message_type"))
 	}
 }
 
@@ -184,6 +190,130 @@ func TestNOAASinceFilter(t *testing.T) {
 	eventByID(t, events, "noaa-urn:oid:2.49.0.1.840.0.flood1")
 }
 
+func TestNOAAAlertLifecycle(t *testing.T) {
+	t.Parallel()
+	srv := serveFixture(t, "noaa_lifecycle.json", nil)
+	a := newTestNOAA(t, srv)
+	now := time.Date(2026, 8, 10, 16, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	want := []string{
+		"noaa-2026-KFWD-SV-W-0042",
+		"noaa-2026-KFWD-TO-A-0510",
+		"noaa-2026-KFWD-TO-W-0017",
+		"noaa-2026-KFWD-FA-Y-0088",
+		"noaa-urn:oid:2.49.0.1.840.0.sps1",
+	}
+	if got := eventIDs(events); !slices.Equal(got, want) {
+		t.Fatalf("IDs = %v, want %v", got, want)
+	}
+
+	// Three messages, one warning: dated from the first onset, described
+	// by the latest message.
+	sv := eventByID(t, events, "noaa-2026-KFWD-SV-W-0042")
+	if sv.Closed() || sv.Metadata["vtec_action"] != "EXT" || sv.Metadata["message_type"] != "update" {
+		t.Errorf("warning closed = %v, metadata = %v", sv.Closed(), sv.Metadata)
+	}
+	if want := time.Date(2026, 8, 10, 14, 0, 0, 0, time.UTC); !sv.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want the first onset %v", sv.StartedAt, want)
+	}
+	if want := time.Date(2026, 8, 10, 15, 30, 0, 0, time.UTC); !sv.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want the latest sent %v", sv.UpdatedAt, want)
+	}
+	if sv.Metadata["ends"] != "2026-08-10T17:00:00Z" || sv.Metadata["expires"] != "2026-08-10T17:00:00Z" {
+		t.Errorf("ends/expires = %v/%v", sv.Metadata["ends"], sv.Metadata["expires"])
+	}
+	if sv.Title != "Severe Thunderstorm Warning extended until 12:00PM CDT" {
+		t.Errorf("Title = %q", sv.Title)
+	}
+
+	// The watch upgraded to a tornado warning ends when the warning is
+	// issued, still described by its own message.
+	watch := eventByID(t, events, "noaa-2026-KFWD-TO-A-0510")
+	if want := time.Date(2026, 8, 10, 15, 0, 0, 0, time.UTC); !watch.EndedAt.Equal(want) || !watch.UpdatedAt.Equal(want) {
+		t.Errorf("upgraded watch EndedAt/UpdatedAt = %v/%v, want %v", watch.EndedAt, watch.UpdatedAt, want)
+	}
+	if watch.Metadata["event"] != "Tornado Watch" || watch.Metadata["vtec_action"] != "UPG" {
+		t.Errorf("upgraded watch metadata = %v", watch.Metadata)
+	}
+	if tor := eventByID(t, events, "noaa-2026-KFWD-TO-W-0017"); tor.Closed() || tor.EventType != "tornado" {
+		t.Errorf("tornado warning closed = %v, type = %q", tor.Closed(), tor.EventType)
+	}
+
+	// Expired at its end time; cancelled when the cancellation was sent.
+	if fa := eventByID(t, events, "noaa-2026-KFWD-FA-Y-0088"); !fa.EndedAt.Equal(time.Date(2026, 8, 10, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("expired advisory EndedAt = %v", fa.EndedAt)
+	}
+	sps := eventByID(t, events, "noaa-urn:oid:2.49.0.1.840.0.sps1")
+	if want := time.Date(2026, 8, 10, 15, 45, 0, 0, time.UTC); !sps.EndedAt.Equal(want) || sps.Metadata["message_type"] != "cancel" {
+		t.Errorf("cancelled statement EndedAt = %v, metadata = %v", sps.EndedAt, sps.Metadata)
+	}
+	if sps.Title != "Special Weather Statement issued August 10 at 7:00AM CDT" {
+		t.Errorf("cancelled statement Title = %q, want the statement's", sps.Title)
+	}
+}
+
+func TestNOAAZoneGeometry(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open(filepath.Join("testdata", "ugc_zones.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zones, err := ugc.Load(f)
+	if err != nil {
+		t.Fatalf("load zones: %v", err)
+	}
+	a := newTestNOAA(t, serveFixture(t, "noaa_zones.json", nil))
+	a.SetZones(ugc.Static(zones))
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+
+	// Two forecast zones and the county, from geocode and affectedZones.
+	heat := eventByID(t, events, "noaa-urn:oid:2.49.0.1.840.0.heat1")
+	if c := heat.Geometry.Coordinates; len(c) != 2 || math.Abs(c[0]+97.1167) > 1e-3 || c[1] != 32.77 {
+		t.Errorf("anchor = %v, want the mean of the zone centroids", c)
+	}
+	if n := len(heat.Geometry.Shapes); n != 3 {
+		t.Errorf("%d shapes, want a polygon per zone", n)
+	}
+	if heat.Metadata["geometry_source"] != "ugc" {
+		t.Errorf("geometry_source = %v", heat.Metadata["geometry_source"])
+	}
+
+	// Zones missing from the dataset leave the alert unlocated.
+	marine := eventByID(t, events, "noaa-urn:oid:2.49.0.1.840.0.marine1")
+	if len(marine.Geometry.Coordinates) != 0 || marine.Metadata["geometry_source"] != nil {
+		t.Errorf("marine geometry = %+v, metadata = %v", marine.Geometry, marine.Metadata)
+	}
+}
+
+func TestParseNOAAVTEC(t *testing.T) {
+	t.Parallel()
+	got := parseNOAAVTEC([]string{
+		"/O.UPG.KFWD.TO.A.0510.000000T0000Z-260810T2000Z/",
+		"/T.NEW.KFWD.TO.W.9999.260810T1500Z-260810T1600Z/",
+		"/O.NEW.KFWD.TO.W.0017.260810T1500Z-260810T1600Z/",
+		"not vtec",
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want the 2 operational ones: %+v", len(got), got)
+	}
+	if got[0].key() != "KFWD.TO.A.0510" || !got[0].ends() {
+		t.Errorf("upgrade = %+v", got[0])
+	}
+	if got[1].key() != "KFWD.TO.W.0017" || got[1].ends() {
+		t.Errorf("new = %+v", got[1])
+	}
+}
+
 func TestNOAACentroid(t *testing.T) {
 	t.Parallel()
 	cases := []struct {
@@ -234,6 +364,10 @@ func TestClassifyNOAAEvent(t *testing.T) {
 		{"Volcano Warning", "volcano"},
 		{"Red Flag Warning", "wildfire"},
 		{"Fire Weather Watch", "wildfire"},
+		{"Hurricane Force Wind Warning", "storm"},
+		{"Storm Surge Warning", "hurricane"},
+		{"flash flood warning", "flood"},
+		{"Flash Flood Emergency", "flood"},
 		{"Special Weather Statement", "weather"},
 		{"Air Quality Alert", "weather"},
 		{"", "weather"},
@@ -0,0 +1,314 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// noaaEventNames classifies every event name the NWS issues, as listed by
+// api.weather.gov/alerts/types. Exact names come first so that each one
+// has a single, reviewed class: by keyword alone "Flash Flood Warning"
+// and "Fire Weather Watch" each match two rules, and "Hurricane Force
+// Wind Warning" is a winter gale, not a hurricane. Names not listed here
+// map to "weather".
+var noaaEventNames = map[string]string{
+	"911 Telephone Outage Emergency":          "weather",
+	"Administrative Message":                  "weather",
+	"Air Quality Alert":                       "weather",
+	"Air Stagnation Advisory":                 "weather",
+	"Arroyo and Small Stream Flood Advisory":  "flood",
+	"Ashfall Advisory":                        "volcano",
+	"Ashfall Warning":                         "volcano",
+	"Avalanche Advisory":                      "weather",
+	"Avalanche Warning":                       "weather",
+	"Avalanche Watch":                         "weather",
+	"Beach Hazards Statement":                 "weather",
+	"Blizzard Warning":                        "winter_storm",
+	"Blizzard Watch":                          "winter_storm",
+	"Blowing Dust Advisory":                   "weather",
+	"Blowing Dust Warning":                    "weather",
+	"Brisk Wind Advisory":                     "weather",
+	"Child Abduction Emergency":               "weather",
+	"Civil Danger Warning":                    "weather",
+	"Civil Emergency Message":                 "weather",
+	"Coastal Flood Advisory":                  "flood",
+	"Coastal Flood Statement":                 "flood",
+	"Coastal Flood Warning":                   "flood",
+	"Coastal Flood Watch":                     "flood",
+	"Cold Weather Advisory":                   "weather",
+	"Dense Fog Advisory":                      "weather",
+	"Dense Smoke Advisory":                    "weather",
+	"Dust Advisory":                           "weather",
+	"Dust Storm Warning":                      "weather",
+	"Earthquake Warning":                      "earthquake",
+	"Evacuation - Immediate":                  "weather",
+	"Excessive Heat Warning":                  "weather",
+	"Excessive Heat Watch":                    "weather",
+	"Extreme Cold Warning":                    "weather",
+	"Extreme Cold Watch":                      "weather",
+	"Extreme Fire Danger":                     "wildfire",
+	"Extreme Heat Warning":                    "weather",
+	"Extreme Heat Watch":                      "weather",
+	"Extreme Wind Warning":                    "hurricane",
+	"Fire Warning":                            "wildfire",
+	"Fire Weather Watch":                      "wildfire",
+	"Flash Flood Statement":                   "flood",
+	"Flash Flood Warning":                     "flood",
+	"Flash Flood Watch":                       "flood",
+	"Flood Advisory":                          "flood",
+	"Flood Statement":                         "flood",
+	"Flood Warning":                           "flood",
+	"Flood Watch":                             "flood",
+	"Freeze Warning":                          "weather",
+	"Freeze Watch":                            "weather",
+	"Freezing Fog Advisory":                   "weather",
+	"Freezing Rain Advisory":                  "winter_storm",
+	"Freezing Spray Advisory":                 "weather",
+	"Frost Advisory":                          "weather",
+	"Gale Warning":                            "weather",
+	"Gale Watch":                              "weather",
+	"Hard Freeze Warning":                     "weather",
+	"Hard Freeze Watch":                       "weather",
+	"Hazardous Materials Warning":             "weather",
+	"Hazardous Seas Warning":                  "weather",
+	"Hazardous Seas Watch":                    "weather",
+	"Hazardous Weather Outlook":               "weather",
+	"Heat Advisory":                           "weather",
+	"Heavy Freezing Spray Warning":            "weather",
+	"Heavy Freezing Spray Watch":              "weather",
+	"High Surf Advisory":                      "weather",
+	"High Surf Warning":                       "weather",
+	"High Wind Warning":                       "weather",
+	"High Wind Watch":                         "weather",
+	"Hurricane Force Wind Warning":            "storm",
+	"Hurricane Force Wind Watch":              "storm",
+	"Hurricane Local Statement":               "hurricane",
+	"Hurricane Warning":                       "hurricane",
+	"Hurricane Watch":                         "hurricane",
+	"Hydrologic Advisory":                     "flood",
+	"Hydrologic Outlook":                      "flood",
+	"Ice Storm Warning":                       "winter_storm",
+	"Lake Effect Snow Advisory":               "winter_storm",
+	"Lake Effect Snow Warning":                "winter_storm",
+	"Lake Effect Snow Watch":                  "winter_storm",
+	"Lake Wind Advisory":                      "weather",
+	"Lakeshore Flood Advisory":                "flood",
+	"Lakeshore Flood Statement":               "flood",
+	"Lakeshore Flood Warning":                 "flood",
+	"Lakeshore Flood Watch":                   "flood",
+	"Law Enforcement Warning":                 "weather",
+	"Local Area Emergency":                    "weather",
+	"Low Water Advisory":                      "weather",
+	"Marine Weather Statement":                "weather",
+	"Nuclear Power Plant Warning":             "weather",
+	"Radiological Hazard Warning":             "weather",
+	"Red Flag Warning":                        "wildfire",
+	"Rip Current Statement":                   "weather",
+	"Severe Thunderstorm Warning":             "storm",
+	"Severe Thunderstorm Watch":               "storm",
+	"Severe Weather Statement":                "storm",
+	"Shelter In Place Warning":                "weather",
+	"Short Term Forecast":                     "weather",
+	"Small Craft Advisory":                    "weather",
+	"Small Craft Advisory For Hazardous Seas": "weather",
+	"Small Craft Advisory For Rough Bar":      "weather",
+	"Small Craft Advisory For Winds":          "weather",
+	"Small Stream Flood Advisory":             "flood",
+	"Snow Squall Warning":                     "winter_storm",
+	"Special Marine Warning":                  "storm",
+	"Special Weather Statement":               "weather",
+	"Storm Surge Warning":                     "hurricane",
+	"Storm Surge Watch":                       "hurricane",
+	"Storm Warning":                           "storm",
+	"Storm Watch":                             "storm",
+	"Test":                                    "weather",
+	"Tornado Warning":                         "tornado",
+	"Tornado Watch":                           "tornado",
+	"Tropical Depression Local Statement":     "storm",
+	"Tropical Storm Local Statement":          "storm",
+	"Tropical Storm Warning":                  "storm",
+	"Tropical Storm Watch":                    "storm",
+	"Tsunami Advisory":                        "tsunami",
+	"Tsunami Warning":                         "tsunami",
+	"Tsunami Watch":                           "tsunami",
+	"Typhoon Local Statement":                 "hurricane",
+	"Typhoon Warning":                         "hurricane",
+	"Typhoon Watch":                           "hurricane",
+	"Urban and Small Stream Flood Advisory":   "flood",
+	"Volcano Warning":                         "volcano",
+	"Wind Advisory":                           "weather",
+	"Wind Chill Advisory":                     "weather",
+	"Wind Chill Warning":                      "weather",
+	"Wind Chill Watch":                        "weather",
+	"Winter Storm Warning":                    "winter_storm",
+	"Winter Storm Watch":                      "winter_storm",
+	"Winter Weather Advisory":                 "winter_storm",
+}
+
+// noaaKeywordRules classify names missing from noaaEventNames, such as a
+// new NWS product or another agency's CAP event, in order: the first rule
+// whose keyword the name contains wins, so more specific keywords come
+// before those they contain.
+var noaaKeywordRules = []NOAAKeywordRule{
+	{"tornado", "tornado"},
+	{"hurricane", "hurricane"},
+	{"typhoon", "hurricane"},
+	{"tropical storm", "storm"},
+	{"winter storm", "winter_storm"},
+	{"ice storm", "winter_storm"},
+	{"blizzard", "winter_storm"},
+	{"severe storm", "storm"},
+	{"thunderstorm", "storm"},
+	{"tsunami", "tsunami"},
+	{"flash flood", "flood"},
+	{"flood", "flood"},
+	{"earthquake", "earthquake"},
+	{"volcano", "volcano"},
+	{"red flag", "wildfire"},
+	{"wildfire", "wildfire"},
+	{"fire", "wildfire"},
+}
+
+// NOAAClassification is a rule table for NWS event names, as read from
+// NWS_EVENT_TYPES_FILE. It is layered over the built-in table: its
+// events replace or add to the built-in names, its keywords are tried
+// before the built-in ones, and its default, if set, replaces "weather".
+type NOAAClassification struct {
+	// Events maps event names, compared case-insensitively, to event
+	// types.
+	Events map[string]string `json:"events,omitempty"`
+	// Keywords classify names Events lacks, first match wins.
+	Keywords []NOAAKeywordRule `json:"keywords,omitempty"`
+	// Default is the type of a name no rule matches.
+	Default string `json:"default,omitempty"`
+}
+
+// NOAAKeywordRule classifies event names containing Keyword, compared
+// case-insensitively, as Type.
+type NOAAKeywordRule struct {
+	Keyword string `json:"keyword"`
+	Type    string `json:"type"`
+}
+
+// LoadNOAAClassification reads and validates a NOAAClassification file, so
+// a bad rule fails at startup rather than misfiling alerts.
+func LoadNOAAClassification(path string) (NOAAClassification, error) {
+	var c NOAAClassification
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return NOAAClassification{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return NOAAClassification{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return NOAAClassification{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c NOAAClassification) validate() error {
+	for name, t := range c.Events {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("events: empty event name")
+		}
+		if !models.IsValidEventType(t) {
+			return fmt.Errorf("events[%q]: unknown event type %q", name, t)
+		}
+	}
+	for i, r := range c.Keywords {
+		if strings.TrimSpace(r.Keyword) == "" {
+			return fmt.Errorf("keywords[%d]: empty keyword", i)
+		}
+		if !models.IsValidEventType(r.Type) {
+			return fmt.Errorf("keywords[%d]: unknown event type %q", i, r.Type)
+		}
+	}
+	if c.Default != "" && !models.IsValidEventType(c.Default) {
+		return fmt.Errorf("unknown default %q", c.Default)
+	}
+	return nil
+}
+
+// noaaClassifier is a rule table ready to classify with.
+type noaaClassifier struct {
+	exact    map[string]string // by lower-case name
+	keywords []NOAAKeywordRule // lower-case keywords, in order
+	fallback string
+	// emits is every type the table can emit, sorted. It is computed
+	// once, as SupportedTypes is asked on every request.
+	emits []string
+}
+
+// defaultNOAAClassifier is the built-in table, which CAP feeds use too.
+var defaultNOAAClassifier = newNOAAClassifier(NOAAClassification{})
+
+// newNOAAClassifier layers c over the built-in table.
+func newNOAAClassifier(c NOAAClassification) *noaaClassifier {
+	nc := &noaaClassifier{
+		exact:    make(map[string]string, len(noaaEventNames)+len(c.Events)),
+		fallback: "weather",
+	}
+	for name, t := range noaaEventNames {
+		nc.exact[strings.ToLower(name)] = t
+	}
+	for name, t := range c.Events {
+		nc.exact[strings.ToLower(strings.TrimSpace(name))] = t
+	}
+	for _, r := range slices.Concat(c.Keywords, noaaKeywordRules) {
+		nc.keywords = append(nc.keywords, NOAAKeywordRule{Keyword: strings.ToLower(r.Keyword), Type: r.Type})
+	}
+	if c.Default != "" {
+		nc.fallback = c.Default
+	}
+	nc.emits = nc.collectTypes()
+	return nc
+}
+
+func (c *noaaClassifier) classify(event string) string {
+	lower := strings.ToLower(strings.TrimSpace(event))
+	if t, ok := c.exact[lower]; ok {
+		return t
+	}
+	for _, r := range c.keywords {
+		if strings.Contains(lower, r.Keyword) {
+			return r.Type
+		}
+	}
+	return c.fallback
+}
+
+// types returns every type the table can emit, sorted. The slice is
+// shared and must not be modified.
+func (c *noaaClassifier) types() []string {
+	return c.emits
+}
+
+func (c *noaaClassifier) collectTypes() []string {
+	types := []string{c.fallback}
+	add := func(t string) {
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	for _, t := range c.exact {
+		add(t)
+	}
+	for _, r := range c.keywords {
+		add(r.Type)
+	}
+	slices.Sort(types)
+	return types
+}
+
+func classifyNOAAEvent(event string) string {
+	return defaultNOAAClassifier.classify(event)
+}
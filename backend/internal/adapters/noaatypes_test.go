@@ -0,0 +1,190 @@
+package adapters
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// Every event name api.weather.gov/alerts/types lists classifies as
+// reviewed in testdata, by the exact table rather than a keyword: by
+// keyword alone "Flash Flood Warning" and "Fire Weather Watch" each match
+// two rules, and map iteration order once made them vary between runs.
+// noaa_alert_types.json is a copy of the API's response; refresh it, and
+// review the new names in noaa_alert_types_classified.json, as the NWS
+// adds products.
+func TestNOAAEventNamesMatchGolden(t *testing.T) {
+	t.Parallel()
+	var listed struct {
+		EventTypes []string `json:"eventTypes"`
+	}
+	var want map[string]string
+	for name, v := range map[string]any{"noaa_alert_types.json": &listed, "noaa_alert_types_classified.json": &want} {
+		if err := json.Unmarshal(readFixture(t, name), v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	if len(listed.EventTypes) == 0 {
+		t.Fatal("no event types listed")
+	}
+
+	for _, name := range listed.EventTypes {
+		typ, ok := want[name]
+		if !ok {
+			t.Errorf("%q is listed by the API but has no reviewed class", name)
+			continue
+		}
+		if _, ok := noaaEventNames[name]; !ok {
+			t.Errorf("%q is missing from noaaEventNames", name)
+		}
+		for _, variant := range []string{name, strings.ToLower(name), " " + strings.ToUpper(name) + " "} {
+			if got := classifyNOAAEvent(variant); got != typ {
+				t.Errorf("classifyNOAAEvent(%q) = %q, want %q", variant, got, typ)
+			}
+		}
+	}
+	for name := range want {
+		if !slices.Contains(listed.EventTypes, name) {
+			t.Errorf("%q has a class but is not listed by the API", name)
+		}
+	}
+}
+
+// Names missing from the exact table, such as a new NWS product or another
+// agency's CAP event, fall back to the first keyword they contain.
+func TestNOAAKeywordClassification(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		event, want string
+	}{
+		{"Tornado Emergency", "tornado"},
+		{"Hurricane Statement", "hurricane"},
+		{"Typhoon Statement", "hurricane"},
+		{"Tropical Storm Flood Statement", "storm"}, // tropical storm before flood
+		{"Ice Storm Watch", "winter_storm"},
+		{"Blizzard Advisory", "winter_storm"},
+		{"Severe Storm Outlook", "storm"},
+		{"Thunderstorm Advisory", "storm"},
+		{"Tsunami Information Statement", "tsunami"},
+		{"Flash Flood Emergency", "flood"},
+		{"River Flood Outlook", "flood"},
+		{"Earthquake Aftershock Statement", "earthquake"},
+		{"Volcano Ash Statement", "volcano"},
+		{"Red Flag Watch", "wildfire"},
+		{"Wildfire Smoke Statement", "wildfire"},
+		{"Fire Danger Statement", "wildfire"},
+		{"Volcanic Ash Advisory", "weather"}, // no keyword: "volcanic" is not "volcano"
+		{"Heat Wave Statement", "weather"},
+	}
+	for _, tc := range cases {
+		if _, ok := noaaEventNames[tc.event]; ok {
+			t.Errorf("%q is in the exact table; pick a name that is not", tc.event)
+			continue
+		}
+		if got := classifyNOAAEvent(tc.event); got != tc.want {
+			t.Errorf("classifyNOAAEvent(%q) = %q, want %q", tc.event, got, tc.want)
+		}
+	}
+	for _, r := range noaaKeywordRules {
+		if !models.IsValidEventType(r.Type) {
+			t.Errorf("keyword %q maps to unknown event type %q", r.Keyword, r.Type)
+		}
+	}
+}
+
+// A keyword that contains an earlier one could never match.
+func TestNOAAKeywordRulesReachable(t *testing.T) {
+	t.Parallel()
+	for i, r := range noaaKeywordRules {
+		for _, earlier := range noaaKeywordRules[:i] {
+			if strings.Contains(r.Keyword, earlier.Keyword) {
+				t.Errorf("keyword %q is shadowed by %q", r.Keyword, earlier.Keyword)
+			}
+		}
+	}
+}
+
+func TestNOAAClassificationOverride(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "types.json")
+	data := `{
+		"events": {"storm surge warning": "flood", "Dense Smoke Advisory": "wildfire"},
+		"keywords": [{"keyword": "Iceberg", "type": "iceberg"}],
+		"default": "other"
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadNOAAClassification(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewNOAAAdapter(nil, testUserAgent)
+	a.SetClassification(c)
+	cases := []struct {
+		event, want string
+	}{
+		{"Storm Surge Warning", "flood"},
+		{"Dense Smoke Advisory", "wildfire"},
+		{"Iceberg Flood Bulletin", "iceberg"},
+		{"Tornado Warning", "tornado"},
+		{"Flash Flood Emergency", "flood"},
+		{"Unheard Of Statement", "other"},
+	}
+	for _, tc := range cases {
+		if got := a.types.classify(tc.event); got != tc.want {
+			t.Errorf("classify(%q) = %q, want %q", tc.event, got, tc.want)
+		}
+	}
+	got := a.SupportedTypes()
+	for _, want := range []string{"iceberg", "other", "weather", "flood"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("SupportedTypes() = %v, missing %q", got, want)
+		}
+	}
+
+	// The override is the adapter's own; other users of the built-in
+	// table, CAP feeds among them, are unaffected.
+	if got := classifyNOAAEvent("Storm Surge Warning"); got != "hurricane" {
+		t.Errorf("built-in classifyNOAAEvent(%q) = %q, want %q", "Storm Surge Warning", got, "hurricane")
+	}
+}
+
+func TestLoadNOAAClassificationInvalid(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"unknown type":    `{"events": {"Flood Warning": "deluge"}}`,
+		"empty name":      `{"events": {" ": "flood"}}`,
+		"empty keyword":   `{"keywords": [{"keyword": "", "type": "flood"}]}`,
+		"keyword type":    `{"keywords": [{"keyword": "smoke", "type": "haze"}]}`,
+		"unknown default": `{"default": "misc"}`,
+		"unknown field":   `{"rules": []}`,
+	}
+	for name, data := range cases {
+		path := filepath.Join(t.TempDir(), "types.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadNOAAClassification(path); err == nil {
+			t.Errorf("%s: LoadNOAAClassification succeeded, want error", name)
+		}
+	}
+}
+
+func TestNOAASupportedTypesComputedOnce(t *testing.T) {
+	a := NewNOAAAdapter(nil, "")
+	a.SetClassification(NOAAClassification{Events: map[string]string{"Iceberg Warning": "iceberg"}})
+	if !slices.Contains(a.SupportedTypes(), "iceberg") {
+		t.Fatalf("SupportedTypes() = %v, want the override's iceberg", a.SupportedTypes())
+	}
+	// Asked on every request: it must not rebuild the set each time.
+	if n := testing.AllocsPerRun(100, func() { a.SupportedTypes() }); n != 0 {
+		t.Errorf("SupportedTypes allocates %v times per call, want 0", n)
+	}
+}
@@ -0,0 +1,436 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const nwpsBaseURL = "https://api.water.noaa.gov/nwps/v1"
+
+// nwpsFetchConcurrency bounds the per-gauge requests in flight at once.
+const nwpsFetchConcurrency = 8
+
+// nwpsThresholdsTTL is how long a gauge's flood stages are reused. They
+// change when a gauge is resurveyed, not from one flood to the next.
+const nwpsThresholdsTTL = 24 * time.Hour
+
+// nwpsTrendWindow is how far back the trend compares the latest observed
+// stage, and nwpsSteadyChange the change below which it is steady.
+const (
+	nwpsTrendWindow  = 6 * time.Hour
+	nwpsSteadyChange = 0.1
+)
+
+// nwpsMissing is how NWPS marks a value it does not have.
+const nwpsMissing = -999
+
+// nwpsCategoryRank orders the flood categories that mean flooding. The
+// others — no_flooding, action, not_defined, obs_not_current and so on —
+// are not reported.
+var nwpsCategoryRank = map[string]int{
+	"minor":    1,
+	"moderate": 2,
+	"major":    3,
+}
+
+// nwpsCategorySeverity maps a flood category onto Severity. Major is the
+// top of the river scale but not necessarily catastrophic, so it ranks
+// severe like the NWS flood warnings that accompany it.
+var nwpsCategorySeverity = map[string]string{
+	"minor":    models.SeverityMinor,
+	"moderate": models.SeverityModerate,
+	"major":    models.SeveritySevere,
+}
+
+// NWPSAdapter reports river gauges of the NWS National Water Prediction
+// Service that are at or forecast above flood stage. The gauge list gives
+// each gauge's observed and forecast flood category; for gauges in flood,
+// the stage hydrograph adds the trend and forecast crest, and the gauge's
+// flood stages date the flood's onset.
+type NWPSAdapter struct {
+	client  *http.Client
+	baseURL string
+
+	mu         sync.Mutex
+	thresholds map[string]nwpsThresholdsEntry // by gauge LID
+}
+
+type nwpsThresholdsEntry struct {
+	stages    nwpsFloodStages
+	fetchedAt time.Time
+}
+
+func NewNWPSAdapter(client *http.Client) *NWPSAdapter {
+	return &NWPSAdapter{
+		client:     client,
+		baseURL:    nwpsBaseURL,
+		thresholds: make(map[string]nwpsThresholdsEntry),
+	}
+}
+
+func (a *NWPSAdapter) Source() string {
+	return "nwps"
+}
+
+func (a *NWPSAdapter) SupportedTypes() []string {
+	return []string{"flood"}
+}
+
+// FetchEvents ignores params: gauge status is current conditions only. A
+// gauge whose hydrograph or flood stages cannot be read is still reported,
+// from the list alone.
+func (a *NWPSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	var list nwpsGaugeList
+	if err := a.getJSON(ctx, "/gauges", &list); err != nil {
+		return nil, fmt.Errorf("nwps: %w", err)
+	}
+
+	var flooding []nwpsGauge
+	for _, g := range list.Gauges {
+		if g.LID != "" && g.floodRank() > 0 {
+			flooding = append(flooding, g)
+		}
+	}
+
+	events := make([]models.Event, len(flooding))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(nwpsFetchConcurrency)
+	for i, gauge := range flooding {
+		g.Go(func() error {
+			var flow nwpsStageFlow
+			if err := a.getJSON(gctx, "/gauges/"+url.PathEscape(gauge.LID)+"/stageflow", &flow); err != nil {
+				if gctx.Err() != nil {
+					return gctx.Err()
+				}
+				slog.Warn("nwps: hydrograph unavailable", "gauge", gauge.LID, "error", err)
+			}
+			stages, err := a.floodStages(gctx, gauge.LID)
+			if err != nil {
+				if gctx.Err() != nil {
+					return gctx.Err()
+				}
+				slog.Warn("nwps: flood stages unavailable", "gauge", gauge.LID, "error", err)
+			}
+			events[i] = parseNWPSGauge(gauge, flow, stages)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("nwps: %w", err)
+	}
+	return events, nil
+}
+
+// floodStages returns a gauge's flood stages, cached for a day. A failed
+// refresh keeps serving the previous stages.
+func (a *NWPSAdapter) floodStages(ctx context.Context, lid string) (nwpsFloodStages, error) {
+	a.mu.Lock()
+	cached, ok := a.thresholds[lid]
+	a.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < nwpsThresholdsTTL {
+		return cached.stages, nil
+	}
+
+	var detail nwpsGaugeDetail
+	if err := a.getJSON(ctx, "/gauges/"+url.PathEscape(lid), &detail); err != nil {
+		return cached.stages, err
+	}
+	c := detail.Flood.Categories
+	stages := nwpsFloodStages{
+		Minor:    nwpsStageOf(c.Minor.Stage),
+		Moderate: nwpsStageOf(c.Moderate.Stage),
+		Major:    nwpsStageOf(c.Major.Stage),
+	}
+
+	a.mu.Lock()
+	a.thresholds[lid] = nwpsThresholdsEntry{stages: stages, fetchedAt: time.Now()}
+	a.mu.Unlock()
+	return stages, nil
+}
+
+func (a *NWPSAdapter) getJSON(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func parseNWPSGauge(g nwpsGauge, flow nwpsStageFlow, stages nwpsFloodStages) models.Event {
+	observed, forecast := g.Status.Observed, g.Status.Forecast
+	category := strings.ToLower(observed.FloodCategory)
+	forecastCategory := strings.ToLower(forecast.FloodCategory)
+
+	// The worse of now and the forecast sets the severity; the title says
+	// which it is.
+	worst := category
+	if nwpsCategoryRank[forecastCategory] > nwpsCategoryRank[worst] {
+		worst = forecastCategory
+	}
+	title := g.Name + ": " + titleCase(worst) + " Flooding"
+	if nwpsCategoryRank[category] == 0 {
+		title += " Forecast"
+	}
+
+	metadata := map[string]any{
+		"lid":            g.LID,
+		"flood_category": category,
+	}
+	if forecastCategory != "" {
+		metadata["forecast_flood_category"] = forecastCategory
+	}
+	if v, ok := nwpsValue(observed.Primary); ok {
+		metadata["stage"] = v
+		metadata["stage_unit"] = observed.PrimaryUnit
+	}
+	if v, ok := nwpsValue(observed.Secondary); ok {
+		metadata["flow"] = v
+		metadata["flow_unit"] = observed.SecondaryUnit
+	}
+	for key, stage := range map[string]nwpsStage{
+		"flood_stage":          stages.Minor,
+		"moderate_flood_stage": stages.Moderate,
+		"major_flood_stage":    stages.Major,
+	} {
+		if stage.ok {
+			metadata[key] = stage.value
+		}
+	}
+	if trend := flow.Observed.trend(); trend != "" {
+		metadata["trend"] = trend
+	}
+	if crest, ok := flow.Forecast.crest(); ok {
+		metadata["forecast_crest"] = crest.Primary
+		if flow.Forecast.PrimaryUnits != "" {
+			metadata["forecast_crest_unit"] = flow.Forecast.PrimaryUnits
+		}
+		metadata["forecast_crest_time"] = crest.ValidTime.UTC().Format(time.RFC3339)
+	}
+	if g.WFO.Abbreviation != "" {
+		metadata["wfo"] = g.WFO.Abbreviation
+	}
+	if g.State.Abbreviation != "" {
+		metadata["state"] = g.State.Abbreviation
+	}
+
+	updated := observed.ValidTime
+	if updated.IsZero() {
+		updated = forecast.ValidTime
+	}
+	started := updated
+	if onset, ok := flow.onset(stages.Minor); ok {
+		started = onset
+	}
+
+	return models.Event{
+		ID:        "nwps-" + strings.ToLower(g.LID),
+		Title:     title,
+		EventType: "flood",
+		Source:    "nwps",
+		Geometry: models.Geometry{
+			Type:        "Point",
+			Coordinates: []float64{g.Longitude, g.Latitude},
+		},
+		Severity:  nwpsCategorySeverity[worst],
+		StartedAt: started.UTC(),
+		UpdatedAt: updated.UTC(),
+		URL:       "https://water.noaa.gov/gauges/" + url.PathEscape(strings.ToLower(g.LID)),
+		Metadata:  metadata,
+	}
+}
+
+type nwpsGaugeList struct {
+	Gauges []nwpsGauge `json:"gauges"`
+}
+
+type nwpsGauge struct {
+	LID       string  `json:"lid"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	WFO       struct {
+		Abbreviation string `json:"abbreviation"`
+	} `json:"wfo"`
+	State struct {
+		Abbreviation string `json:"abbreviation"`
+	} `json:"state"`
+	Status struct {
+		Observed nwpsStatus `json:"observed"`
+		Forecast nwpsStatus `json:"forecast"`
+	} `json:"status"`
+}
+
+// floodRank is the rank of the worse of the observed and forecast flood
+// categories, 0 when neither is flooding.
+func (g nwpsGauge) floodRank() int {
+	return max(
+		nwpsCategoryRank[strings.ToLower(g.Status.Observed.FloodCategory)],
+		nwpsCategoryRank[strings.ToLower(g.Status.Forecast.FloodCategory)],
+	)
+}
+
+type nwpsStatus struct {
+	Primary       float64   `json:"primary"`
+	PrimaryUnit   string    `json:"primaryUnit"`
+	Secondary     float64   `json:"secondary"`
+	SecondaryUnit string    `json:"secondaryUnit"`
+	FloodCategory string    `json:"floodCategory"`
+	ValidTime     time.Time `json:"validTime"`
+}
+
+type nwpsGaugeDetail struct {
+	Flood struct {
+		Categories struct {
+			Minor    nwpsCategory `json:"minor"`
+			Moderate nwpsCategory `json:"moderate"`
+			Major    nwpsCategory `json:"major"`
+		} `json:"categories"`
+	} `json:"flood"`
+}
+
+type nwpsCategory struct {
+	Stage float64 `json:"stage"`
+}
+
+// nwpsFloodStages are a gauge's minor, moderate and major flood stages.
+type nwpsFloodStages struct {
+	Minor, Moderate, Major nwpsStage
+}
+
+type nwpsStage struct {
+	value float64
+	ok    bool
+}
+
+// nwpsValue reads a value that NWPS marks missing with nwpsMissing or
+// below.
+func nwpsValue(v float64) (float64, bool) {
+	return v, v > nwpsMissing
+}
+
+// nwpsStageOf is nwpsValue as an nwpsStage.
+func nwpsStageOf(v float64) nwpsStage {
+	v, ok := nwpsValue(v)
+	return nwpsStage{value: v, ok: ok}
+}
+
+type nwpsStageFlow struct {
+	Observed nwpsSeries `json:"observed"`
+	Forecast nwpsSeries `json:"forecast"`
+}
+
+type nwpsSeries struct {
+	PrimaryUnits string        `json:"primaryUnits"`
+	Data         []nwpsReading `json:"data"`
+}
+
+type nwpsReading struct {
+	ValidTime time.Time `json:"validTime"`
+	Primary   float64   `json:"primary"`
+}
+
+// readings returns the series' valid readings, in time order as NWPS
+// lists them.
+func (s nwpsSeries) readings() []nwpsReading {
+	var out []nwpsReading
+	for _, r := range s.Data {
+		if _, ok := nwpsValue(r.Primary); ok && !r.ValidTime.IsZero() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (s nwpsSeries) inFeet() bool {
+	return strings.EqualFold(s.PrimaryUnits, "ft")
+}
+
+// trend compares the latest reading with the earliest within
+// nwpsTrendWindow of it: rising, falling or steady.
+func (s nwpsSeries) trend() string {
+	rs := s.readings()
+	if len(rs) < 2 {
+		return ""
+	}
+	latest := rs[len(rs)-1]
+	earliest := latest
+	for i := len(rs) - 2; i >= 0 && latest.ValidTime.Sub(rs[i].ValidTime) <= nwpsTrendWindow; i-- {
+		earliest = rs[i]
+	}
+	if earliest.ValidTime.Equal(latest.ValidTime) {
+		return ""
+	}
+	switch change := latest.Primary - earliest.Primary; {
+	case change >= nwpsSteadyChange:
+		return "rising"
+	case change <= -nwpsSteadyChange:
+		return "falling"
+	default:
+		return "steady"
+	}
+}
+
+// crest is the highest forecast reading, the first if it is reached more
+// than once.
+func (s nwpsSeries) crest() (nwpsReading, bool) {
+	rs := s.readings()
+	if len(rs) == 0 {
+		return nwpsReading{}, false
+	}
+	best := rs[0]
+	for _, r := range rs[1:] {
+		if r.Primary > best.Primary {
+			best = r
+		}
+	}
+	return best, true
+}
+
+// onset dates the flood: the start of the run of observed readings at or
+// above flood stage that the latest ends, or if the river is not in flood
+// yet, the first forecast reading that reaches it. Flood stages are in
+// feet, so a gauge reporting only flow has no onset.
+func (f nwpsStageFlow) onset(floodStage nwpsStage) (time.Time, bool) {
+	if !floodStage.ok {
+		return time.Time{}, false
+	}
+	observed := f.Observed.readings()
+	if n := len(observed); n > 0 && f.Observed.inFeet() && observed[n-1].Primary >= floodStage.value {
+		start := observed[n-1].ValidTime
+		for i := n - 2; i >= 0 && observed[i].Primary >= floodStage.value; i-- {
+			start = observed[i].ValidTime
+		}
+		return start, true
+	}
+	if !f.Forecast.inFeet() {
+		return time.Time{}, false
+	}
+	for _, r := range f.Forecast.readings() {
+		if r.Primary >= floodStage.value {
+			return r.ValidTime, true
+		}
+	}
+	return time.Time{}, false
+}
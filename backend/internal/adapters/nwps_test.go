@@ -0,0 +1,196 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nwpsServer serves the NWPS fixtures, counting requests by path. Paths
+// in fail answer 503.
+type nwpsServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]int
+	fail     map[string]bool
+}
+
+func serveNWPS(t *testing.T, fail ...string) *nwpsServer {
+	t.Helper()
+	s := &nwpsServer{requests: make(map[string]int), fail: make(map[string]bool)}
+	for _, p := range fail {
+		s.fail[p] = true
+	}
+	fixtures := readFixtures(t, "nwps_*.json")
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+		if s.fail[r.URL.Path] {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var name string
+		switch parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); {
+		case len(parts) == 1 && parts[0] == "gauges":
+			name = "nwps_gauges.json"
+		case len(parts) == 2:
+			name = "nwps_gauge_" + strings.ToLower(parts[1]) + ".json"
+		case len(parts) == 3 && parts[2] == "stageflow":
+			name = "nwps_stageflow_" + strings.ToLower(parts[1]) + ".json"
+		}
+		data, ok := fixtures[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *nwpsServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func newTestNWPS(srv *nwpsServer) *NWPSAdapter {
+	a := NewNWPSAdapter(srv.Client())
+	a.baseURL = srv.URL
+	return a
+}
+
+func TestNWPSFetchEvents(t *testing.T) {
+	t.Parallel()
+	srv := serveNWPS(t)
+	events, err := newTestNWPS(srv).FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	// The gauges at action stage only, out of date or below flood stage
+	// are left out.
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want the 2 gauges in or forecast in flood", len(events))
+	}
+
+	stl := eventByID(t, events, "nwps-eadm7")
+	if stl.Title != "Mississippi River at St. Louis: Moderate Flooding" || stl.EventType != "flood" || stl.Severity != "moderate" {
+		t.Errorf("title/type/severity = %q/%q/%q", stl.Title, stl.EventType, stl.Severity)
+	}
+	if c := stl.Geometry.Coordinates; c[0] != -90.1797 || c[1] != 38.6292 {
+		t.Errorf("coordinates = %v", c)
+	}
+	// In flood since the 01:00 reading first reached the 30 ft flood stage.
+	if want := time.Date(2026, 8, 20, 1, 0, 0, 0, time.UTC); !stl.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", stl.StartedAt, want)
+	}
+	if want := time.Date(2026, 8, 20, 11, 0, 0, 0, time.UTC); !stl.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", stl.UpdatedAt, want)
+	}
+	for key, want := range map[string]any{
+		"stage":               35.2,
+		"stage_unit":          "ft",
+		"flow":                512.0,
+		"flood_category":      "moderate",
+		"flood_stage":         30.0,
+		"major_flood_stage":   40.0,
+		"trend":               "rising",
+		"forecast_crest":      37.1,
+		"forecast_crest_unit": "ft",
+		"forecast_crest_time": "2026-08-22T12:00:00Z",
+		"wfo":                 "LSX",
+		"state":               "MO",
+	} {
+		if stl.Metadata[key] != want {
+			t.Errorf("metadata[%s] = %v, want %v", key, stl.Metadata[key], want)
+		}
+	}
+	if stl.URL != "https://water.noaa.gov/gauges/eadm7" {
+		t.Errorf("URL = %q", stl.URL)
+	}
+
+	// Only forecast to flood: dated by the first forecast reading at flood
+	// stage, and the missing crest value is skipped.
+	ill := eventByID(t, events, "nwps-chsi2")
+	if ill.Title != "Illinois River at Chillicothe: Minor Flooding Forecast" || ill.Severity != "minor" {
+		t.Errorf("title/severity = %q/%q", ill.Title, ill.Severity)
+	}
+	if want := time.Date(2026, 8, 21, 6, 0, 0, 0, time.UTC); !ill.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", ill.StartedAt, want)
+	}
+	if ill.Metadata["trend"] != "falling" || ill.Metadata["forecast_crest"] != 17.4 || ill.Metadata["flood_category"] != "action" {
+		t.Errorf("metadata = %v", ill.Metadata)
+	}
+	if _, ok := ill.Metadata["flow"]; ok {
+		t.Error("missing flow reported")
+	}
+	if _, ok := ill.Metadata["major_flood_stage"]; ok {
+		t.Error("undefined major flood stage reported")
+	}
+}
+
+func TestNWPSFetchEventsCachesFloodStages(t *testing.T) {
+	t.Parallel()
+	srv := serveNWPS(t)
+	a := newTestNWPS(srv)
+	for range 2 {
+		if _, err := a.FetchEvents(context.Background(), FetchParams{}); err != nil {
+			t.Fatalf("FetchEvents: %v", err)
+		}
+	}
+	if n := srv.count("/gauges/EADM7"); n != 1 {
+		t.Errorf("flood stages fetched %d times, want once", n)
+	}
+	if n := srv.count("/gauges/EADM7/stageflow"); n != 2 {
+		t.Errorf("hydrograph fetched %d times, want every time", n)
+	}
+}
+
+func TestNWPSFetchEventsDegrades(t *testing.T) {
+	t.Parallel()
+	srv := serveNWPS(t, "/gauges/EADM7/stageflow", "/gauges/EADM7")
+	events, err := newTestNWPS(srv).FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	stl := eventByID(t, events, "nwps-eadm7")
+	if _, ok := stl.Metadata["trend"]; ok {
+		t.Errorf("trend without a hydrograph: %v", stl.Metadata)
+	}
+	if !stl.StartedAt.Equal(stl.UpdatedAt) || stl.Metadata["stage"] != 35.2 {
+		t.Errorf("StartedAt = %v, metadata = %v; want the list's reading", stl.StartedAt, stl.Metadata)
+	}
+
+	down := serveNWPS(t, "/gauges")
+	if _, err := newTestNWPS(down).FetchEvents(context.Background(), FetchParams{}); err == nil || !strings.Contains(err.Error(), "nwps: unexpected status 503") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNWPSSeriesTrend(t *testing.T) {
+	t.Parallel()
+	at := func(h int) time.Time { return time.Date(2026, 8, 20, h, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		name string
+		data []nwpsReading
+		want string
+	}{
+		{"one reading", []nwpsReading{{at(0), 10}}, ""},
+		{"steady", []nwpsReading{{at(0), 10}, {at(3), 10.05}}, "steady"},
+		// The 10 ft reading is outside the window; 12 to 11.5 is falling.
+		{"window", []nwpsReading{{at(0), 10}, {at(4), 12}, {at(10), 11.5}}, "falling"},
+		{"missing skipped", []nwpsReading{{at(0), 10}, {at(2), 11}, {at(3), -999}}, "rising"},
+	}
+	for _, tc := range cases {
+		if got := (nwpsSeries{Data: tc.data}).trend(); got != tc.want {
+			t.Errorf("%s: trend = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const (
+	swpcBaseURL    = "https://services.swpc.noaa.gov"
+	swpcAlertsPath = "/products/alerts.json"
+	swpcKpPath     = "/products/noaa-planetary-k-index.json"
+
+	swpcAlertsPage = "https://www.swpc.noaa.gov/products/alerts-watches-and-warnings"
+	swpcKpPage     = "https://www.swpc.noaa.gov/products/planetary-k-index"
+)
+
+// swpcWatchLifetime is how long a geomagnetic storm watch stays in effect
+// without a newer one: watches forecast the next three UT days.
+const swpcWatchLifetime = 3 * 24 * time.Hour
+
+// swpcStormKp is the rounded planetary K-index at which a geomagnetic
+// storm begins, G1. Each step above it is one level on the G scale.
+const swpcStormKp = 5
+
+// SWPCAdapter reports space weather from NOAA's Space Weather Prediction
+// Center: its alerts, watches and warnings, and a geomagnetic storm in
+// progress as measured by the planetary K-index. Space weather has no
+// location, so every event has a models.GlobalGeometry.
+//
+// Geomagnetic (G scale) messages are geomagnetic_storm events; solar
+// radiation storms (S scale) and the radio blackouts of X-ray flares (R
+// scale) are both solar_radiation, told apart by metadata scale.
+type SWPCAdapter struct {
+	client  *http.Client
+	baseURL string
+	now     func() time.Time
+}
+
+func NewSWPCAdapter(client *http.Client) *SWPCAdapter {
+	return &SWPCAdapter{client: client, baseURL: swpcBaseURL, now: time.Now}
+}
+
+func (a *SWPCAdapter) Source() string {
+	return "swpc"
+}
+
+func (a *SWPCAdapter) SupportedTypes() []string {
+	return []string{"geomagnetic_storm", "solar_radiation"}
+}
+
+// FetchEvents ignores params: the products cover the last few days only.
+// The K-index is an addition to the alerts, so if it fails the alerts are
+// still reported.
+func (a *SWPCAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
+	var products []swpcProduct
+	if err := a.getJSON(ctx, swpcAlertsPath, &products); err != nil {
+		return nil, fmt.Errorf("swpc: %w", err)
+	}
+	var messages []swpcMessage
+	for _, p := range products {
+		if m, ok := parseSWPCMessage(p); ok {
+			messages = append(messages, m)
+		}
+	}
+	events := swpcEvents(messages, a.now())
+
+	var kp []json.RawMessage
+	if err := a.getJSON(ctx, swpcKpPath, &kp); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("swpc: %w", ctx.Err())
+		}
+		slog.Warn("swpc: planetary K-index unavailable", "error", err)
+		return events, nil
+	}
+	if e, ok := swpcKpStorm(parseSWPCKp(kp)); ok {
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (a *SWPCAdapter) getJSON(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+type swpcProduct struct {
+	ProductID     string `json:"product_id"`
+	IssueDatetime string `json:"issue_datetime"`
+	Message       string `json:"message"`
+}
+
+// swpcMessage is one parsed alert, watch or warning. SWPC messages are
+// plain text with "Key: value" header lines and a headline such as
+// "WARNING: Geomagnetic K-index of 5 expected".
+type swpcMessage struct {
+	code      string // ALTK05, WARPX1, ...
+	serial    string
+	issued    time.Time
+	kind      string // ALERT, WARNING, EXTENDED WARNING, WATCH, SUMMARY, CANCEL ...
+	headline  string
+	ref       string // the serial an extension or cancellation refers to
+	began     time.Time
+	validFrom time.Time
+	validTo   time.Time
+	scale     string // G1 to G5, S1 to S5, R1 to R5
+	text      string
+}
+
+var (
+	swpcHeadlineRe = regexp.MustCompile(`^((?:CANCEL |EXTENDED )?(?:ALERT|WARNING|WATCH|SUMMARY)):\s*(.+)$`)
+	swpcScaleRe    = regexp.MustCompile(`\b([GSR])([1-5])\b`)
+)
+
+func parseSWPCMessage(p swpcProduct) (swpcMessage, bool) {
+	text := strings.TrimSpace(strings.ReplaceAll(p.Message, "\r\n", "\n"))
+	m := swpcMessage{text: text}
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.TrimSpace(line)
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Space Weather Message Code":
+			m.code = strings.ToUpper(value)
+		case "Serial Number":
+			m.serial = value
+		case "Issue Time":
+			m.issued = parseSWPCTime(value)
+		case "Extension to Serial Number", "Cancel Serial Number":
+			m.ref = value
+		case "Threshold Reached", "Begin Time":
+			m.began = parseSWPCTime(value)
+		case "Valid From":
+			m.validFrom = parseSWPCTime(value)
+		case "Valid To", "Now Valid Until":
+			m.validTo = parseSWPCTime(value)
+		default:
+			if sm := swpcHeadlineRe.FindStringSubmatch(line); sm != nil && m.kind == "" {
+				m.kind, m.headline = sm[1], strings.TrimSpace(sm[2])
+			}
+		}
+	}
+	if m.code == "" || m.serial == "" || m.kind == "" {
+		return swpcMessage{}, false
+	}
+	if m.issued.IsZero() {
+		m.issued, _ = time.Parse("2006-01-02 15:04:05.000", p.IssueDatetime)
+	}
+
+	// A watch lists the highest level for each day; the scale is the
+	// highest of any day.
+	level := 0
+	for _, sm := range swpcScaleRe.FindAllStringSubmatch(text, -1) {
+		if n, _ := strconv.Atoi(sm[2]); n > level {
+			level, m.scale = n, sm[1]+sm[2]
+		}
+	}
+	return m, true
+}
+
+// parseSWPCTime parses message times such as "2026 Aug 20 0859 UTC".
+func parseSWPCTime(s string) time.Time {
+	t, err := time.Parse("2006 Jan 02 1504 MST", strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// swpcEvents resolves messages into what is in effect at now. Extended
+// warnings continue the warning they extend, cancelled ones are dropped and
+// so are expired ones; each watch supersedes every earlier one. Alerts and
+// summaries report something that has happened and stay for as long as
+// SWPC lists them.
+func swpcEvents(messages []swpcMessage, now time.Time) []models.Event {
+	slices.SortStableFunc(messages, func(a, b swpcMessage) int {
+		return a.issued.Compare(b.issued)
+	})
+
+	// Chains of a message and its extensions, keyed by the root's code and
+	// serial, oldest first.
+	root := make(map[string]string)
+	chains := make(map[string][]swpcMessage)
+	cancelled := make(map[string]bool)
+	var watch *swpcMessage
+	for _, m := range messages {
+		key := m.code + "-" + m.serial
+		if m.ref != "" {
+			ref := m.code + "-" + m.ref
+			if r, ok := root[ref]; ok {
+				ref = r
+			}
+			if strings.HasPrefix(m.kind, "CANCEL") {
+				cancelled[ref] = true
+				continue
+			}
+			key = ref
+		}
+		root[m.code+"-"+m.serial] = key
+
+		switch {
+		case m.kind == "CANCEL WATCH":
+			watch = nil
+		case strings.HasPrefix(m.kind, "CANCEL"):
+			// A cancellation without a reference names nothing to drop.
+		case m.kind == "WATCH":
+			watch = &m
+		default:
+			chains[key] = append(chains[key], m)
+		}
+	}
+	if watch != nil && now.Sub(watch.issued) < swpcWatchLifetime {
+		chains[watch.code+"-"+watch.serial] = []swpcMessage{*watch}
+	}
+
+	events := make([]models.Event, 0, len(chains))
+	for _, key := range slices.Sorted(maps.Keys(chains)) {
+		if cancelled[key] {
+			continue
+		}
+		chain := chains[key]
+		latest := chain[len(chain)-1]
+		if !latest.validTo.IsZero() && latest.validTo.Before(now) {
+			continue
+		}
+		events = append(events, swpcEvent(chain[0], latest, len(chain) > 1))
+	}
+	return events
+}
+
+// swpcEvent reports the message chain from first to latest as one event,
+// identified by the first message.
+func swpcEvent(first, latest swpcMessage, extended bool) models.Event {
+	started := first.issued
+	switch {
+	case !first.began.IsZero():
+		started = first.began
+	case !first.validFrom.IsZero():
+		started = first.validFrom
+	}
+
+	kind := strings.TrimPrefix(latest.kind, "EXTENDED ")
+	title := titleCase(kind) + ": " + latest.headline
+	if latest.scale != "" && !strings.Contains(latest.headline, latest.scale) {
+		title += " (" + latest.scale + ")"
+	}
+
+	metadata := map[string]any{
+		"message_code":  latest.code,
+		"serial_number": latest.serial,
+		"message_type":  strings.ToLower(kind),
+	}
+	if latest.scale != "" {
+		metadata["scale"] = latest.scale
+	}
+	if !latest.validFrom.IsZero() {
+		metadata["valid_from"] = latest.validFrom.Format(time.RFC3339)
+	}
+	if !latest.validTo.IsZero() {
+		metadata["valid_to"] = latest.validTo.Format(time.RFC3339)
+	}
+	if extended {
+		metadata["extended"] = true
+	}
+
+	return models.Event{
+		ID:          "swpc-" + strings.ToLower(first.code) + "-" + first.serial,
+		Title:       title,
+		Description: latest.text,
+		EventType:   swpcEventType(latest.code, latest.scale),
+		Source:      "swpc",
+		Geometry:    models.Geometry{Type: models.GlobalGeometry},
+		Severity:    swpcScaleSeverity(latest.scale),
+		StartedAt:   started,
+		UpdatedAt:   latest.issued,
+		URL:         swpcAlertsPage,
+		Metadata:    metadata,
+	}
+}
+
+// swpcEventType classifies by NOAA scale, or without one by message code:
+// K-index messages (ALTK, WARK), storm watches (WATA) and sudden impulses
+// (WARSUD, SUMSUD) are geomagnetic, the particle, X-ray and radio messages
+// solar.
+func swpcEventType(code, scale string) string {
+	if scale != "" {
+		if scale[0] == 'G' {
+			return "geomagnetic_storm"
+		}
+		return "solar_radiation"
+	}
+	for _, prefix := range []string{"ALTK", "WARK", "WATA"} {
+		if strings.HasPrefix(code, prefix) {
+			return "geomagnetic_storm"
+		}
+	}
+	if strings.HasSuffix(code, "SUD") {
+		return "geomagnetic_storm"
+	}
+	return "solar_radiation"
+}
+
+// swpcScaleSeverity maps a NOAA scale level onto Severity. The scales run
+// Minor, Moderate, Strong, Severe, Extreme; Strong and Severe both rank
+// severe. Messages below the scales, a K-index of 4 say, are minor.
+func swpcScaleSeverity(scale string) string {
+	level := 0
+	if len(scale) == 2 {
+		level = int(scale[1] - '0')
+	}
+	switch {
+	case level >= 5:
+		return models.SeverityExtreme
+	case level >= 3:
+		return models.SeveritySevere
+	case level == 2:
+		return models.SeverityModerate
+	default:
+		return models.SeverityMinor
+	}
+}
+
+// swpcKpReading is one 3-hourly planetary K-index value.
+type swpcKpReading struct {
+	at time.Time
+	kp float64
+}
+
+// parseSWPCKp reads the K-index product, oldest first. It has been
+// published both as a table, a header row followed by rows of strings, and
+// as a list of objects; either is accepted.
+func parseSWPCKp(raw []json.RawMessage) []swpcKpReading {
+	var readings []swpcKpReading
+	add := func(at string, kp any) {
+		t, err := time.Parse("2006-01-02 15:04:05.000", at)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02T15:04:05", at); err != nil {
+				return
+			}
+		}
+		var v float64
+		switch kp := kp.(type) {
+		case float64:
+			v = kp
+		case string:
+			if v, err = strconv.ParseFloat(kp, 64); err != nil {
+				return
+			}
+		default:
+			return
+		}
+		readings = append(readings, swpcKpReading{at: t.UTC(), kp: v})
+	}
+
+	if len(raw) > 0 && bytes.HasPrefix(bytes.TrimSpace(raw[0]), []byte("[")) {
+		var header []string
+		if json.Unmarshal(raw[0], &header) != nil {
+			return nil
+		}
+		timeCol, kpCol := slices.Index(header, "time_tag"), slices.Index(header, "Kp")
+		if timeCol < 0 || kpCol < 0 {
+			return nil
+		}
+		for _, r := range raw[1:] {
+			var row []any
+			if json.Unmarshal(r, &row) != nil || len(row) <= max(timeCol, kpCol) {
+				continue
+			}
+			at, _ := row[timeCol].(string)
+			add(at, row[kpCol])
+		}
+	} else {
+		for _, r := range raw {
+			var obj map[string]any
+			if json.Unmarshal(r, &obj) != nil {
+				continue
+			}
+			at, _ := obj["time_tag"].(string)
+			add(at, obj["Kp"])
+		}
+	}
+	slices.SortStableFunc(readings, func(a, b swpcKpReading) int {
+		return a.at.Compare(b.at)
+	})
+	return readings
+}
+
+// swpcKpStorm reports the geomagnetic storm in progress, if the latest
+// K-index is at storm level. The storm began with the run of storm-level
+// readings the latest ends, and keeps that start as its identity while it
+// lasts.
+func swpcKpStorm(readings []swpcKpReading) (models.Event, bool) {
+	if len(readings) == 0 {
+		return models.Event{}, false
+	}
+	latest := readings[len(readings)-1]
+	if swpcKpLevel(latest.kp) < 1 {
+		return models.Event{}, false
+	}
+	start, peak := latest, latest.kp
+	for i := len(readings) - 2; i >= 0 && swpcKpLevel(readings[i].kp) >= 1; i-- {
+		start, peak = readings[i], max(peak, readings[i].kp)
+	}
+
+	scale := fmt.Sprintf("G%d", swpcKpLevel(latest.kp))
+	peakScale := fmt.Sprintf("G%d", swpcKpLevel(peak))
+	kp := latest.kp
+	return models.Event{
+		ID:        "swpc-kp-" + start.at.Format("20060102T1504Z"),
+		Title:     fmt.Sprintf("Geomagnetic storm: Kp %s (%s)", strconv.FormatFloat(kp, 'f', -1, 64), scale),
+		EventType: "geomagnetic_storm",
+		Source:    "swpc",
+		Geometry:  models.Geometry{Type: models.GlobalGeometry},
+		Magnitude: &kp,
+		Severity:  swpcScaleSeverity(peakScale),
+		StartedAt: start.at,
+		UpdatedAt: latest.at,
+		URL:       swpcKpPage,
+		Metadata: map[string]any{
+			"kp":         kp,
+			"scale":      scale,
+			"peak_kp":    peak,
+			"peak_scale": peakScale,
+		},
+	}, true
+}
+
+// swpcKpLevel is the G scale level of a K-index value: Kp 5 is G1 and Kp 9
+// G5. Kp comes in thirds, so 5.67 ("6-") counts as 6.
+func swpcKpLevel(kp float64) int {
+	return min(int(math.Round(kp))-swpcStormKp+1, 5)
+}
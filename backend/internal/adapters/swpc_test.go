@@ -0,0 +1,202 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+var swpcTestNow = time.Date(2026, 8, 20, 12, 0, 0, 0, time.UTC)
+
+// serveSWPC serves the alerts and K-index fixtures; a path mapped to a
+// status in fail answers with it instead.
+func serveSWPC(t *testing.T, fail map[string]int) *httptest.Server {
+	t.Helper()
+	files := map[string][]byte{
+		swpcAlertsPath: readFixture(t, "swpc_alerts.json"),
+		swpcKpPath:     readFixture(t, "swpc_kp.json"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status, ok := fail[r.URL.Path]; ok {
+			w.WriteHeader(status)
+			return
+		}
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSWPC(srv *httptest.Server) *SWPCAdapter {
+	a := NewSWPCAdapter(srv.Client())
+	a.baseURL = srv.URL
+	a.now = func() time.Time { return swpcTestNow }
+	return a
+}
+
+func TestSWPCFetchEvents(t *testing.T) {
+	t.Parallel()
+	events, err := newTestSWPC(serveSWPC(t, nil)).FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+
+	// The cancelled proton warning, the expired K4 warning and the
+	// superseded G3 watch are gone.
+	var got []string
+	for _, e := range events {
+		got = append(got, e.ID)
+		if !e.Geometry.IsGlobal() || e.Source != "swpc" {
+			t.Errorf("%s: geometry = %+v, source = %q", e.ID, e.Geometry, e.Source)
+		}
+	}
+	want := []string{
+		"swpc-altef3-3300",
+		"swpc-altk05-2101",
+		"swpc-sumx01-150",
+		"swpc-wark05-1400",
+		"swpc-wata30-211",
+		"swpc-kp-20260820T0000Z",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("IDs = %v, want %v", got, want)
+	}
+
+	cases := []struct {
+		id, title, typ, severity, scale string
+	}{
+		{"swpc-altef3-3300", "Alert: Electron 2MeV Integral Flux exceeded 1000pfu", "solar_radiation", "minor", ""},
+		{"swpc-altk05-2101", "Alert: Geomagnetic K-index of 5 (G1)", "geomagnetic_storm", "minor", "G1"},
+		{"swpc-sumx01-150", "Summary: X-ray Event exceeded X1 (R3)", "solar_radiation", "severe", "R3"},
+		{"swpc-wata30-211", "Watch: Geomagnetic Storm Category G2 Predicted", "geomagnetic_storm", "moderate", "G2"},
+	}
+	for _, tc := range cases {
+		e := eventByID(t, events, tc.id)
+		if e.Title != tc.title || e.EventType != tc.typ || e.Severity != tc.severity {
+			t.Errorf("%s: title/type/severity = %q/%q/%q, want %q/%q/%q", tc.id, e.Title, e.EventType, e.Severity, tc.title, tc.typ, tc.severity)
+		}
+		if scale, _ := e.Metadata["scale"].(string); scale != tc.scale {
+			t.Errorf("%s: scale = %q, want %q", tc.id, scale, tc.scale)
+		}
+	}
+
+	alert := eventByID(t, events, "swpc-altk05-2101")
+	if want := time.Date(2026, 8, 20, 8, 59, 0, 0, time.UTC); !alert.StartedAt.Equal(want) {
+		t.Errorf("alert StartedAt = %v, want the threshold time %v", alert.StartedAt, want)
+	}
+	if strings.Contains(alert.Description, "\r") || !strings.HasPrefix(alert.Description, "Space Weather Message Code: ALTK05") {
+		t.Errorf("alert Description = %q", alert.Description)
+	}
+
+	// The extension continues the warning it extends.
+	warning := eventByID(t, events, "swpc-wark05-1400")
+	if warning.Metadata["extended"] != true || warning.Metadata["serial_number"] != "1401" ||
+		warning.Metadata["valid_to"] != "2026-08-20T21:00:00Z" || warning.Metadata["message_type"] != "warning" {
+		t.Errorf("warning metadata = %v", warning.Metadata)
+	}
+	if want := time.Date(2026, 8, 20, 6, 20, 0, 0, time.UTC); !warning.StartedAt.Equal(want) {
+		t.Errorf("warning StartedAt = %v, want %v", warning.StartedAt, want)
+	}
+	if want := time.Date(2026, 8, 20, 11, 40, 0, 0, time.UTC); !warning.UpdatedAt.Equal(want) {
+		t.Errorf("warning UpdatedAt = %v, want %v", warning.UpdatedAt, want)
+	}
+
+	// Storm-level Kp since 00:00, when 4.67 ("5-") counts as 5, peaking at 6.
+	kp := eventByID(t, events, "swpc-kp-20260820T0000Z")
+	if kp.Title != "Geomagnetic storm: Kp 5.67 (G2)" || kp.Magnitude == nil || *kp.Magnitude != 5.67 {
+		t.Errorf("kp title/magnitude = %q/%v", kp.Title, kp.Magnitude)
+	}
+	if kp.Severity != "moderate" || kp.Metadata["peak_kp"] != 6.0 || kp.Metadata["peak_scale"] != "G2" {
+		t.Errorf("kp severity = %q, metadata = %v", kp.Severity, kp.Metadata)
+	}
+	if want := time.Date(2026, 8, 20, 9, 0, 0, 0, time.UTC); !kp.UpdatedAt.Equal(want) {
+		t.Errorf("kp UpdatedAt = %v, want %v", kp.UpdatedAt, want)
+	}
+}
+
+func TestSWPCFetchEventsFailures(t *testing.T) {
+	t.Parallel()
+	// Without the K-index the alerts are still reported.
+	events, err := newTestSWPC(serveSWPC(t, map[string]int{swpcKpPath: http.StatusBadGateway})).
+		FetchEvents(context.Background(), FetchParams{})
+	if err != nil || len(events) != 5 {
+		t.Errorf("kp down: %d events, err = %v; want the 5 alerts", len(events), err)
+	}
+
+	_, err = newTestSWPC(serveSWPC(t, map[string]int{swpcAlertsPath: http.StatusServiceUnavailable})).
+		FetchEvents(context.Background(), FetchParams{})
+	if err == nil || !strings.Contains(err.Error(), "swpc: unexpected status 503") {
+		t.Errorf("alerts down: err = %v", err)
+	}
+}
+
+func TestSWPCEventsExpireWatches(t *testing.T) {
+	t.Parallel()
+	watch := swpcMessage{code: "WATA20", serial: "9", kind: "WATCH", headline: "Geomagnetic Storm Category G1 Predicted", issued: swpcTestNow.Add(-swpcWatchLifetime)}
+	if events := swpcEvents([]swpcMessage{watch}, swpcTestNow); len(events) != 0 {
+		t.Errorf("watch issued %v ago still in effect: %v", swpcWatchLifetime, events)
+	}
+	cancel := swpcMessage{code: "WATA20", serial: "10", kind: "CANCEL WATCH", issued: swpcTestNow.Add(-time.Hour)}
+	watch.issued = swpcTestNow.Add(-2 * time.Hour)
+	if events := swpcEvents([]swpcMessage{watch, cancel}, swpcTestNow); len(events) != 0 {
+		t.Errorf("cancelled watch still in effect: %v", events)
+	}
+}
+
+func TestParseSWPCKpObjects(t *testing.T) {
+	t.Parallel()
+	var raw []json.RawMessage
+	data := `[{"time_tag":"2026-08-20T06:00:00","Kp":7.0},{"time_tag":"2026-08-20T03:00:00","Kp":4.67},{"time_tag":"2026-08-20T09:00:00","Kp":8.33}]`
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := swpcKpStorm(parseSWPCKp(raw))
+	if !ok {
+		t.Fatal("no storm reported")
+	}
+	// 4.67 rounds to 5, so the storm runs from 03:00; 8.33 is G4.
+	if e.ID != "swpc-kp-20260820T0300Z" || e.Metadata["scale"] != "G4" || e.Severity != "severe" {
+		t.Errorf("ID/scale/severity = %s/%v/%s", e.ID, e.Metadata["scale"], e.Severity)
+	}
+
+	quiet := []swpcKpReading{{at: swpcTestNow, kp: 6}, {at: swpcTestNow.Add(3 * time.Hour), kp: 4.33}}
+	if e, ok := swpcKpStorm(quiet); ok {
+		t.Errorf("storm reported after Kp fell below 5: %+v", e)
+	}
+}
+
+func TestSWPCScaleSeverity(t *testing.T) {
+	t.Parallel()
+	for scale, want := range map[string]string{
+		"": "minor", "G1": "minor", "S2": "moderate", "R3": "severe", "G4": "severe", "G5": "extreme",
+	} {
+		if got := swpcScaleSeverity(scale); got != want {
+			t.Errorf("swpcScaleSeverity(%q) = %q, want %q", scale, got, want)
+		}
+	}
+	if got := swpcKpLevel(9); got != 5 {
+		t.Errorf("swpcKpLevel(9) = %d, want 5", got)
+	}
+}
+
+func TestSWPCSupportedTypesAreCanonical(t *testing.T) {
+	t.Parallel()
+	for _, typ := range NewSWPCAdapter(nil).SupportedTypes() {
+		if !models.IsValidEventType(typ) {
+			t.Errorf("%q is not a canonical event type", typ)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveTsunami serves both centers' feeds, with product links pointing back
+// at the test server, the CAP document of the NTWC warning's latest
+// bulletin and a USGS query answer. Other CAP documents 404. USGS queries
+// are counted in usgsQueries.
+func serveTsunami(t *testing.T, usgsQueries *atomic.Int32) *httptest.Server {
+	t.Helper()
+	files := map[string][]byte{
+		"/ntwc.xml": readFixture(t, "tsunami_ntwc.xml"),
+		"/ptwc.xml": readFixture(t, "tsunami_ptwc.xml"),
+		"/events/PAAQ/2026/08/14/sny9jb/2/WEAK51/PAAQCAP.xml": readFixture(t, "tsunami_cap.xml"),
+	}
+	usgs := readFixture(t, "tsunami_usgs.json")
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/usgs/query" {
+			if usgsQueries != nil {
+				usgsQueries.Add(1)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(usgs)
+			return
+		}
+		if data, ok := files[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write(bytes.ReplaceAll(data, []byte("{{BASE}}"), []byte(srv.URL)))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// tsunamiTestNow is shortly after the fixtures' bulletins, before the
+// warning's CAP expiry.
+var tsunamiTestNow = time.Date(2026, 8, 14, 8, 0, 0, 0, time.UTC)
+
+func newTestTsunami(t *testing.T, srv *httptest.Server) *TsunamiAdapter {
+	t.Helper()
+	a := NewTsunamiAdapter(srv.Client())
+	a.now = func() time.Time { return tsunamiTestNow }
+	a.feeds = []tsunamiFeed{
+		{center: "NTWC", url: srv.URL + "/ntwc.xml"},
+		{center: "PTWC", url: srv.URL + "/ptwc.xml"},
+	}
+	a.usgsURL = srv.URL + "/usgs"
+	return a
+}
+
+func TestTsunamiSourceAndSupportedTypes(t *testing.T) {
+	t.Parallel()
+	a := NewTsunamiAdapter(nil)
+	if got := a.Source(); got != "tsunami" {
+		t.Errorf("Source() = %q, want %q", got, "tsunami")
+	}
+	if got := a.SupportedTypes(); !slices.Equal(got, []string{"tsunami"}) {
+		t.Errorf("SupportedTypes() = %v, want [tsunami]", got)
+	}
+}
+
+func TestTsunamiMatchesAtUSGSQueryEndpoint(t *testing.T) {
+	t.Parallel()
+	// The service root answers with documentation, not events, so a
+	// lookup there would fail quietly and leave every bulletin unmatched.
+	var paths []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"FeatureCollection","features":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	a := NewTsunamiAdapter(srv.Client())
+	if want := usgsBaseURL; a.usgsURL != want {
+		t.Fatalf("usgsURL = %q, want the FDSN service %q", a.usgsURL, want)
+	}
+	a.usgsURL = srv.URL + "/fdsnws/event/1"
+	q := tsunamiQuake{origin: time.Date(2026, 8, 14, 6, 5, 27, 0, time.UTC), lat: 39.91, lon: -125.42, located: true}
+	if _, err := a.matchUSGS(context.Background(), q); err != nil {
+		t.Fatalf("matchUSGS: %v", err)
+	}
+	if !slices.Equal(paths, []string{"/fdsnws/event/1/query"}) {
+		t.Errorf("requested %v, want [/fdsnws/event/1/query]", paths)
+	}
+}
+
+func TestTsunamiFetchEvents(t *testing.T) {
+	t.Parallel()
+	a := newTestTsunami(t, serveTsunami(t, nil))
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3 (bulletins grouped by earthquake); IDs: %v", len(events), eventIDs(events))
+	}
+
+	t.Run("warning from its latest bulletin and CAP", func(t *testing.T) {
+		e := eventByID(t, events, "tsunami-paaq-sny9jb")
+		if e.Title != "Tsunami Warning - 85 miles SW of Cape Mendocino, California" {
+			t.Errorf("Title = %q", e.Title)
+		}
+		if e.EventType != "tsunami" || e.Source != "tsunami" || e.Severity != "extreme" {
+			t.Errorf("EventType, Source, Severity = %q, %q, %q", e.EventType, e.Source, e.Severity)
+		}
+		if e.Magnitude == nil || *e.Magnitude != 7.2 {
+			t.Errorf("Magnitude = %v, want the updated 7.2", e.Magnitude)
+		}
+		if !slices.Equal(e.Geometry.Coordinates, []float64{-125.42, 39.91}) {
+			t.Errorf("Coordinates = %v, want the CAP epicenter [-125.42 39.91]", e.Geometry.Coordinates)
+		}
+		if want := time.Date(2026, 8, 14, 6, 5, 27, 0, time.UTC); !e.StartedAt.Equal(want) {
+			t.Errorf("StartedAt = %v, want origin time %v", e.StartedAt, want)
+		}
+		if want := time.Date(2026, 8, 14, 7, 2, 10, 0, time.UTC); !e.UpdatedAt.Equal(want) {
+			t.Errorf("UpdatedAt = %v, want %v", e.UpdatedAt, want)
+		}
+		if !strings.HasSuffix(e.URL, "/sny9jb/2/WEAK51/WEAK51.txt") {
+			t.Errorf("URL = %q, want the latest bulletin", e.URL)
+		}
+		m := e.Metadata
+		if m["center"] != "NTWC" || m["message_type"] != "warning" || m["bulletin_number"] != 2 || m["bulletins"] != 2 {
+			t.Errorf("center, message_type, bulletin_number, bulletins = %v, %v, %v, %v",
+				m["center"], m["message_type"], m["bulletin_number"], m["bulletins"])
+		}
+		wantCoasts := []string{
+			"Davenport, California to Cape Mendocino, California",
+			"Cape Mendocino, California to Florence, Oregon",
+		}
+		if got, _ := m["coasts"].([]string); !slices.Equal(got, wantCoasts) {
+			t.Errorf("coasts = %v, want %v", m["coasts"], wantCoasts)
+		}
+		if m["quake_magnitude_type"] != "Mww" || m["quake_depth_km"] != 10.0 || m["quake_origin_time"] != "2026-08-14T06:05:27Z" {
+			t.Errorf("quake metadata = %v, %v, %v", m["quake_magnitude_type"], m["quake_depth_km"], m["quake_origin_time"])
+		}
+		if m["expires"] != "2026-08-14T13:02:10Z" {
+			t.Errorf("expires = %v", m["expires"])
+		}
+		if m["usgs_id"] != "us7000q1ab" || m["usgs_event_id"] != "usgs-us7000q1ab" {
+			t.Errorf("usgs_id, usgs_event_id = %v, %v; want the M7.1, not the closer-in-time M4.1", m["usgs_id"], m["usgs_event_id"])
+		}
+		if e.Closed() {
+			t.Errorf("EndedAt = %v, want open before its expiry", e.EndedAt)
+		}
+	})
+
+	t.Run("information statement without its CAP", func(t *testing.T) {
+		e := eventByID(t, events, "tsunami-paaq-sn2k4q")
+		if e.Title != "Tsunami Information Statement - Rat Islands, Aleutian Islands" || e.Severity != "minor" {
+			t.Errorf("Title, Severity = %q, %q", e.Title, e.Severity)
+		}
+		if e.Description != "There is no tsunami danger from this earthquake." {
+			t.Errorf("Description = %q", e.Description)
+		}
+		if !slices.Equal(e.Geometry.Coordinates, []float64{178.1, 51.62}) {
+			t.Errorf("Coordinates = %v, want the Atom position", e.Geometry.Coordinates)
+		}
+		if e.Metadata["quake_magnitude_type"] != "Mb" {
+			t.Errorf("quake_magnitude_type = %v, want Mb from the summary", e.Metadata["quake_magnitude_type"])
+		}
+		if _, ok := e.Metadata["usgs_id"]; ok {
+			t.Error("usgs_id set without an origin time to match on")
+		}
+	})
+
+	t.Run("escaped HTML summary", func(t *testing.T) {
+		e := eventByID(t, events, "tsunami-pheb-smx7pd")
+		if e.Title != "Tsunami Threat Message - Near East Coast of Honshu, Japan" || e.Severity != "severe" {
+			t.Errorf("Title, Severity = %q, %q", e.Title, e.Severity)
+		}
+		if e.Magnitude == nil || *e.Magnitude != 7.4 || e.Metadata["center"] != "PTWC" {
+			t.Errorf("Magnitude, center = %v, %v", e.Magnitude, e.Metadata["center"])
+		}
+	})
+}
+
+func TestTsunamiWarningEndsAtExpiry(t *testing.T) {
+	t.Parallel()
+	a := newTestTsunami(t, serveTsunami(t, nil))
+	a.now = func() time.Time { return time.Date(2026, 8, 14, 14, 0, 0, 0, time.UTC) }
+
+	events, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	e := eventByID(t, events, "tsunami-paaq-sny9jb")
+	if want := time.Date(2026, 8, 14, 13, 2, 10, 0, time.UTC); !e.EndedAt.Equal(want) {
+		t.Errorf("EndedAt = %v, want the CAP expiry %v", e.EndedAt, want)
+	}
+}
+
+func TestTsunamiEventEnds(t *testing.T) {
+	t.Parallel()
+	issued := time.Date(2026, 8, 14, 6, 20, 0, 0, time.UTC)
+	expires := issued.Add(6 * time.Hour)
+	warning := tsunamiBulletin{key: "paaq-sny9jb", messageType: "warning", issued: issued, expires: expires}
+	cases := []struct {
+		name   string
+		latest tsunamiBulletin
+		now    time.Time
+		want   time.Time
+	}{
+		{"warning in force", warning, issued.Add(time.Hour), time.Time{}},
+		{"warning past its expiry", warning, expires.Add(time.Minute), expires},
+		{"warning without an expiry", tsunamiBulletin{key: "paaq-sny9jb", messageType: "warning", issued: issued}, expires.Add(time.Hour), time.Time{}},
+		{
+			"cancellation",
+			tsunamiBulletin{key: "paaq-sny9jb", messageType: "cancellation", issued: issued.Add(2 * time.Hour), expires: expires},
+			issued.Add(3 * time.Hour),
+			issued.Add(2 * time.Hour),
+		},
+	}
+	for _, tc := range cases {
+		e := tsunamiEvent(warning, tc.latest, 2, tc.now)
+		if !e.EndedAt.Equal(tc.want) {
+			t.Errorf("%s: EndedAt = %v, want %v", tc.name, e.EndedAt, tc.want)
+		}
+	}
+}
+
+func TestTsunamiUSGSMatchCached(t *testing.T) {
+	t.Parallel()
+	var queries atomic.Int32
+	a := newTestTsunami(t, serveTsunami(t, &queries))
+
+	for range 2 {
+		events, err := a.FetchEvents(context.Background(), FetchParams{})
+		if err != nil {
+			t.Fatalf("FetchEvents: %v", err)
+		}
+		if e := eventByID(t, events, "tsunami-paaq-sny9jb"); e.Metadata["usgs_id"] != "us7000q1ab" {
+			t.Errorf("usgs_id = %v", e.Metadata["usgs_id"])
+		}
+	}
+	if got := queries.Load(); got != 1 {
+		t.Errorf("USGS queried %d times, want once", got)
+	}
+}
+
+func TestTsunamiFeedFailureFailsFetch(t *testing.T) {
+	t.Parallel()
+	srv := serveTsunami(t, nil)
+	a := newTestTsunami(t, srv)
+	a.feeds[1].url = srv.URL + "/missing.xml"
+
+	_, err := a.FetchEvents(context.Background(), FetchParams{})
+	if err == nil || !strings.Contains(err.Error(), "PTWC feed") || !strings.Contains(err.Error(), "unexpected status 404") {
+		t.Errorf("error = %v, want the PTWC feed's 404", err)
+	}
+}
+
+func TestTsunamiMessageType(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		category, want string
+	}{
+		{"Warning", "warning"},
+		{"Advisory", "advisory"},
+		{"Watch", "watch"},
+		{"Threat", "threat"},
+		{"Information", "information_statement"},
+		{"Tsunami Information Statement", "information_statement"},
+		{"Warning Cancellation", "cancellation"},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		if got := tsunamiMessageType(tc.category); got != tc.want {
+			t.Errorf("tsunamiMessageType(%q) = %q, want %q", tc.category, got, tc.want)
+		}
+	}
+}
+
+func TestParseTsunamiDepthKm(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"10 kilometers", 10, true},
+		{"33", 33, true},
+		{"6 miles", 9.7, true},
+		{"", 0, false},
+		{"unknown", 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseTsunamiDepthKm(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseTsunamiDepthKm(%q) = %v, %v; want %v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
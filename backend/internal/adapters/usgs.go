@@ -4,21 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
 )
 
-const usgsBaseURL = "https://earthquake.usgs.gov/fdsnws/event/1/query"
+// usgsBaseURL is the FDSN event service; queries go to /query and result
+// counts to /count.
+const usgsBaseURL = "https://earthquake.usgs.gov/fdsnws/event/1"
+
+// usgsDefaultWindow is how far back a fetch without Since reaches.
+const usgsDefaultWindow = 7 * 24 * time.Hour
+
+const (
+	// usgsMaxResults is the FDSN service's cap on one query's results,
+	// used when /count doesn't report its own maxAllowed. Past it a query
+	// fails instead of returning a partial catalog.
+	usgsMaxResults = 20000
+	// usgsSliceFill is the share of the cap a slice is planned to fill,
+	// leaving room for events reported between the count and the query.
+	usgsSliceFill = 0.8
+	// usgsMinSlice bounds the splitting: a window this short still over
+	// the cap is an error, not a reason to split forever.
+	usgsMinSlice = time.Hour
+	// usgsFetchConcurrency bounds the slices queried at once.
+	usgsFetchConcurrency = 4
+)
 
 type USGSAdapter struct {
 	client  *http.Client
 	baseURL string
+	now     func() time.Time
 }
 
 func NewUSGSAdapter(client *http.Client) *USGSAdapter {
-	return &USGSAdapter{client: client, baseURL: usgsBaseURL}
+	return &USGSAdapter{client: client, baseURL: usgsBaseURL, now: time.Now}
 }
 
 func (a *USGSAdapter) Source() string {
@@ -29,44 +56,173 @@ func (a *USGSAdapter) SupportedTypes() []string {
 	return []string{"earthquake"}
 }
 
+func (a *USGSAdapter) Window() time.Duration {
+	return usgsDefaultWindow
+}
+
+// FiltersMinMagnitude reports that MinMagnitude is sent upstream as
+// minmagnitude, so a magnitude floor shrinks a historical fetch.
+func (a *USGSAdapter) FiltersMinMagnitude() {}
+
+// usgsSlice is a time window of the catalog. A zero end leaves it open, so
+// the newest slice also takes events reported while the others load.
+type usgsSlice struct {
+	start, end time.Time
+}
+
+// FetchEvents queries the catalog from Since, or the default window, on.
+// The default window is far below the FDSN cap and is fetched in one query;
+// a longer one is counted first and, if it holds more events than one
+// query may return, split into slices queried concurrently and merged.
 func (a *USGSAdapter) FetchEvents(ctx context.Context, params FetchParams) ([]models.Event, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("usgs: build request: %w", err)
+	now := a.now()
+	whole := usgsSlice{start: now.Add(-usgsDefaultWindow)}
+	if !params.Since.IsZero() {
+		whole.start = params.Since
+	}
+	plan := []usgsSlice{whole}
+	if now.Sub(whole.start) > usgsDefaultWindow {
+		var err error
+		if plan, err = a.planSlices(ctx, whole, now, params.MinMagnitude); err != nil {
+			return nil, err
+		}
 	}
 
-	q := req.URL.Query()
-	q.Set("format", "geojson")
+	results := make([][]usgsFeature, len(plan))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(usgsFetchConcurrency)
+	for i, sl := range plan {
+		g.Go(func() error {
+			var result usgsResponse
+			if err := a.getJSON(gctx, "/query", sl, params.MinMagnitude, &result); err != nil {
+				return err
+			}
+			results[i] = result.Features
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-	if !params.Since.IsZero() {
-		q.Set("starttime", params.Since.Format(time.RFC3339))
-	} else {
-		q.Set("starttime", time.Now().AddDate(0, 0, -7).Format(time.RFC3339))
+	// Slices are planned newest first, like each query's results, so the
+	// merge keeps USGS's order. An event on a boundary is in both slices.
+	seen := make(map[string]bool)
+	var events []models.Event
+	for _, features := range results {
+		for _, f := range features {
+			if seen[f.ID] {
+				continue
+			}
+			seen[f.ID] = true
+			events = append(events, parseUSGSFeature(f))
+		}
 	}
+	if events == nil {
+		events = []models.Event{}
+	}
+	return events, nil
+}
 
-	req.URL.RawQuery = q.Encode()
+// planSlices counts the events in sl and returns it whole if one query can
+// return them all, or else split into slices, newest first, each counted in
+// turn since earthquakes cluster: an aftershock sequence can fill one day
+// of a quiet month.
+func (a *USGSAdapter) planSlices(ctx context.Context, sl usgsSlice, now time.Time, minMag *float64) ([]usgsSlice, error) {
+	var count usgsCount
+	if err := a.getJSON(ctx, "/count", sl, minMag, &count); err != nil {
+		return nil, err
+	}
+	limit := usgsMaxResults
+	if count.MaxAllowed > 0 {
+		limit = count.MaxAllowed
+	}
+	if count.Count <= limit {
+		return []usgsSlice{sl}, nil
+	}
 
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("usgs: request failed: %w", err)
+	end := sl.end
+	if end.IsZero() {
+		end = now
 	}
-	defer resp.Body.Close()
+	span := end.Sub(sl.start)
+	if span <= usgsMinSlice {
+		return nil, fmt.Errorf("usgs: %d events between %s and %s, more than the %d one query returns",
+			count.Count, sl.start.Format(time.RFC3339), end.Format(time.RFC3339), limit)
+	}
+	parts := int(math.Ceil(float64(count.Count) / (float64(limit) * usgsSliceFill)))
+	step := max(span/time.Duration(parts), usgsMinSlice)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("usgs: unexpected status %d", resp.StatusCode)
+	var out []usgsSlice
+	for hi := sl.end; ; {
+		lo := end.Add(-step)
+		if !lo.After(sl.start) {
+			lo = sl.start
+		}
+		sub, err := a.planSlices(ctx, usgsSlice{start: lo, end: hi}, now, minMag)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub...)
+		if lo.Equal(sl.start) {
+			return out, nil
+		}
+		hi, end = lo, lo
+	}
+}
+
+// getJSON requests path (/query or /count) for the slice and decodes the
+// GeoJSON response into v.
+func (a *USGSAdapter) getJSON(ctx context.Context, path string, sl usgsSlice, minMag *float64, v any) error {
+	q := url.Values{}
+	q.Set("starttime", sl.start.UTC().Format(time.RFC3339))
+	if !sl.end.IsZero() {
+		q.Set("endtime", sl.end.UTC().Format(time.RFC3339))
+	}
+	if minMag != nil {
+		q.Set("minmagnitude", strconv.FormatFloat(*minMag, 'f', -1, 64))
 	}
+	if err := usgsGet(ctx, a.client, a.baseURL+path, q, v); err != nil {
+		return fmt.Errorf("usgs: %w", err)
+	}
+	return nil
+}
 
-	var result usgsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("usgs: decode response: %w", err)
+// usgsQuery searches the FDSN event service at baseURL, such as
+// usgsBaseURL, with the given parameters and decodes the GeoJSON response
+// into v. Callers outside this adapter use it rather than building the
+// endpoint themselves.
+func usgsQuery(ctx context.Context, client *http.Client, baseURL string, params url.Values, v any) error {
+	return usgsGet(ctx, client, baseURL+"/query", params, v)
+}
+
+// usgsGet requests endpoint in GeoJSON with params and decodes the
+// response into v.
+func usgsGet(ctx context.Context, client *http.Client, endpoint string, params url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
 	}
+	q := req.URL.Query()
+	for k, vs := range params {
+		q[k] = vs
+	}
+	q.Set("format", "geojson")
+	req.URL.RawQuery = q.Encode()
 
-	events := make([]models.Event, 0, len(result.Features))
-	for _, f := range result.Features {
-		events = append(events, parseUSGSFeature(f))
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	return events, nil
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
 }
 
 func parseUSGSFeature(f usgsFeature) models.Event {
@@ -102,23 +258,69 @@ func parseUSGSFeature(f usgsFeature) models.Event {
 		StartedAt: startedAt,
 		UpdatedAt: updatedAt,
 		URL:       f.Properties.URL,
-		Metadata: map[string]any{
-			"place": f.Properties.Place,
-			"depth": depthFromCoords(f.Geometry.Coordinates),
-		},
+		Metadata:  usgsMetadata(f),
 	}
 }
 
+// usgsMetadata structures the feed's properties. Optional values are set
+// only when USGS reports them: felt, cdi and mmi appear once there are
+// felt reports or a ShakeMap, and nst, gap and rms are missing for many
+// solutions.
+func usgsMetadata(f usgsFeature) map[string]any {
+	p := f.Properties
+	metadata := map[string]any{
+		"place":                 p.Place,
+		"depth":                 depthFromCoords(f.Geometry.Coordinates),
+		"sig":                   p.Sig,
+		models.MetaTsunami:      p.Tsunami == 1,
+		models.MetaReviewStatus: strings.ToLower(p.Status),
+	}
+	if p.MagType != "" {
+		metadata["magtype"] = strings.ToLower(p.MagType)
+	}
+	if p.Net != "" {
+		metadata["net"] = p.Net
+	}
+	if types := splitUSGSList(p.Types); len(types) > 0 {
+		metadata["product_types"] = types
+	}
+	for key, v := range map[string]*float64{
+		"felt": p.Felt,
+		"cdi":  p.CDI,
+		"mmi":  p.MMI,
+		"nst":  p.NST,
+		"gap":  p.Gap,
+		"rms":  p.RMS,
+	} {
+		if v != nil {
+			metadata[key] = *v
+		}
+	}
+	return metadata
+}
+
+// splitUSGSList splits USGS's comma-delimited lists, which are written
+// with a leading and trailing comma: ",origin,phase-data,".
+func splitUSGSList(s string) []string {
+	var out []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func usgsAlertToSeverity(alert string) string {
 	switch alert {
 	case "red":
-		return "extreme"
+		return models.SeverityExtreme
 	case "orange":
-		return "severe"
+		return models.SeveritySevere
 	case "yellow":
-		return "moderate"
+		return models.SeverityModerate
 	case "green":
-		return "minor"
+		return models.SeverityMinor
 	default:
 		return ""
 	}
@@ -133,6 +335,11 @@ func depthFromCoords(coords []float64) float64 {
 
 // USGS GeoJSON response types
 
+type usgsCount struct {
+	Count      int `json:"count"`
+	MaxAllowed int `json:"maxAllowed"`
+}
+
 type usgsResponse struct {
 	Features []usgsFeature `json:"features"`
 }
@@ -156,4 +363,16 @@ type usgsProperties struct {
 	URL     string   `json:"url"`
 	Title   string   `json:"title"`
 	Alert   string   `json:"alert"`
+	Tsunami int      `json:"tsunami"`
+	Sig     int      `json:"sig"`
+	Felt    *float64 `json:"felt"`
+	CDI     *float64 `json:"cdi"`
+	MMI     *float64 `json:"mmi"`
+	MagType string   `json:"magType"`
+	Net     string   `json:"net"`
+	Status  string   `json:"status"`
+	Types   string   `json:"types"`
+	NST     *float64 `json:"nst"`
+	Gap     *float64 `json:"gap"`
+	RMS     *float64 `json:"rms"`
 }
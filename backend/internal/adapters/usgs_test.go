@@ -2,11 +2,20 @@ package adapters
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
 )
 
 func newTestUSGS(t *testing.T, srv *httptest.Server) *USGSAdapter {
@@ -80,6 +89,42 @@ func TestUSGSFetchEventsParsing(t *testing.T) {
 		}
 	})
 
+	t.Run("structured metadata", func(t *testing.T) {
+		e := eventByID(t, events, "usgs-us7000red1")
+		for key, want := range map[string]any{
+			"tsunami":       true,
+			"review_status": "reviewed",
+			"sig":           987,
+			"felt":          1200.0,
+			"cdi":           6.1,
+			"mmi":           7.2,
+			"magtype":       "mww",
+			"net":           "ak",
+			"nst":           112.0,
+			"gap":           24.0,
+			"rms":           0.82,
+		} {
+			if got := e.Metadata[key]; got != want {
+				t.Errorf("Metadata[%s] = %v (%T), want %v", key, got, got, want)
+			}
+		}
+		want := []string{"dyfi", "losspager", "origin", "phase-data", "shakemap"}
+		if got, _ := e.Metadata["product_types"].([]string); !slices.Equal(got, want) {
+			t.Errorf("Metadata[product_types] = %v, want %v", e.Metadata["product_types"], want)
+		}
+
+		// Unreported optional values are left out rather than zero.
+		auto := eventByID(t, events, "usgs-nc75001234")
+		if auto.Metadata["review_status"] != "automatic" || auto.Metadata["tsunami"] != false {
+			t.Errorf("automatic solution metadata = %v", auto.Metadata)
+		}
+		for _, key := range []string{"felt", "cdi", "mmi", "nst", "gap", "rms", "magtype", "product_types"} {
+			if v, ok := auto.Metadata[key]; ok {
+				t.Errorf("Metadata[%s] = %v, want absent", key, v)
+			}
+		}
+	})
+
 	t.Run("null magnitude stays nil", func(t *testing.T) {
 		e := eventByID(t, events, "usgs-nc75001234")
 		if e.Magnitude != nil {
@@ -211,3 +256,140 @@ func TestUSGSMalformedJSON(t *testing.T) {
 		t.Errorf("error = %q, want decode error", err)
 	}
 }
+
+// usgsCatalog serves /count and /query over a synthetic catalog like the
+// FDSN service: newest first, and a 400 for a query over maxAllowed.
+type usgsCatalog struct {
+	times      []time.Time // newest first
+	mags       []float64
+	maxAllowed int
+
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+func serveUSGSCatalog(t *testing.T, c *usgsCatalog) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		start, _ := time.Parse(time.RFC3339, q.Get("starttime"))
+		end := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+		if v := q.Get("endtime"); v != "" {
+			end, _ = time.Parse(time.RFC3339, v)
+		}
+		minMag := math.Inf(-1)
+		if v := q.Get("minmagnitude"); v != "" {
+			minMag, _ = strconv.ParseFloat(v, 64)
+		}
+		var features []map[string]any
+		for i, at := range c.times {
+			if at.Before(start) || at.After(end) || c.mags[i] < minMag {
+				continue
+			}
+			features = append(features, map[string]any{
+				"id":         fmt.Sprintf("q%d", i),
+				"geometry":   map[string]any{"type": "Point", "coordinates": []float64{0, 0, 10}},
+				"properties": map[string]any{"mag": c.mags[i], "time": at.UnixMilli(), "updated": at.UnixMilli()},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/count":
+			json.NewEncoder(w).Encode(map[string]int{"count": len(features), "maxAllowed": c.maxAllowed})
+		case "/query":
+			c.mu.Lock()
+			c.queries = append(c.queries, q)
+			c.mu.Unlock()
+			if len(features) > c.maxAllowed {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"features": features})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUSGSFetchEventsSlicesLargeWindows(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	// 90 days, one quake every 6 hours, plus a burst of 60 aftershocks on
+	// day 10 that overfills the slice it lands in.
+	c := &usgsCatalog{maxAllowed: 100}
+	for h := 0; h < 90*24; h += 6 {
+		c.times = append(c.times, now.Add(-time.Duration(h)*time.Hour))
+		c.mags = append(c.mags, float64(h%7))
+	}
+	for i := range 60 {
+		c.times = append(c.times, now.Add(-10*24*time.Hour-time.Duration(i)*time.Minute-time.Second))
+		c.mags = append(c.mags, 3)
+	}
+	slices.SortStableFunc(c.times, func(x, y time.Time) int { return y.Compare(x) })
+
+	a := NewUSGSAdapter(nil)
+	srv := serveUSGSCatalog(t, c)
+	a.client, a.baseURL = srv.Client(), srv.URL
+	a.now = func() time.Time { return now }
+
+	since := now.Add(-90 * 24 * time.Hour).Add(time.Second)
+	events, err := a.FetchEvents(context.Background(), FetchParams{Since: since})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(events) != len(c.times) {
+		t.Errorf("got %d events, want all %d exactly once", len(events), len(c.times))
+	}
+	if !slices.IsSortedFunc(events, func(x, y models.Event) int { return y.StartedAt.Compare(x.StartedAt) }) {
+		t.Error("events not newest first")
+	}
+	if len(c.queries) < 4 {
+		t.Errorf("%d queries, want the %d events split into several", len(c.queries), len(c.times))
+	}
+	open := 0
+	for _, q := range c.queries {
+		if q.Get("endtime") == "" {
+			open++
+		}
+	}
+	if open != 1 {
+		t.Errorf("%d open-ended slices, want only the newest", open)
+	}
+
+	// A magnitude floor goes upstream and shrinks the window to one query.
+	c.queries = nil
+	floor := 6.0
+	events, err = a.FetchEvents(context.Background(), FetchParams{Since: since, MinMagnitude: &floor})
+	if err != nil {
+		t.Fatalf("FetchEvents: %v", err)
+	}
+	if len(c.queries) != 1 || c.queries[0].Get("minmagnitude") != "6" {
+		t.Errorf("queries = %v, want one with minmagnitude=6", c.queries)
+	}
+	for _, e := range events {
+		if *e.Magnitude < floor {
+			t.Fatalf("event %s of magnitude %v under the floor", e.ID, *e.Magnitude)
+		}
+	}
+}
+
+func TestUSGSFetchEventsTooDenseToSlice(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC)
+	c := &usgsCatalog{maxAllowed: 10}
+	for i := range 20 {
+		c.times = append(c.times, now.Add(-20*24*time.Hour-time.Duration(i)*time.Second))
+		c.mags = append(c.mags, 1)
+	}
+	a := NewUSGSAdapter(nil)
+	srv := serveUSGSCatalog(t, c)
+	a.client, a.baseURL = srv.Client(), srv.URL
+	a.now = func() time.Time { return now }
+
+	_, err := a.FetchEvents(context.Background(), FetchParams{Since: now.Add(-30 * 24 * time.Hour)})
+	if err == nil || !strings.Contains(err.Error(), "more than the 10 one query returns") {
+		t.Errorf("err = %v", err)
+	}
+}
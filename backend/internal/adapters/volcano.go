@@ -0,0 +1,62 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// Volcano alerting uses two parallel scales. The ground-based alert level
+// speaks to people on the ground; the aviation color code to aircraft. Both
+// are four steps, and observatories outside the US use their own numbered
+// levels, so each scale is mapped onto Severity and the more severe of the
+// two wins.
+
+var volcanoAlertLevelSeverity = map[string]string{
+	"NORMAL":   models.SeverityMinor,
+	"ADVISORY": models.SeverityModerate,
+	"WATCH":    models.SeveritySevere,
+	"WARNING":  models.SeverityExtreme,
+}
+
+var volcanoColorCodeSeverity = map[string]string{
+	"GREEN":  models.SeverityMinor,
+	"YELLOW": models.SeverityModerate,
+	"ORANGE": models.SeveritySevere,
+	"RED":    models.SeverityExtreme,
+}
+
+// volcanoSeverity maps a USGS alert level and aviation color code, either
+// possibly empty, onto Severity.
+func volcanoSeverity(alertLevel, colorCode string) string {
+	return moreSevere(
+		volcanoAlertLevelSeverity[strings.ToUpper(strings.TrimSpace(alertLevel))],
+		volcanoColorCodeSeverity[strings.ToUpper(strings.TrimSpace(colorCode))],
+	)
+}
+
+// volcanoRankSeverity maps a numbered alert level onto Severity by its
+// place on its own scale, so level 3 of 4 (Indonesia's Siaga) and level 4
+// of 5 (Japan's evacuation-prepare) both rank severe.
+func volcanoRankSeverity(rank, scale int) string {
+	if rank < 1 || scale < 2 || rank > scale {
+		return ""
+	}
+	switch f := float64(rank) / float64(scale); {
+	case f >= 1:
+		return models.SeverityExtreme
+	case f >= 0.75:
+		return models.SeveritySevere
+	case f >= 0.5:
+		return models.SeverityModerate
+	default:
+		return models.SeverityMinor
+	}
+}
+
+func moreSevere(a, b string) string {
+	if models.SeverityRank(b) > models.SeverityRank(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,50 @@
+package adapters
+
+import "testing"
+
+func TestVolcanoSeverity(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		level, color, want string
+	}{
+		{"NORMAL", "GREEN", "minor"},
+		{"ADVISORY", "YELLOW", "moderate"},
+		{"WATCH", "ORANGE", "severe"},
+		{"WARNING", "RED", "extreme"},
+		{"ADVISORY", "RED", "extreme"},
+		{"watch", "", "severe"},
+		{"", "yellow", "moderate"},
+		{"", "", ""},
+		{"UNASSIGNED", "UNASSIGNED", ""},
+	}
+	for _, tc := range cases {
+		if got := volcanoSeverity(tc.level, tc.color); got != tc.want {
+			t.Errorf("volcanoSeverity(%q, %q) = %q, want %q", tc.level, tc.color, got, tc.want)
+		}
+	}
+}
+
+func TestVolcanoRankSeverity(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		rank, scale int
+		want        string
+	}{
+		{1, 4, "minor"},
+		{2, 4, "moderate"},
+		{3, 4, "severe"},
+		{4, 4, "extreme"},
+		{2, 5, "minor"},
+		{3, 5, "moderate"},
+		{4, 5, "severe"},
+		{5, 5, "extreme"},
+		{0, 4, ""},
+		{5, 4, ""},
+		{1, 1, ""},
+	}
+	for _, tc := range cases {
+		if got := volcanoRankSeverity(tc.rank, tc.scale); got != tc.want {
+			t.Errorf("volcanoRankSeverity(%d, %d) = %q, want %q", tc.rank, tc.scale, got, tc.want)
+		}
+	}
+}
@@ -1,46 +1,81 @@
 package cache
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Freshness reports how a value returned by GetOrLoad relates to the
+// cache's TTLs. The zero value means no value was returned.
+type Freshness int
+
+const (
+	// Fresh values are younger than the soft TTL.
+	Fresh Freshness = iota + 1
+	// Stale values are past the soft TTL but within the hard TTL. They are
+	// still served, while a background refresh replaces them.
+	Stale
 )
 
 type entry[V any] struct {
-	value     V
-	expiresAt time.Time
+	value      V
+	staleAt    time.Time
+	expiresAt  time.Time
+	refreshing bool
 }
 
 type Cache[V any] struct {
 	mu        sync.RWMutex
 	items     map[string]entry[V]
-	ttl       time.Duration
+	softTTL   time.Duration
+	hardTTL   time.Duration
+	loads     singleflight.Group
+	locked    map[string]struct{} // by TryLock, guarded by mu
 	closeCh   chan struct{}
 	closeOnce sync.Once
 }
 
-// New creates a cache whose entries expire after ttl. The ttl must be
-// positive: it drives the janitor ticker, which panics opaquely deep in
-// time.NewTicker otherwise.
+// New creates a cache whose entries expire after ttl, with no stale
+// window. The ttl must be positive: it drives the janitor ticker, which
+// panics opaquely deep in time.NewTicker otherwise.
 func New[V any](ttl time.Duration) *Cache[V] {
-	if ttl <= 0 {
-		panic(fmt.Sprintf("cache: ttl must be positive, got %v", ttl))
+	return NewWithStale[V](ttl, ttl)
+}
+
+// NewWithStale creates a cache whose entries are fresh for softTTL and are
+// then served stale, while being refreshed, until hardTTL. hardTTL must not
+// be shorter than softTTL.
+func NewWithStale[V any](softTTL, hardTTL time.Duration) *Cache[V] {
+	if softTTL <= 0 {
+		panic(fmt.Sprintf("cache: ttl must be positive, got %v", softTTL))
+	}
+	if hardTTL < softTTL {
+		panic(fmt.Sprintf("cache: hard ttl %v is shorter than soft ttl %v", hardTTL, softTTL))
 	}
 	c := &Cache[V]{
 		items:   make(map[string]entry[V]),
-		ttl:     ttl,
+		locked:  make(map[string]struct{}),
+		softTTL: softTTL,
+		hardTTL: hardTTL,
 		closeCh: make(chan struct{}),
 	}
 	go c.cleanup()
 	return c
 }
 
+// Get returns the value for key while it is fresh. Stale values are only
+// handed out by GetOrLoad, which takes responsibility for refreshing them.
 func (c *Cache[V]) Get(key string) (V, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	e, ok := c.items[key]
-	if !ok || time.Now().After(e.expiresAt) {
+	if !ok || !time.Now().Before(e.staleAt) {
 		var zero V
 		return zero, false
 	}
@@ -51,10 +86,98 @@ func (c *Cache[V]) Set(key string, value V) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	now := time.Now()
 	c.items[key] = entry[V]{
 		value:     value,
-		expiresAt: time.Now().Add(c.ttl),
+		staleAt:   now.Add(c.softTTL),
+		expiresAt: now.Add(c.hardTTL),
+	}
+}
+
+// GetOrLoad returns the value for key, calling load when there is nothing
+// usable. A fresh value is returned as is. A stale value is returned
+// immediately and, unless a refresh is already running, one background
+// call to load replaces it; if that call fails the stale value stays until
+// the hard TTL. Without a value, load runs synchronously and concurrent
+// callers for the same key share a single call; a caller whose ctx ends
+// first stops waiting for it and gets ctx's error.
+func (c *Cache[V]) GetOrLoad(ctx context.Context, key string, load func() (V, error)) (V, Freshness, error) {
+	c.mu.Lock()
+	e, ok := c.items[key]
+	now := time.Now()
+	switch {
+	case ok && now.Before(e.staleAt):
+		c.mu.Unlock()
+		return e.value, Fresh, nil
+	case ok && now.Before(e.expiresAt):
+		if !e.refreshing {
+			e.refreshing = true
+			c.items[key] = e
+			go c.refresh(key, load)
+		}
+		c.mu.Unlock()
+		return e.value, Stale, nil
+	}
+	c.mu.Unlock()
+
+	v, err := c.load(ctx, key, load)
+	if err != nil {
+		var zero V
+		return zero, 0, err
+	}
+	return v, Fresh, nil
+}
+
+// load runs fn through the singleflight group, so that a background
+// refresh and a synchronous load of the same key never overlap.
+func (c *Cache[V]) load(ctx context.Context, key string, fn func() (V, error)) (V, error) {
+	ch := c.loads.DoChan(key, func() (any, error) {
+		v, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		c.Set(key, v)
+		return v, nil
+	})
+	var zero V
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(V), nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[V]) refresh(key string, fn func() (V, error)) {
+	if _, err := c.load(context.Background(), key, fn); err != nil {
+		slog.Warn("cache: background refresh failed", "key", key, "error", err)
+		// Clear the marker so the next stale read retries.
+		c.mu.Lock()
+		if e, ok := c.items[key]; ok {
+			e.refreshing = false
+			c.items[key] = e
+		}
+		c.mu.Unlock()
+	}
+}
+
+// TryLock takes key's lock. A Cache is one process's, with no replica to
+// coordinate with, so the lock only excludes other TryLock holders.
+func (c *Cache[V]) TryLock(key string) (func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, held := c.locked[key]; held {
+		return nil, false
 	}
+	c.locked[key] = struct{}{}
+	return func() {
+		c.mu.Lock()
+		delete(c.locked, key)
+		c.mu.Unlock()
+	}, true
 }
 
 // Close stops the janitor goroutine. Safe to call more than once.
@@ -65,7 +188,7 @@ func (c *Cache[V]) Close() {
 }
 
 func (c *Cache[V]) cleanup() {
-	ticker := time.NewTicker(c.ttl / 2)
+	ticker := time.NewTicker(c.softTTL / 2)
 	defer ticker.Stop()
 
 	for {
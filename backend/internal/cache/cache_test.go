@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -133,3 +136,208 @@ func TestConcurrentGetSet(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestNewWithStalePanicsWhenHardTTLShorter(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Error("NewWithStale(time.Minute, time.Second) did not panic")
+		}
+	}()
+	NewWithStale[int](time.Minute, time.Second)
+}
+
+func TestGetOrLoadMissLoadsAndCaches(t *testing.T) {
+	t.Parallel()
+	c := New[int](time.Minute)
+	defer c.Close()
+
+	var calls atomic.Int32
+	load := func() (int, error) {
+		calls.Add(1)
+		return 42, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, fr, err := c.GetOrLoad(context.Background(), "k", load)
+		if err != nil {
+			t.Fatalf("GetOrLoad #%d: %v", i+1, err)
+		}
+		if v != 42 || fr != Fresh {
+			t.Errorf("GetOrLoad #%d = %v, %v; want 42, Fresh", i+1, v, fr)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times, want 1 (second call served from cache)", got)
+	}
+}
+
+func TestGetOrLoadMissError(t *testing.T) {
+	t.Parallel()
+	c := New[int](time.Minute)
+	defer c.Close()
+
+	boom := errors.New("boom")
+	v, fr, err := c.GetOrLoad(context.Background(), "k", func() (int, error) { return 0, boom })
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if v != 0 || fr != 0 {
+		t.Errorf("GetOrLoad = %v, %v; want zero values on error", v, fr)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Error("failed load was cached")
+	}
+}
+
+func TestGetOrLoadCallerStopsWaitingAtContextEnd(t *testing.T) {
+	t.Parallel()
+	c := New[int](time.Minute)
+	defer c.Close()
+
+	gate := make(chan struct{})
+	load := func() (int, error) {
+		<-gate
+		return 42, nil
+	}
+	done := make(chan int)
+	go func() {
+		v, _, _ := c.GetOrLoad(context.Background(), "k", load)
+		done <- v
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.GetOrLoad(ctx, "k", load); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the caller's deadline", err)
+	}
+	// The shared load runs on for the caller still waiting.
+	close(gate)
+	if v := <-done; v != 42 {
+		t.Errorf("other caller got %d, want 42", v)
+	}
+}
+
+func TestGetOrLoadServesStaleAndRefreshesOnce(t *testing.T) {
+	t.Parallel()
+	c := NewWithStale[int](20*time.Millisecond, time.Minute)
+	defer c.Close()
+
+	c.Set("k", 1)
+	time.Sleep(30 * time.Millisecond)
+
+	gate := make(chan struct{})
+	var calls atomic.Int32
+	load := func() (int, error) {
+		calls.Add(1)
+		<-gate
+		return 2, nil
+	}
+
+	// Every reader during the refresh gets the stale value without waiting.
+	for i := 0; i < 5; i++ {
+		v, fr, err := c.GetOrLoad(context.Background(), "k", load)
+		if err != nil {
+			t.Fatalf("GetOrLoad: %v", err)
+		}
+		if v != 1 || fr != Stale {
+			t.Fatalf("GetOrLoad = %v, %v; want 1, Stale", v, fr)
+		}
+	}
+	close(gate)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if v, ok := c.Get("k"); ok && v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh never replaced the stale value")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times, want exactly one background refresh", got)
+	}
+}
+
+func TestGetOrLoadFailedRefreshKeepsStaleAndRetries(t *testing.T) {
+	t.Parallel()
+	c := NewWithStale[int](20*time.Millisecond, time.Minute)
+	defer c.Close()
+
+	c.Set("k", 1)
+	time.Sleep(30 * time.Millisecond)
+
+	var calls atomic.Int32
+	failing := func() (int, error) {
+		calls.Add(1)
+		return 0, errors.New("upstream down")
+	}
+	if v, fr, _ := c.GetOrLoad(context.Background(), "k", failing); v != 1 || fr != Stale {
+		t.Fatalf("GetOrLoad = %v, %v; want 1, Stale", v, fr)
+	}
+
+	// Once the failed refresh has cleared its marker, the next stale read
+	// starts another one — and still gets the stale value meanwhile.
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("failed refresh was never retried")
+		}
+		if v, fr, _ := c.GetOrLoad(context.Background(), "k", failing); v != 1 || fr != Stale {
+			t.Fatalf("GetOrLoad = %v, %v; want 1, Stale", v, fr)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGetOrLoadPastHardTTLLoadsSynchronously(t *testing.T) {
+	t.Parallel()
+	c := NewWithStale[int](10*time.Millisecond, 20*time.Millisecond)
+	defer c.Close()
+
+	c.Set("k", 1)
+	time.Sleep(40 * time.Millisecond)
+
+	v, fr, err := c.GetOrLoad(context.Background(), "k", func() (int, error) { return 2, nil })
+	if err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if v != 2 || fr != Fresh {
+		t.Errorf("GetOrLoad = %v, %v; want 2, Fresh (nothing stale left to serve)", v, fr)
+	}
+}
+
+func TestGetIgnoresStaleEntries(t *testing.T) {
+	t.Parallel()
+	c := NewWithStale[int](20*time.Millisecond, time.Minute)
+	defer c.Close()
+
+	c.Set("k", 1)
+	time.Sleep(30 * time.Millisecond)
+	if v, ok := c.Get("k"); ok {
+		t.Errorf("Get on stale entry returned ok with %v, want only fresh values", v)
+	}
+}
+
+func TestCacheTryLockExcludesOtherHolders(t *testing.T) {
+	t.Parallel()
+	c := New[int](time.Minute)
+	t.Cleanup(c.Close)
+
+	unlock, ok := c.TryLock("k")
+	if !ok {
+		t.Fatal("TryLock on a free key failed")
+	}
+	if _, ok := c.TryLock("k"); ok {
+		t.Error("TryLock succeeded while the key was held")
+	}
+	if _, ok := c.TryLock("other"); !ok {
+		t.Error("TryLock on another key failed")
+	}
+	unlock()
+	if _, ok := c.TryLock("k"); !ok {
+		t.Error("TryLock failed after unlock")
+	}
+}
@@ -0,0 +1,369 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// redisOpTimeout bounds each individual Redis command. Loads are not
+	// covered: they run under the caller's own deadline.
+	redisOpTimeout = 2 * time.Second
+	// redisPollInterval is how often a replica that lost the fill lock
+	// checks whether the winner has stored its result.
+	redisPollInterval = 100 * time.Millisecond
+)
+
+// unlockScript deletes a lock only if it still holds our token, so a
+// holder whose lock already expired cannot release a successor's lock.
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// An entry is stored as a hash of two fields: the value as JSON, and the
+// time it was written, which travels with it because freshness is judged
+// against the soft TTL while Redis itself only enforces the hard TTL. The
+// write time doubles as the entry's version: a read fetches only it, and
+// decodes the value only when it differs from the one decoded last.
+const (
+	redisFieldStoredAt = "stored_at"
+	redisFieldValue    = "value"
+)
+
+// redisMemo is the value last decoded for a key, or last written from this
+// process, and the write time it was stored with.
+type redisMemo[V any] struct {
+	storedAt string
+	value    V
+}
+
+// Redis is a Store backed by a Redis-protocol server, so that replicas
+// share cached values as JSON. Filling a key is guarded by a lock in Redis:
+// on a miss one replica loads while the others wait for its result, and a
+// stale entry is refreshed by whichever replica takes the lock first.
+//
+// Values are large (a source's whole event set) and read on every request,
+// so each replica keeps the value it decoded last per key and reuses it
+// for as long as the entry's write time is unchanged.
+//
+// Redis failures never fail a read: they degrade to loading directly, as if
+// there were no cache.
+type Redis[V any] struct {
+	client  redis.UniversalClient
+	prefix  string
+	softTTL time.Duration
+	hardTTL time.Duration
+	lockTTL time.Duration
+
+	loads      singleflight.Group
+	mu         sync.Mutex
+	refreshing map[string]struct{}
+	memo       map[string]redisMemo[V]
+}
+
+// NewRedis creates a Redis-backed store. Keys are namespaced under prefix.
+// lockTTL bounds how long one replica may hold a key's fill lock, and so
+// how long the others wait for it; it should cover a full upstream fetch.
+func NewRedis[V any](client redis.UniversalClient, prefix string, softTTL, hardTTL, lockTTL time.Duration) *Redis[V] {
+	if softTTL <= 0 || lockTTL <= 0 {
+		panic(fmt.Sprintf("cache: ttls must be positive, got soft %v, lock %v", softTTL, lockTTL))
+	}
+	if hardTTL < softTTL {
+		panic(fmt.Sprintf("cache: hard ttl %v is shorter than soft ttl %v", hardTTL, softTTL))
+	}
+	return &Redis[V]{
+		client:     client,
+		prefix:     prefix,
+		softTTL:    softTTL,
+		hardTTL:    hardTTL,
+		lockTTL:    lockTTL,
+		refreshing: make(map[string]struct{}),
+		memo:       make(map[string]redisMemo[V]),
+	}
+}
+
+// GetOrLoad has the semantics of Cache.GetOrLoad, with the refresh and the
+// miss load coordinated across every replica sharing the server.
+func (r *Redis[V]) GetOrLoad(ctx context.Context, key string, load func() (V, error)) (V, Freshness, error) {
+	if v, storedAt, ok := r.get(key); ok {
+		if time.Since(storedAt) < r.softTTL {
+			return v, Fresh, nil
+		}
+		r.refreshInBackground(key, load)
+		return v, Stale, nil
+	}
+
+	// The fill is shared by this replica's callers, so it does not run
+	// under any one caller's ctx; each caller stops waiting on its own.
+	ch := r.loads.DoChan(key, func() (any, error) {
+		return r.fill(key, load)
+	})
+	var zero V
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, 0, res.Err
+		}
+		return res.Val.(V), Fresh, nil
+	case <-ctx.Done():
+		return zero, 0, ctx.Err()
+	}
+}
+
+// Set stores value under key. Failures are logged, not returned: a value
+// that could not be shared is only a missed optimization.
+func (r *Redis[V]) Set(key string, value V) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		slog.Warn("cache: encode value", "key", key, "error", err)
+		return
+	}
+	storedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	k := r.prefix + key
+	// Replaces whatever was there, including an entry in an older format.
+	_, err = r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, k)
+		p.HSet(ctx, k, redisFieldStoredAt, storedAt, redisFieldValue, data)
+		p.Expire(ctx, k, r.hardTTL)
+		return nil
+	})
+	if err != nil {
+		slog.Warn("cache: redis set failed", "key", key, "error", err)
+		return
+	}
+	r.mu.Lock()
+	r.memo[key] = redisMemo[V]{storedAt: storedAt, value: value}
+	r.mu.Unlock()
+}
+
+// fill loads a missing key, letting only the lock holder call load. The
+// others wait for its result and load themselves only if it never comes.
+func (r *Redis[V]) fill(key string, load func() (V, error)) (V, error) {
+	token, locked, err := r.lock(key)
+	if err != nil {
+		slog.Warn("cache: redis lock failed", "key", key, "error", err)
+		return load()
+	}
+	if locked {
+		defer r.unlock(key, token)
+		// Another replica may have filled the key between our miss and
+		// taking the lock.
+		if v, _, ok := r.get(key); ok {
+			return v, nil
+		}
+		v, err := load()
+		if err != nil {
+			return v, err
+		}
+		r.Set(key, v)
+		return v, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.lockTTL)
+	defer cancel()
+	if v, ok := r.waitFor(ctx, key); ok {
+		return v, nil
+	}
+	// The holder failed or outlived its lock. Load without the lock rather
+	// than queue up again behind another holder that may fail the same way.
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	r.Set(key, v)
+	return v, nil
+}
+
+// TryLock takes key's fill lock, the one GetOrLoad fills and refreshes
+// under, so that holding it also keeps other replicas' refreshes of key
+// from overlapping. If Redis cannot be reached the lock is granted, as a
+// failed read would load directly.
+func (r *Redis[V]) TryLock(key string) (func(), bool) {
+	token, locked, err := r.lock(key)
+	if err != nil {
+		slog.Warn("cache: redis lock failed", "key", key, "error", err)
+		return func() {}, true
+	}
+	if !locked {
+		return nil, false
+	}
+	return func() { r.unlock(key, token) }, true
+}
+
+func (r *Redis[V]) refreshInBackground(key string, load func() (V, error)) {
+	r.mu.Lock()
+	if _, busy := r.refreshing[key]; busy {
+		r.mu.Unlock()
+		return
+	}
+	r.refreshing[key] = struct{}{}
+	r.mu.Unlock()
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.refreshing, key)
+			r.mu.Unlock()
+		}()
+
+		token, locked, err := r.lock(key)
+		if err != nil {
+			slog.Warn("cache: redis lock failed", "key", key, "error", err)
+			return
+		}
+		if !locked {
+			return // another replica is refreshing
+		}
+		defer r.unlock(key, token)
+
+		v, err := load()
+		if err != nil {
+			slog.Warn("cache: background refresh failed", "key", key, "error", err)
+			return
+		}
+		r.Set(key, v)
+	}()
+}
+
+// get returns key's value and when it was stored. Only the write time is
+// fetched while it matches the memo; the value is fetched and decoded when
+// it does not.
+func (r *Redis[V]) get(key string) (V, time.Time, bool) {
+	var zero V
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	k := r.prefix + key
+
+	at, err := r.client.HGet(ctx, k, redisFieldStoredAt).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			r.forget(key)
+		} else {
+			slog.Warn("cache: redis get failed", "key", key, "error", err)
+		}
+		return zero, time.Time{}, false
+	}
+	storedAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		slog.Warn("cache: decode stored_at", "key", key, "error", err)
+		return zero, time.Time{}, false
+	}
+	r.mu.Lock()
+	m, ok := r.memo[key]
+	r.mu.Unlock()
+	if ok && m.storedAt == at {
+		return m.value, storedAt, true
+	}
+
+	// Both fields in one read, so the value is the one the time belongs
+	// to even if the entry is replaced meanwhile.
+	fields, err := r.client.HMGet(ctx, k, redisFieldStoredAt, redisFieldValue).Result()
+	if err != nil {
+		slog.Warn("cache: redis get failed", "key", key, "error", err)
+		return zero, time.Time{}, false
+	}
+	at, atOK := fields[0].(string)
+	data, dataOK := fields[1].(string)
+	if !atOK || !dataOK {
+		return zero, time.Time{}, false // replaced or expired meanwhile
+	}
+	if storedAt, err = time.Parse(time.RFC3339Nano, at); err != nil {
+		slog.Warn("cache: decode stored_at", "key", key, "error", err)
+		return zero, time.Time{}, false
+	}
+	var v V
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		// Most likely written by a build with a different value shape;
+		// treat as a miss so it gets overwritten.
+		slog.Warn("cache: decode value", "key", key, "error", err)
+		return zero, time.Time{}, false
+	}
+	r.mu.Lock()
+	r.memo[key] = redisMemo[V]{storedAt: at, value: v}
+	r.mu.Unlock()
+	return v, storedAt, true
+}
+
+// forget drops key's memo once its entry is gone from Redis.
+func (r *Redis[V]) forget(key string) {
+	r.mu.Lock()
+	delete(r.memo, key)
+	r.mu.Unlock()
+}
+
+// waitFor polls for key until it appears, or until ctx ends or the fill
+// lock is released without a value, as it is when the holder's load fails.
+func (r *Redis[V]) waitFor(ctx context.Context, key string) (V, bool) {
+	var zero V
+	ticker := time.NewTicker(redisPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return zero, false
+		}
+		// The lock is checked first: the holder stores its value before
+		// releasing the lock, so a value stored just after is still seen.
+		held := r.lockHeld(ctx, key)
+		if v, _, ok := r.get(key); ok {
+			return v, true
+		}
+		if !held {
+			return zero, false
+		}
+	}
+}
+
+// lockHeld reports whether key's fill lock is held. An error reads as held,
+// leaving waitFor to its deadline.
+func (r *Redis[V]) lockHeld(ctx context.Context, key string) bool {
+	ctx, cancel := context.WithTimeout(ctx, redisOpTimeout)
+	defer cancel()
+	n, err := r.client.Exists(ctx, r.lockKey(key)).Result()
+	return err != nil || n > 0
+}
+
+func (r *Redis[V]) lock(key string) (string, bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	ok, err := r.client.SetNX(ctx, r.lockKey(key), token, r.lockTTL).Result()
+	if err != nil {
+		return "", false, err
+	}
+	return token, ok, nil
+}
+
+func (r *Redis[V]) unlock(key, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := unlockScript.Run(ctx, r.client, []string{r.lockKey(key)}, token).Err(); err != nil {
+		// The lock expires on its own after lockTTL.
+		slog.Warn("cache: redis unlock failed", "key", key, "error", err)
+	}
+}
+
+func (r *Redis[V]) lockKey(key string) string {
+	return r.prefix + "lock:" + key
+}
@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis returns a store on a fresh in-process Redis stand-in, plus
+// the server so tests can attach further "replicas" to it.
+func newTestRedis(t *testing.T, softTTL time.Duration) (*Redis[[]string], *miniredis.Miniredis) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	return replicaOf(t, srv, softTTL), srv
+}
+
+// replicaOf returns a store with its own client on srv, as another
+// replica of the service would have.
+func replicaOf(t *testing.T, srv *miniredis.Miniredis, softTTL time.Duration) *Redis[[]string] {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedis[[]string](client, "test:", softTTL, time.Hour, 2*time.Second)
+}
+
+func TestRedisGetOrLoadMissLoadsAndShares(t *testing.T) {
+	t.Parallel()
+	r, _ := newTestRedis(t, time.Minute)
+
+	var calls atomic.Int32
+	load := func() ([]string, error) {
+		calls.Add(1)
+		return []string{"a", "b"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, fr, err := r.GetOrLoad(context.Background(), "k", load)
+		if err != nil {
+			t.Fatalf("GetOrLoad #%d: %v", i+1, err)
+		}
+		if len(v) != 2 || v[0] != "a" || fr != Fresh {
+			t.Errorf("GetOrLoad #%d = %v, %v; want [a b], Fresh", i+1, v, fr)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times, want 1 (second call served from Redis)", got)
+	}
+}
+
+func TestRedisValueVisibleToOtherReplica(t *testing.T) {
+	t.Parallel()
+	r1, srv := newTestRedis(t, time.Minute)
+	r2 := replicaOf(t, srv, time.Minute)
+
+	r1.Set("k", []string{"shared"})
+	v, fr, err := r2.GetOrLoad(context.Background(), "k", func() ([]string, error) {
+		t.Error("replica 2 loaded despite a shared value")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if len(v) != 1 || v[0] != "shared" || fr != Fresh {
+		t.Errorf("GetOrLoad = %v, %v; want [shared], Fresh", v, fr)
+	}
+}
+
+func TestRedisOnlyOneReplicaLoadsOnMiss(t *testing.T) {
+	t.Parallel()
+	_, srv := newTestRedis(t, time.Minute)
+
+	var calls atomic.Int32
+	load := func() ([]string, error) {
+		calls.Add(1)
+		time.Sleep(150 * time.Millisecond) // long enough for the others to queue
+		return []string{"v"}, nil
+	}
+
+	const replicas = 4
+	var wg sync.WaitGroup
+	errs := make([]error, replicas)
+	for i := 0; i < replicas; i++ {
+		r := replicaOf(t, srv, time.Minute)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, _, err := r.GetOrLoad(context.Background(), "k", load)
+			if err == nil && (len(v) != 1 || v[0] != "v") {
+				err = errors.New("unexpected value")
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("replica %d: %v", i, err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times across replicas, want 1", got)
+	}
+	if srv.Exists("test:lock:k") {
+		t.Error("fill lock was not released")
+	}
+}
+
+func TestRedisStaleRefreshedOnce(t *testing.T) {
+	t.Parallel()
+	r1, srv := newTestRedis(t, 20*time.Millisecond)
+	r2 := replicaOf(t, srv, 20*time.Millisecond)
+
+	r1.Set("k", []string{"old"})
+	time.Sleep(30 * time.Millisecond)
+
+	gate := make(chan struct{})
+	var calls atomic.Int32
+	load := func() ([]string, error) {
+		calls.Add(1)
+		<-gate
+		return []string{"new"}, nil
+	}
+
+	for _, r := range []*Redis[[]string]{r1, r2, r1, r2} {
+		v, fr, err := r.GetOrLoad(context.Background(), "k", load)
+		if err != nil {
+			t.Fatalf("GetOrLoad: %v", err)
+		}
+		if len(v) != 1 || v[0] != "old" || fr != Stale {
+			t.Fatalf("GetOrLoad = %v, %v; want [old], Stale", v, fr)
+		}
+	}
+	// Let the losing replica's goroutine observe the held lock before the
+	// winner finishes.
+	time.Sleep(50 * time.Millisecond)
+	close(gate)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		v, fr, _ := r2.GetOrLoad(context.Background(), "k", load)
+		if fr == Fresh && len(v) == 1 && v[0] == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stale value was never refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times across replicas, want one refresh", got)
+	}
+}
+
+func TestRedisUnavailableFallsBackToLoad(t *testing.T) {
+	t.Parallel()
+	srv := miniredis.RunT(t)
+	// No retries: the client's default backoff would only slow the test.
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	r := NewRedis[[]string](client, "test:", time.Minute, time.Hour, 2*time.Second)
+	srv.Close()
+
+	v, fr, err := r.GetOrLoad(context.Background(), "k", func() ([]string, error) { return []string{"direct"}, nil })
+	if err != nil {
+		t.Fatalf("GetOrLoad with Redis down: %v, want a direct load", err)
+	}
+	if len(v) != 1 || v[0] != "direct" || fr != Fresh {
+		t.Errorf("GetOrLoad = %v, %v; want [direct], Fresh", v, fr)
+	}
+}
+
+func TestRedisLoadErrorIsReturnedAndNotCached(t *testing.T) {
+	t.Parallel()
+	r, srv := newTestRedis(t, time.Minute)
+
+	boom := errors.New("boom")
+	if _, _, err := r.GetOrLoad(context.Background(), "k", func() ([]string, error) { return nil, boom }); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if srv.Exists("test:k") {
+		t.Error("failed load was stored")
+	}
+	if srv.Exists("test:lock:k") {
+		t.Error("fill lock was not released after a failed load")
+	}
+}
+
+func TestRedisEntryExpiresAtHardTTL(t *testing.T) {
+	t.Parallel()
+	r, srv := newTestRedis(t, time.Minute)
+
+	r.Set("k", []string{"v"})
+	if ttl := srv.TTL("test:k"); ttl != time.Hour {
+		t.Errorf("TTL = %v, want the hard TTL of 1h", ttl)
+	}
+}
+
+func TestRedisTryLockAcrossReplicas(t *testing.T) {
+	t.Parallel()
+	r1, srv := newTestRedis(t, time.Minute)
+	r2 := replicaOf(t, srv, time.Minute)
+
+	unlock, ok := r1.TryLock("k")
+	if !ok {
+		t.Fatal("TryLock on a free key failed")
+	}
+	if _, ok := r2.TryLock("k"); ok {
+		t.Error("second replica took a held lock")
+	}
+	unlock()
+	unlock2, ok := r2.TryLock("k")
+	if !ok {
+		t.Fatal("second replica could not lock after release")
+	}
+	unlock2()
+}
+
+func TestRedisReusesDecodedValueUntilRewritten(t *testing.T) {
+	t.Parallel()
+	r1, srv := newTestRedis(t, time.Minute)
+	r2 := replicaOf(t, srv, time.Minute)
+	noLoad := func() ([]string, error) {
+		t.Error("loaded despite a stored value")
+		return nil, nil
+	}
+
+	r1.Set("k", []string{"v1"})
+	if v, _, _ := r2.GetOrLoad(context.Background(), "k", noLoad); len(v) != 1 || v[0] != "v1" {
+		t.Fatalf("GetOrLoad = %v, want [v1]", v)
+	}
+	// Same write time, different bytes: a replica that decoded the entry
+	// once must not fetch and decode it again.
+	srv.HSet("test:k", "value", `["refetched"]`)
+	for _, r := range []*Redis[[]string]{r1, r2} {
+		if v, _, _ := r.GetOrLoad(context.Background(), "k", noLoad); len(v) != 1 || v[0] != "v1" {
+			t.Errorf("GetOrLoad = %v, want the memoized [v1]", v)
+		}
+	}
+
+	// A new write is seen at once.
+	r1.Set("k", []string{"v2"})
+	if v, _, _ := r2.GetOrLoad(context.Background(), "k", noLoad); len(v) != 1 || v[0] != "v2" {
+		t.Errorf("GetOrLoad after a rewrite = %v, want [v2]", v)
+	}
+}
+
+func TestRedisOverwritesEntryInOldFormat(t *testing.T) {
+	t.Parallel()
+	r, srv := newTestRedis(t, time.Minute)
+	srv.Set("test:k", `{"stored_at":"2026-01-01T00:00:00Z","value":["old"]}`)
+
+	v, _, err := r.GetOrLoad(context.Background(), "k", func() ([]string, error) { return []string{"new"}, nil })
+	if err != nil || len(v) != 1 || v[0] != "new" {
+		t.Fatalf("GetOrLoad = %v, %v; want a load of [new]", v, err)
+	}
+	if got := srv.HGet("test:k", "stored_at"); got == "" {
+		t.Error("old-format entry was not replaced")
+	}
+}
+
+func TestRedisWaiterStopsWhenHolderFailsWithoutValue(t *testing.T) {
+	t.Parallel()
+	r1, srv := newTestRedis(t, time.Minute)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	// A lock TTL far beyond the test: only the released lock can end the
+	// wait in time.
+	r2 := NewRedis[[]string](client, "test:", time.Minute, time.Hour, time.Minute)
+
+	go r1.GetOrLoad(context.Background(), "k", func() ([]string, error) {
+		time.Sleep(150 * time.Millisecond)
+		return nil, errors.New("upstream down")
+	})
+	for !srv.Exists("test:lock:k") {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	v, _, err := r2.GetOrLoad(context.Background(), "k", func() ([]string, error) { return []string{"own"}, nil })
+	if err != nil || len(v) != 1 || v[0] != "own" {
+		t.Fatalf("GetOrLoad = %v, %v; want its own load", v, err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("waited %v after the holder gave up its lock", waited)
+	}
+}
+
+func TestRedisWaiterStopsAtContextEnd(t *testing.T) {
+	t.Parallel()
+	r1, srv := newTestRedis(t, time.Minute)
+	r2 := replicaOf(t, srv, time.Minute)
+
+	unlock, ok := r1.TryLock("k")
+	if !ok {
+		t.Fatal("TryLock failed")
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// The load may run once the test's lock is released, after the test.
+	_, _, err := r2.GetOrLoad(ctx, "k", func() ([]string, error) { return nil, nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the caller's deadline", err)
+	}
+}
@@ -0,0 +1,26 @@
+package cache
+
+import "context"
+
+// Store is a read-through cache with stale-while-revalidate semantics. Cache
+// is the per-process implementation; Redis shares entries, and the work of
+// filling them, across replicas.
+type Store[V any] interface {
+	// GetOrLoad returns the value for key, loading it on a miss and
+	// refreshing it in the background once stale. See Cache.GetOrLoad.
+	// ctx bounds only the caller's wait: a load other callers share runs
+	// on.
+	GetOrLoad(ctx context.Context, key string, load func() (V, error)) (V, Freshness, error)
+	// Set stores value under key, fresh as of now.
+	Set(key string, value V)
+	// TryLock takes key's fill lock, for work that refills key with Set
+	// outside GetOrLoad and should run once however many replicas share
+	// the store. ok is false while another holder has it; otherwise
+	// unlock releases it.
+	TryLock(key string) (unlock func(), ok bool)
+}
+
+var (
+	_ Store[int] = (*Cache[int])(nil)
+	_ Store[int] = (*Redis[int])(nil)
+)
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// gzipCompressor compresses the JSON bodies written per request. SSE is
+// deliberately absent — compressing it buffers flushes and breaks
+// progressive delivery.
+var gzipCompressor = middleware.NewCompressor(5, "application/json", "application/geo+json")
+
+// Compress gzips the responses the response cache does not serve: the
+// types list, error JSON, and anything else written per request. Cached
+// bodies already carry a Content-Encoding, which the compressor leaves
+// alone. It only engages when the client accepts gzip, so whenever it
+// runs the cache would also have chosen an encoded variant; a body the
+// cache sends as identity is never compressed under that variant's ETag.
+func Compress(next http.Handler) http.Handler {
+	compressed := gzipCompressor.Handler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if negotiateAmong(r.Header.Get("Accept-Encoding"), []string{"gzip"}) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		compressed.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompressCoversResponsesTheCacheDoesNot(t *testing.T) {
+	t.Parallel()
+	f := &fakeAdapter{source: "alpha", events: makeEvents(50, "alpha")}
+	h := newTestHandler(t, f)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/events", h.GetEvents)
+	mux.HandleFunc("/api/v1/types", GetTypes)
+	srv := Compress(mux)
+
+	get := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	cases := []struct {
+		name           string
+		target         string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{"types", "/api/v1/types", "gzip", "gzip", `"hurricane"`},
+		{"error JSON", "/api/v1/events?limit=0", "gzip, br", "gzip", "invalid limit"},
+		// Cached bodies pass through as the cache encoded them, not twice.
+		{"cached body", "/api/v1/events?format=json", "gzip, br", "br", `"alpha-0"`},
+		{"cached gzip body", "/api/v1/events?format=json", "gzip", "gzip", `"alpha-0"`},
+		// The cache sends identity here; it must not be encoded after all.
+		{"identity body", "/api/v1/events?format=json", "deflate", "", `"alpha-0"`},
+		{"gzip refused", "/api/v1/types", "br;q=0.5, gzip;q=0", "", `"hurricane"`},
+	}
+	for _, tc := range cases {
+		rec := get(tc.target, tc.acceptEncoding)
+		if got := rec.Header().Get("Content-Encoding"); got != tc.wantEncoding {
+			t.Errorf("%s: Content-Encoding = %q, want %q", tc.name, got, tc.wantEncoding)
+			continue
+		}
+		if body := decodeBody(t, tc.wantEncoding, rec.Body.Bytes()); !bytes.Contains(body, []byte(tc.wantBody)) {
+			t.Errorf("%s: decoded body lacks %s: %.200s", tc.name, tc.wantBody, body)
+		}
+	}
+
+	// Compressing SSE would buffer flushes.
+	rec := get("/api/v1/events?format=sse", "gzip")
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("SSE: Content-Encoding = %q, want none", got)
+	}
+	if !bytes.Contains(rec.Body.Bytes(), []byte("event: done")) {
+		t.Errorf("SSE body lacks the done event: %.200s", rec.Body.String())
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,11 +25,15 @@ const (
 )
 
 type EventsHandler struct {
-	service *service.EventsService
+	service   *service.EventsService
+	responses *responseCache
 }
 
 func NewEventsHandler(svc *service.EventsService) *EventsHandler {
-	return &EventsHandler{service: svc}
+	return &EventsHandler{
+		service:   svc,
+		responses: newResponseCache(responseCacheBytes),
+	}
 }
 
 func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
@@ -37,10 +43,16 @@ func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	live, err := parseLive(r, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if format == "sse" {
 		// No default limit here: SSE is the map's own chunked path and is
 		// expected to deliver the full matching set unless asked otherwise.
-		h.streamEvents(w, r, params)
+		h.streamEvents(w, r, params, live)
 		return
 	}
 
@@ -61,29 +73,28 @@ func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var data []byte
-	switch format {
-	case "json":
-		data, err = models.MarshalEventsJSON(events, sources)
-		w.Header().Set("Content-Type", "application/json")
-	default:
-		data, err = models.MarshalGeoJSON(events, sources)
-		w.Header().Set("Content-Type", "application/geo+json")
-	}
-
+	// Querying the indexed snapshots is cheap; encoding the result is not.
+	// Identical queries over unchanged snapshots reuse one encoded body.
+	resp, err := h.responses.getOrBuild(responseKey(params, format), sourcesVersion(sources),
+		func() (string, []byte, error) {
+			if format == "json" {
+				data, err := models.MarshalEventsJSON(events, sources)
+				return "application/json", data, err
+			}
+			data, err := models.MarshalGeoJSON(events, sources)
+			return "application/geo+json", data, err
+		})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to marshal response")
 		return
 	}
-
-	// Aligned with the server-side source cache: repeat requests within
-	// the window can be answered by intermediaries and browsers.
-	w.Header().Set("Cache-Control", "public, max-age=60")
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	resp.serve(w, r)
 }
 
-func (h *EventsHandler) streamEvents(w http.ResponseWriter, r *http.Request, params adapters.FetchParams) {
+// streamEvents writes one features event per source, then done. A live
+// stream stays open after done, writing a features event for each matching
+// event a streaming source pushes; Limit applies only before done.
+func (h *EventsHandler) streamEvents(w http.ResponseWriter, r *http.Request, params adapters.FetchParams, live bool) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeError(w, http.StatusInternalServerError, "streaming not supported")
@@ -100,6 +111,16 @@ func (h *EventsHandler) streamEvents(w http.ResponseWriter, r *http.Request, par
 	// ResponseWriter chain doesn't support it, the old timeout applies.
 	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
 
+	// Subscribed before the snapshot stream starts, so that nothing pushed
+	// in between is missed; a push may repeat an event the snapshot already
+	// had, which clients replace by ID.
+	var pushes <-chan service.StreamBatch
+	if live {
+		var unsubscribe func()
+		pushes, unsubscribe = h.service.Subscribe(params)
+		defer unsubscribe()
+	}
+
 	ch := make(chan service.StreamBatch, 4)
 	go func() {
 		h.service.StreamEvents(r.Context(), params, ch)
@@ -126,8 +147,10 @@ loop:
 				continue
 			}
 			sources = append(sources, models.SourceStatus{
-				Source: batch.Source,
-				OK:     true,
+				Source:    batch.Source,
+				OK:        true,
+				Stale:     batch.Stale,
+				FetchedAt: batch.FetchedAt,
 			})
 			if len(batch.Events) == 0 {
 				continue
@@ -150,6 +173,46 @@ loop:
 	doneData, _ := json.Marshal(map[string]any{"total": total, "sources": sources})
 	fmt.Fprintf(w, "event: done\ndata: %s\n\n", doneData)
 	flusher.Flush()
+
+	if !live {
+		return
+	}
+	for {
+		select {
+		case batch, ok := <-pushes:
+			if !ok {
+				return // shutting down
+			}
+			data, err := models.MarshalGeoJSON(batch.Events, nil)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: features\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// parseLive reads the live parameter, which keeps an SSE stream open for
+// pushed events and means nothing to the other formats.
+func parseLive(r *http.Request, format string) (bool, error) {
+	v := r.URL.Query().Get("live")
+	if v == "" {
+		return false, nil
+	}
+	live, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid live: must be true or false")
+	}
+	if live && format != "sse" {
+		return false, fmt.Errorf("invalid live: only supported with format=sse")
+	}
+	return live, nil
 }
 
 func parseQueryParams(r *http.Request) (adapters.FetchParams, string, error) {
@@ -160,17 +223,22 @@ func parseQueryParams(r *http.Request) (adapters.FetchParams, string, error) {
 		// Validated and deduplicated: unknown values are rejected rather
 		// than passed through, because raw user input would otherwise mint
 		// unbounded cache keys (each triggering a fresh upstream fetch).
+		// Aliases resolve to their type, and each type expands to its
+		// descendants, so the service only ever matches exact types.
 		seen := make(map[string]struct{})
-		for _, t := range strings.Split(types, ",") {
-			t = strings.TrimSpace(t)
-			if !models.IsValidEventType(t) {
-				return params, "", fmt.Errorf("invalid type %q: valid types are %s", t, strings.Join(models.EventTypes, ", "))
+		for _, name := range strings.Split(types, ",") {
+			name = strings.TrimSpace(name)
+			t, ok := models.ResolveEventType(name)
+			if !ok {
+				return params, "", fmt.Errorf("invalid type %q: valid types are %s", name, strings.Join(models.EventTypes, ", "))
 			}
-			if _, dup := seen[t]; dup {
-				continue
+			for _, t := range models.EventTypeSubtree(t) {
+				if _, dup := seen[t]; dup {
+					continue
+				}
+				seen[t] = struct{}{}
+				params.Types = append(params.Types, t)
 			}
-			seen[t] = struct{}{}
-			params.Types = append(params.Types, t)
 		}
 	}
 
@@ -182,6 +250,15 @@ func parseQueryParams(r *http.Request) (adapters.FetchParams, string, error) {
 		params.BBox = bbox
 	}
 
+	nearStr, radiusStr := q.Get("near"), q.Get("radius_km")
+	if nearStr != "" || radiusStr != "" {
+		near, err := parseNear(nearStr, radiusStr)
+		if err != nil {
+			return params, "", fmt.Errorf("invalid near: %w", err)
+		}
+		params.Near = near
+	}
+
 	if sinceStr := q.Get("since"); sinceStr != "" {
 		t, err := time.Parse(time.RFC3339, sinceStr)
 		if err != nil {
@@ -204,6 +281,53 @@ func parseQueryParams(r *http.Request) (adapters.FetchParams, string, error) {
 		params.Limit = limit
 	}
 
+	if minMag := q.Get("min_magnitude"); minMag != "" {
+		m, err := strconv.ParseFloat(minMag, 64)
+		if err != nil || math.IsNaN(m) || math.IsInf(m, 0) {
+			return params, "", fmt.Errorf("invalid min_magnitude: must be a number")
+		}
+		params.MinMagnitude = &m
+	}
+
+	if reviewed := q.Get("reviewed_only"); reviewed != "" {
+		v, err := strconv.ParseBool(reviewed)
+		if err != nil {
+			return params, "", fmt.Errorf("invalid reviewed_only: must be true or false")
+		}
+		params.ReviewedOnly = v
+	}
+
+	if tsunami := q.Get("tsunami"); tsunami != "" {
+		v, err := strconv.ParseBool(tsunami)
+		if err != nil {
+			return params, "", fmt.Errorf("invalid tsunami: must be true or false")
+		}
+		params.Tsunami = &v
+	}
+
+	switch status := q.Get("status"); status {
+	case "", adapters.StatusOpen, adapters.StatusClosed, adapters.StatusAll:
+		params.Status = status
+	default:
+		return params, "", fmt.Errorf("invalid status: must be 'open', 'closed', or 'all'")
+	}
+
+	if minSev := q.Get("min_severity"); minSev != "" {
+		if !models.IsValidSeverity(minSev) {
+			return params, "", fmt.Errorf("invalid min_severity: must be 'minor', 'moderate', 'severe', or 'extreme'")
+		}
+		params.MinSeverity = minSev
+	}
+
+	switch order := q.Get("sort"); order {
+	case "", adapters.SortNewest, adapters.SortSeverity:
+		params.Sort = order
+	default:
+		return params, "", fmt.Errorf("invalid sort: must be 'newest' or 'severity'")
+	}
+
+	normalizeParams(&params)
+
 	format := q.Get("format")
 	if format != "" && format != "geojson" && format != "json" && format != "sse" {
 		return params, "", fmt.Errorf("invalid format: must be 'geojson', 'json', or 'sse'")
@@ -215,6 +339,32 @@ func parseQueryParams(r *http.Request) (adapters.FetchParams, string, error) {
 	return params, format, nil
 }
 
+// normalizeParams puts equivalent queries into one form so they share a
+// response cache entry. Types are sorted, bboxes widened outward to the
+// next 0.01° (about a kilometre), radius centers rounded to 0.01° and radii
+// rounded up to 0.1 km; at map scale none of this changes what is shown.
+// An explicit status=open or sort=newest is the default.
+func normalizeParams(params *adapters.FetchParams) {
+	sort.Strings(params.Types)
+	if params.Status == adapters.StatusOpen {
+		params.Status = ""
+	}
+	if params.Sort == adapters.SortNewest {
+		params.Sort = ""
+	}
+	if b := params.BBox; b != nil {
+		b.MinLon = math.Floor(b.MinLon*100) / 100
+		b.MinLat = math.Floor(b.MinLat*100) / 100
+		b.MaxLon = math.Ceil(b.MaxLon*100) / 100
+		b.MaxLat = math.Ceil(b.MaxLat*100) / 100
+	}
+	if n := params.Near; n != nil {
+		n.Lon = math.Round(n.Lon*100) / 100
+		n.Lat = math.Round(n.Lat*100) / 100
+		n.RadiusKm = math.Ceil(n.RadiusKm*10) / 10
+	}
+}
+
 func parseBBox(s string) (*adapters.BBox, error) {
 	parts := strings.Split(s, ",")
 	if len(parts) != 4 {
@@ -238,6 +388,33 @@ func parseBBox(s string) (*adapters.BBox, error) {
 	}, nil
 }
 
+// maxRadiusKm is half the Earth's circumference: any larger radius already
+// covers the whole globe.
+const maxRadiusKm = 20015.0
+
+func parseNear(nearStr, radiusStr string) (*adapters.Circle, error) {
+	if nearStr == "" || radiusStr == "" {
+		return nil, fmt.Errorf("near and radius_km must be given together")
+	}
+	parts := strings.Split(nearStr, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected 2 values: lon,lat")
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("lon must be a number between -180 and 180")
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("lat must be a number between -90 and 90")
+	}
+	radius, err := strconv.ParseFloat(radiusStr, 64)
+	if err != nil || radius <= 0 || radius > maxRadiusKm {
+		return nil, fmt.Errorf("radius_km must be a number greater than 0 and at most %g", maxRadiusKm)
+	}
+	return &adapters.Circle{Lon: lon, Lat: lat, RadiusKm: radius}, nil
+}
+
 func writeError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
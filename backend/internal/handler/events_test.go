@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -30,13 +31,11 @@ type fakeAdapter struct {
 
 	mu    sync.Mutex
 	calls int
-	got   []adapters.FetchParams
 }
 
 func (f *fakeAdapter) FetchEvents(ctx context.Context, params adapters.FetchParams) ([]models.Event, error) {
 	f.mu.Lock()
 	f.calls++
-	f.got = append(f.got, params)
 	f.mu.Unlock()
 
 	if f.gate != nil {
@@ -67,19 +66,9 @@ func (f *fakeAdapter) callCount() int {
 	return f.calls
 }
 
-func (f *fakeAdapter) lastParams(t *testing.T) adapters.FetchParams {
-	t.Helper()
-	f.mu.Lock()
-	defer f.mu.Unlock()
-	if len(f.got) == 0 {
-		t.Fatal("adapter was never called")
-	}
-	return f.got[len(f.got)-1]
-}
-
 func newTestHandler(t *testing.T, adps ...adapters.Adapter) *EventsHandler {
 	t.Helper()
-	c := cache.New[[]models.Event](time.Minute)
+	c := cache.New[service.Snapshot](time.Minute)
 	t.Cleanup(c.Close)
 	svc := service.NewEventsService(adps, c, 5*time.Second)
 	return NewEventsHandler(svc)
@@ -194,11 +183,27 @@ func TestGetEventsBadRequests(t *testing.T) {
 		{"unknown type lists valid ones", "?types=sharknado", "valid types are"},
 		{"bbox wrong count", "?bbox=1,2,3", "expected 4 values"},
 		{"bbox non-numeric", "?bbox=a,2,3,4", "not a valid number"},
+		{"near without radius", "?near=10,10", "must be given together"},
+		{"radius without near", "?radius_km=50", "must be given together"},
+		{"near wrong count", "?near=10&radius_km=50", "expected 2 values"},
+		{"near lat out of range", "?near=10,95&radius_km=50", "lat must be"},
+		{"near lon non-numeric", "?near=x,10&radius_km=50", "lon must be"},
+		{"radius zero", "?near=10,10&radius_km=0", "radius_km must be"},
+		{"radius beyond half the globe", "?near=10,10&radius_km=30000", "radius_km must be"},
 		{"invalid since", "?since=yesterday", "invalid since"},
 		{"limit zero", "?limit=0", "invalid limit"},
 		{"limit negative", "?limit=-5", "invalid limit"},
 		{"limit non-numeric", "?limit=abc", "invalid limit"},
 		{"invalid format", "?format=xml", "invalid format"},
+		{"live not a bool", "?format=sse&live=maybe", "invalid live"},
+		{"live without sse", "?live=true", "only supported with format=sse"},
+		{"min_magnitude non-numeric", "?min_magnitude=big", "invalid min_magnitude"},
+		{"min_magnitude infinite", "?min_magnitude=Inf", "invalid min_magnitude"},
+		{"reviewed_only not a bool", "?reviewed_only=yes", "invalid reviewed_only"},
+		{"tsunami not a bool", "?tsunami=maybe", "invalid tsunami"},
+		{"unknown status", "?status=ended", "invalid status"},
+		{"unknown min_severity", "?min_severity=high", "invalid min_severity"},
+		{"unknown sort", "?sort=magnitude", "invalid sort"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -223,43 +228,105 @@ func TestGetEventsBadRequests(t *testing.T) {
 	}
 }
 
-func TestGetEventsTypesDedupAndTrim(t *testing.T) {
+func TestParseQueryParamsTypesDedupAndTrim(t *testing.T) {
 	t.Parallel()
-	f := &fakeAdapter{source: "alpha", events: makeEvents(1, "alpha")}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events?types=earthquake,%20flood%20,earthquake", nil)
+	params, _, err := parseQueryParams(req)
+	if err != nil {
+		t.Fatalf("parseQueryParams: %v", err)
+	}
+	got := params.Types
+	if len(got) != 2 || got[0] != "earthquake" || got[1] != "flood" {
+		t.Errorf("Types = %v, want [earthquake flood] (trimmed, deduped)", got)
+	}
+}
+
+func TestParseQueryParamsTypesExpandAliasesAndDescendants(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"types=storm", "cyclone,hurricane,storm,tornado,winter_storm"},
+		{"types=typhoon", "cyclone,hurricane"},
+		{"types=hurricane,cyclone", "cyclone,hurricane"},
+		{"types=quake,flood", "earthquake,flood"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?"+tc.query, nil)
+		params, _, err := parseQueryParams(req)
+		if err != nil {
+			t.Fatalf("%s: parseQueryParams: %v", tc.query, err)
+		}
+		if got := strings.Join(params.Types, ","); got != tc.want {
+			t.Errorf("%s: Types = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestGetEventsParentTypeIncludesDescendants(t *testing.T) {
+	t.Parallel()
+	events := makeEvents(4, "alpha")
+	for i, typ := range []string{"storm", "hurricane", "tornado", "flood"} {
+		events[i].EventType = typ
+	}
+	f := &fakeAdapter{source: "alpha", events: events}
 	h := newTestHandler(t, f)
 
-	rec := doGet(t, h, "?types=earthquake,%20flood%20,earthquake")
+	rec := doGet(t, h, "?types=storm&format=json")
 	if rec.Code != http.StatusOK {
-		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
 	}
-	got := f.lastParams(t).Types
-	if len(got) != 2 || got[0] != "earthquake" || got[1] != "flood" {
-		t.Errorf("upstream Types = %v, want [earthquake flood] (trimmed, deduped)", got)
+	var body struct {
+		Events []models.FlatEvent `json:"events"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range body.Events {
+		got = append(got, e.EventType)
+	}
+	if strings.Join(got, ",") != "storm,hurricane,tornado" {
+		t.Errorf("types=storm returned %v, want storm, hurricane and tornado", got)
 	}
 }
 
 func TestGetEventsSinceFormats(t *testing.T) {
 	t.Parallel()
+	day := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	events := []models.Event{
+		{
+			ID: "morning", Title: "morning", EventType: "earthquake", Source: "alpha",
+			Geometry:  models.Geometry{Type: "Point", Coordinates: []float64{1, 1}},
+			StartedAt: day.Add(12 * time.Hour), UpdatedAt: day.Add(12 * time.Hour),
+		},
+		{
+			ID: "afternoon", Title: "afternoon", EventType: "earthquake", Source: "alpha",
+			Geometry:  models.Geometry{Type: "Point", Coordinates: []float64{1, 1}},
+			StartedAt: day.Add(13 * time.Hour), UpdatedAt: day.Add(13 * time.Hour),
+		},
+	}
 	cases := []struct {
 		name  string
 		query string
-		want  time.Time
+		want  int
 	}{
-		{"RFC3339", "?since=2026-08-01T12:30:00Z", time.Date(2026, 8, 1, 12, 30, 0, 0, time.UTC)},
-		{"date only", "?since=2026-08-01", time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"RFC3339", "?since=2026-08-01T12:30:00Z", 1},
+		{"date only", "?since=2026-08-01", 2},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			f := &fakeAdapter{source: "alpha", events: makeEvents(1, "alpha")}
+			f := &fakeAdapter{source: "alpha", events: events}
 			h := newTestHandler(t, f)
 
 			rec := doGet(t, h, tc.query)
 			if rec.Code != http.StatusOK {
 				t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
 			}
-			if got := f.lastParams(t).Since; !got.Equal(tc.want) {
-				t.Errorf("upstream Since = %v, want %v", got, tc.want)
+			if fc := decodeFeatureCollection(t, rec); len(fc.Features) != tc.want {
+				t.Errorf("got %d features, want %d", len(fc.Features), tc.want)
 			}
 		})
 	}
@@ -299,6 +366,161 @@ func TestGetEventsBBoxFiltering(t *testing.T) {
 	}
 }
 
+func TestGetEventsAttributeFilters(t *testing.T) {
+	t.Parallel()
+	quake := func(id string, mag float64, status string, tsunami bool) models.Event {
+		return models.Event{
+			ID: id, Title: id, EventType: "earthquake", Source: "alpha",
+			Magnitude: &mag,
+			StartedAt: baseTime, UpdatedAt: baseTime,
+			Metadata: map[string]any{
+				models.MetaReviewStatus: status,
+				models.MetaTsunami:      tsunami,
+			},
+		}
+	}
+	unrated := models.Event{ID: "alert", Title: "alert", EventType: "flood", Source: "alpha", StartedAt: baseTime, UpdatedAt: baseTime}
+	ended := quake("ended", 6.0, "reviewed", false)
+	ended.EndedAt = baseTime.Add(time.Hour)
+	f := &fakeAdapter{source: "alpha", events: []models.Event{
+		quake("big-reviewed", 7.1, "reviewed", true),
+		quake("small-automatic", 2.4, "automatic", false),
+		quake("mid-automatic", 5.0, "automatic", false),
+		unrated,
+		ended,
+	}}
+	h := newTestHandler(t, f)
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"?min_magnitude=5", []string{"big-reviewed", "mid-automatic"}},
+		{"?reviewed_only=true", []string{"big-reviewed", "alert"}},
+		{"?tsunami=1", []string{"big-reviewed"}},
+		{"?tsunami=0", []string{"small-automatic", "mid-automatic", "alert"}},
+		{"?min_magnitude=3&reviewed_only=1", []string{"big-reviewed"}},
+		{"?status=closed", []string{"ended"}},
+		{"?status=all&min_magnitude=5.5", []string{"big-reviewed", "ended"}},
+	}
+	for _, tc := range cases {
+		rec := doGet(t, h, tc.query+"&format=json")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; body: %s", tc.query, rec.Code, rec.Body.String())
+		}
+		var resp models.EventsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range resp.Events {
+			got = append(got, e.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestGetEventsSeverityFilterAndSort(t *testing.T) {
+	t.Parallel()
+	event := func(id, source, severity string, mag float64, started time.Time) models.Event {
+		e := models.Event{
+			ID: id, Title: id, EventType: "earthquake", Source: source, Severity: severity,
+			StartedAt: started, UpdatedAt: started,
+		}
+		if mag > 0 {
+			e.Magnitude = &mag
+		}
+		return e
+	}
+	alpha := &fakeAdapter{source: "alpha", events: []models.Event{
+		event("minor-new", "alpha", "minor", 0, baseTime.Add(3*time.Hour)),
+		event("severe-old", "alpha", "severe", 0, baseTime),
+		event("unrated", "alpha", "", 0, baseTime.Add(4*time.Hour)),
+	}}
+	// usgs events score by magnitude within their band: the M7.5 scores
+	// 67 and the M6.5 61, either side of the unmeasured severe event's
+	// mid-band 63.
+	usgs := &fakeAdapter{source: "usgs", events: []models.Event{
+		event("severe-m65", "usgs", "severe", 6.5, baseTime.Add(2*time.Hour)),
+		event("severe-m75", "usgs", "severe", 7.5, baseTime.Add(time.Hour)),
+		event("extreme", "usgs", "extreme", 8.0, baseTime.Add(-time.Hour)),
+	}}
+	h := newTestHandler(t, alpha, usgs)
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"?min_severity=severe", []string{"severe-m65", "severe-m75", "severe-old", "extreme"}},
+		{"?min_severity=extreme", []string{"extreme"}},
+		{"?sort=severity", []string{"extreme", "severe-m75", "severe-old", "severe-m65", "minor-new", "unrated"}},
+		{"?sort=severity&limit=2", []string{"extreme", "severe-m75"}},
+		{"?sort=severity&min_severity=moderate&min_magnitude=7", []string{"extreme", "severe-m75"}},
+		{"?sort=newest&limit=2", []string{"unrated", "minor-new"}},
+	}
+	for _, tc := range cases {
+		rec := doGet(t, h, tc.query+"&format=json")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; body: %s", tc.query, rec.Code, rec.Body.String())
+		}
+		var resp models.EventsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range resp.Events {
+			got = append(got, e.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	rec := doGet(t, h, "?sort=severity&limit=1&format=json")
+	var resp models.EventsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Events) != 1 || resp.Events[0].Impact == nil {
+		t.Fatalf("events = %+v, want one with an impact", resp.Events)
+	}
+	if got := *resp.Events[0].Impact; got != 94 {
+		t.Errorf("extreme M8.0 impact = %d, want 94", got)
+	}
+}
+
+func TestGetEventsNearFiltering(t *testing.T) {
+	t.Parallel()
+	events := []models.Event{
+		{
+			ID: "near", Title: "near", EventType: "earthquake", Source: "alpha",
+			Geometry:  models.Geometry{Type: "Point", Coordinates: []float64{10.5, 10}},
+			StartedAt: baseTime, UpdatedAt: baseTime,
+		},
+		{
+			ID: "far", Title: "far", EventType: "earthquake", Source: "alpha",
+			Geometry:  models.Geometry{Type: "Point", Coordinates: []float64{15, 10}},
+			StartedAt: baseTime, UpdatedAt: baseTime,
+		},
+	}
+	f := &fakeAdapter{source: "alpha", events: events}
+	h := newTestHandler(t, f)
+
+	rec := doGet(t, h, "?near=10,10&radius_km=100")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+	fc := decodeFeatureCollection(t, rec)
+	if len(fc.Features) != 1 {
+		t.Fatalf("got %d features, want 1 (only the event within 100 km)", len(fc.Features))
+	}
+	if id := fc.Features[0].Properties["id"]; id != "near" {
+		t.Errorf("feature id = %v, want near", id)
+	}
+}
+
 func TestGetEventsLimits(t *testing.T) {
 	t.Parallel()
 
@@ -672,3 +894,109 @@ func TestSSEWithoutFlusherFails(t *testing.T) {
 		t.Errorf("error = %q, want %q", msg, "streaming not supported")
 	}
 }
+
+// fakeStreamer is a fakeAdapter that streams whatever is sent on pushes.
+type fakeStreamer struct {
+	*fakeAdapter
+	pushes chan models.Event
+}
+
+func (f *fakeStreamer) Stream(ctx context.Context, emit func(models.Event)) error {
+	for {
+		select {
+		case e := <-f.pushes:
+			emit(e)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestSSELiveDeliversPushes(t *testing.T) {
+	t.Parallel()
+	fs := &fakeStreamer{
+		fakeAdapter: &fakeAdapter{source: "alpha", events: makeEvents(1, "alpha")},
+		pushes:      make(chan models.Event),
+	}
+	c := cache.New[service.Snapshot](time.Minute)
+	t.Cleanup(c.Close)
+	svc := service.NewEventsService([]adapters.Adapter{fs}, c, 5*time.Second)
+	ctx, stop := context.WithCancel(context.Background())
+	streamsDone := make(chan struct{})
+	go func() {
+		svc.RunStreams(ctx, time.Hour)
+		close(streamsDone)
+	}()
+	t.Cleanup(func() {
+		stop()
+		<-streamsDone
+	})
+	srv := httptest.NewServer(http.HandlerFunc(NewEventsHandler(svc).GetEvents))
+	t.Cleanup(srv.Close)
+
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	defer cancelReq()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL+"?format=sse&live=true&bbox=0,0,50,50", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	frames := make(chan sseFrame)
+	go func() {
+		defer close(frames)
+		sc := bufio.NewScanner(resp.Body)
+		var f sseFrame
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				f.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				f.data = strings.TrimPrefix(line, "data: ")
+			case line == "" && f.event != "":
+				frames <- f
+				f = sseFrame{}
+			}
+		}
+	}()
+	next := func() sseFrame {
+		t.Helper()
+		select {
+		case f, ok := <-frames:
+			if !ok {
+				t.Fatal("stream ended")
+			}
+			return f
+		case <-time.After(2 * time.Second):
+			t.Fatal("no frame")
+			return sseFrame{}
+		}
+	}
+
+	// The snapshot's one event lies outside the bbox.
+	if f := next(); f.event != "done" {
+		t.Fatalf("first frame = %q, want done", f.event)
+	}
+
+	outside := makeEvents(1, "alpha")[0]
+	outside.ID = "alpha-far"
+	outside.Geometry.Coordinates = []float64{-100, -40}
+	pushed := makeEvents(1, "alpha")[0]
+	pushed.ID = "alpha-new"
+	pushed.Geometry.Coordinates = []float64{10, 10}
+	fs.pushes <- outside
+	fs.pushes <- pushed
+
+	f := next()
+	if f.event != "features" {
+		t.Fatalf("frame after done = %q, want features", f.event)
+	}
+	var fc models.FeatureCollection
+	if err := json.Unmarshal([]byte(f.data), &fc); err != nil {
+		t.Fatalf("pushed frame: %v", err)
+	}
+	if len(fc.Features) != 1 || fc.Features[0].Properties["id"] != "alpha-new" {
+		t.Errorf("pushed frame = %s, want only the event inside the bbox", f.data)
+	}
+}
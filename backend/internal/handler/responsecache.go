@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// responseCacheBytes bounds the encoded bodies kept across all variants.
+// A default map response is ~400 KB raw and a tenth of that compressed, so
+// this holds well over a hundred distinct queries.
+const responseCacheBytes = 64 << 20
+
+// responseEncodings lists the encodings bodies are stored in, in the order
+// preferred when a client accepts several equally.
+var responseEncodings = []string{"br", "zstd", "gzip"}
+
+// Bodies are encoded once per snapshot and then served many times, so the
+// encoders trade CPU for size. EncodeAll is safe for concurrent use.
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+
+// cachedResponse is one encoded response body in every stored encoding.
+// It is immutable once built.
+type cachedResponse struct {
+	contentType string
+	// tag is the body hash; each encoding's ETag is derived from it.
+	tag    string
+	bodies map[string][]byte // by Content-Encoding, "" for identity
+}
+
+func newCachedResponse(contentType string, body []byte) (*cachedResponse, error) {
+	sum := sha256.Sum256(body)
+	resp := &cachedResponse{
+		contentType: contentType,
+		tag:         hex.EncodeToString(sum[:12]),
+		bodies:      map[string][]byte{"": body},
+	}
+
+	var buf bytes.Buffer
+	gz, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err := gz.Write(body); err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	resp.bodies["gzip"] = bytes.Clone(buf.Bytes())
+
+	buf.Reset()
+	br := brotli.NewWriterLevel(&buf, 9)
+	if _, err := br.Write(body); err != nil {
+		return nil, fmt.Errorf("brotli: %w", err)
+	}
+	if err := br.Close(); err != nil {
+		return nil, fmt.Errorf("brotli: %w", err)
+	}
+	resp.bodies["br"] = bytes.Clone(buf.Bytes())
+
+	resp.bodies["zstd"] = zstdEncoder.EncodeAll(body, nil)
+	return resp, nil
+}
+
+func (c *cachedResponse) size() int {
+	n := 0
+	for _, b := range c.bodies {
+		n += len(b)
+	}
+	return n
+}
+
+// etag is the strong ETag of the body in encoding. Encodings get distinct
+// tags, as the bytes differ, but share a prefix so revalidation matches
+// whichever variant the client cached.
+func (c *cachedResponse) etag(encoding string) string {
+	if encoding == "" {
+		return `"` + c.tag + `"`
+	}
+	return `"` + c.tag + "-" + encoding + `"`
+}
+
+// serve writes the variant the client accepts best, or 304 when the
+// client already has this body in any encoding.
+func (c *cachedResponse) serve(w http.ResponseWriter, r *http.Request) {
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	body := c.bodies[encoding]
+
+	h := w.Header()
+	h.Set("Content-Type", c.contentType)
+	// Aligned with the server-side source cache: repeat requests within
+	// the window can be answered by intermediaries and browsers.
+	h.Set("Cache-Control", "public, max-age=60")
+	h.Set("ETag", c.etag(encoding))
+	h.Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+	}
+
+	if c.matches(r.Header.Get("If-None-Match")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// matches reports whether an If-None-Match header names this body. The
+// comparison is weak, as RFC 9110 requires for If-None-Match, so it ignores
+// W/ prefixes and the per-encoding suffix.
+func (c *cachedResponse) matches(ifNoneMatch string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+		if base, _, _ := strings.Cut(tag, "-"); base == c.tag {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks the stored encoding with the highest q-value in
+// an Accept-Encoding header, or "" for identity.
+func negotiateEncoding(header string) string {
+	return negotiateAmong(header, responseEncodings)
+}
+
+// negotiateAmong picks the encoding in offered with the highest q-value in
+// an Accept-Encoding header, earlier ones winning ties, or "" for identity.
+func negotiateAmong(header string, offered []string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, param, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			weights[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range offered {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// responseCache holds encoded responses by normalized query. Each entry
+// records the version of the source data it was built from; a request
+// whose data has a different version rebuilds and replaces it, so a
+// snapshot refresh invalidates every response derived from it. Entries are
+// evicted least recently used once the byte budget is exceeded.
+type responseCache struct {
+	maxBytes int
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // of *responseEntry, most recently used first
+	entries map[string]*list.Element
+
+	builds singleflight.Group
+}
+
+type responseEntry struct {
+	key     string
+	version string
+	resp    *cachedResponse
+}
+
+func newResponseCache(maxBytes int) *responseCache {
+	return &responseCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// getOrBuild returns the response for key at version, calling build on a
+// miss. Concurrent misses for the same key and version share one build.
+func (c *responseCache) getOrBuild(key, version string, build func() (string, []byte, error)) (*cachedResponse, error) {
+	if resp := c.get(key, version); resp != nil {
+		return resp, nil
+	}
+	result, err, _ := c.builds.Do(key+"\x00"+version, func() (any, error) {
+		contentType, body, err := build()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := newCachedResponse(contentType, body)
+		if err != nil {
+			return nil, err
+		}
+		c.put(key, version, resp)
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*cachedResponse), nil
+}
+
+func (c *responseCache) get(key, version string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*responseEntry)
+	if e.version != version {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e.resp
+}
+
+func (c *responseCache) put(key, version string, resp *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	if resp.size() > c.maxBytes {
+		return // served, but would evict everything else
+	}
+	c.entries[key] = c.lru.PushFront(&responseEntry{key: key, version: version, resp: resp})
+	c.size += resp.size()
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *responseCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*responseEntry)
+	delete(c.entries, e.key)
+	c.size -= e.resp.size()
+}
+
+// responseKey identifies a query by everything that shapes its body.
+// params must already be normalized by parseQueryParams.
+func responseKey(params adapters.FetchParams, format string) string {
+	var b strings.Builder
+	b.WriteString(format)
+	b.WriteString("|")
+	b.WriteString(strings.Join(params.Types, ","))
+	b.WriteString("|")
+	if bb := params.BBox; bb != nil {
+		fmt.Fprintf(&b, "%.2f,%.2f,%.2f,%.2f", bb.MinLon, bb.MinLat, bb.MaxLon, bb.MaxLat)
+	}
+	b.WriteString("|")
+	if n := params.Near; n != nil {
+		fmt.Fprintf(&b, "%.2f,%.2f,%g", n.Lon, n.Lat, n.RadiusKm)
+	}
+	b.WriteString("|")
+	if !params.Since.IsZero() {
+		b.WriteString(params.Since.UTC().Format(time.RFC3339Nano))
+	}
+	b.WriteString("|")
+	b.WriteString(strconv.Itoa(params.Limit))
+	b.WriteString("|")
+	if m := params.MinMagnitude; m != nil {
+		b.WriteString(strconv.FormatFloat(*m, 'g', -1, 64))
+	}
+	b.WriteString("|")
+	b.WriteString(strconv.FormatBool(params.ReviewedOnly))
+	b.WriteString("|")
+	if t := params.Tsunami; t != nil {
+		b.WriteString(strconv.FormatBool(*t))
+	}
+	b.WriteString("|")
+	b.WriteString(params.Status)
+	b.WriteString("|")
+	b.WriteString(params.MinSeverity)
+	b.WriteString("|")
+	b.WriteString(params.Sort)
+	return b.String()
+}
+
+// sourcesVersion identifies the source data behind a response. Statuses
+// carry each snapshot's fetch time, so equal versions mean equal events,
+// and any refresh or change in source health yields a new version.
+func sourcesVersion(sources []models.SourceStatus) string {
+	sorted := make([]models.SourceStatus, len(sources))
+	copy(sorted, sources)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Source < sorted[j].Source })
+
+	var b strings.Builder
+	for _, s := range sorted {
+		fmt.Fprintf(&b, "%s:%t:%t:%d:%s;", s.Source, s.OK, s.Stale, s.FetchedAt.UnixNano(), s.Error)
+	}
+	return b.String()
+}
@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/cache"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/service"
+)
+
+func decodeBody(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "":
+		return body
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = gz
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("zstd: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		t.Fatalf("unexpected encoding %q", encoding)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode %s: %v", encoding, err)
+	}
+	return out
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"gzip, deflate, br, zstd", "br"},
+		{"zstd, gzip", "zstd"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", "br"},
+		{"*;q=0.1, gzip", "gzip"},
+		{"GZIP", "gzip"},
+		{"deflate", ""},
+	}
+	for _, tc := range cases {
+		if got := negotiateEncoding(tc.header); got != tc.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestCachedResponseVariantsDecodeToBody(t *testing.T) {
+	t.Parallel()
+	body := []byte(strings.Repeat(`{"type":"Feature","properties":{"id":"x"}},`, 200))
+	resp, err := newCachedResponse("application/json", body)
+	if err != nil {
+		t.Fatalf("newCachedResponse: %v", err)
+	}
+	for _, enc := range append([]string{""}, responseEncodings...) {
+		stored, ok := resp.bodies[enc]
+		if !ok {
+			t.Fatalf("no %q variant stored", enc)
+		}
+		if enc != "" && len(stored) >= len(body) {
+			t.Errorf("%s variant is %d bytes, not smaller than %d", enc, len(stored), len(body))
+		}
+		if got := decodeBody(t, enc, stored); !bytes.Equal(got, body) {
+			t.Errorf("%s variant does not decode to the body", enc)
+		}
+	}
+}
+
+func TestCachedResponseServe(t *testing.T) {
+	t.Parallel()
+	resp, err := newCachedResponse("application/geo+json", []byte(`{"type":"FeatureCollection"}`))
+	if err != nil {
+		t.Fatalf("newCachedResponse: %v", err)
+	}
+
+	serve := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+		req.Header = header
+		rec := httptest.NewRecorder()
+		resp.serve(rec, req)
+		return rec
+	}
+
+	rec := serve(http.Header{"Accept-Encoding": {"gzip"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	gzipTag := rec.Header().Get("ETag")
+
+	identity := serve(http.Header{})
+	if identity.Header().Get("Content-Encoding") != "" {
+		t.Error("identity response carries a Content-Encoding")
+	}
+	if identity.Body.String() != `{"type":"FeatureCollection"}` {
+		t.Errorf("identity body = %q", identity.Body.String())
+	}
+	if identity.Header().Get("ETag") == gzipTag {
+		t.Error("identity and gzip variants share a strong ETag")
+	}
+
+	for _, inm := range []string{gzipTag, identity.Header().Get("ETag"), "W/" + gzipTag, `"other", ` + gzipTag, "*"} {
+		rec := serve(http.Header{"Accept-Encoding": {"br"}, "If-None-Match": {inm}})
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: status = %d, want 304", inm, rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: 304 has a body", inm)
+		}
+	}
+	if rec := serve(http.Header{"If-None-Match": {`"other"`}}); rec.Code != http.StatusOK {
+		t.Errorf("non-matching If-None-Match: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestResponseCacheInvalidatedByVersion(t *testing.T) {
+	t.Parallel()
+	c := newResponseCache(1 << 20)
+	builds := 0
+	build := func(body string) func() (string, []byte, error) {
+		return func() (string, []byte, error) {
+			builds++
+			return "application/json", []byte(body), nil
+		}
+	}
+
+	first, _ := c.getOrBuild("k", "v1", build("one"))
+	again, _ := c.getOrBuild("k", "v1", build("unused"))
+	if again != first || builds != 1 {
+		t.Fatalf("same version rebuilt: builds = %d", builds)
+	}
+	second, _ := c.getOrBuild("k", "v2", build("two"))
+	if builds != 2 || string(second.bodies[""]) != "two" {
+		t.Fatalf("new version not rebuilt: builds = %d, body %q", builds, second.bodies[""])
+	}
+	if len(c.entries) != 1 {
+		t.Errorf("entries = %d, want the old version replaced", len(c.entries))
+	}
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	body := bytes.Repeat([]byte("x"), 1000)
+	one, _ := newCachedResponse("application/json", body)
+	c := newResponseCache(2*one.size() + one.size()/2)
+
+	build := func() (string, []byte, error) { return "application/json", body, nil }
+	c.getOrBuild("a", "v", build)
+	c.getOrBuild("b", "v", build)
+	c.getOrBuild("a", "v", build) // a is now the most recently used
+	c.getOrBuild("c", "v", build)
+
+	if c.get("b", "v") != nil {
+		t.Error("least recently used entry b was not evicted")
+	}
+	if c.get("a", "v") == nil || c.get("c", "v") == nil {
+		t.Error("recently used entries were evicted")
+	}
+	if c.size > c.maxBytes {
+		t.Errorf("size %d exceeds budget %d", c.size, c.maxBytes)
+	}
+}
+
+func TestResponseKeyNormalizesEquivalentQueries(t *testing.T) {
+	t.Parallel()
+	key := func(query string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events"+query, nil)
+		params, format, err := parseQueryParams(req)
+		if err != nil {
+			t.Fatalf("parseQueryParams(%q): %v", query, err)
+		}
+		return responseKey(params, format)
+	}
+
+	same := [][2]string{
+		{"?types=flood,earthquake", "?types=earthquake,flood"},
+		{"?types=flood,earthquake", "?types=earthquake,%20flood,flood"},
+		{"?bbox=-10.001,20.004,30.001,40.0", "?bbox=-10.002,20.001,30.002,39.999"},
+		{"?near=10.001,20.002&radius_km=49.95", "?near=10.004,19.998&radius_km=50"},
+		{"?since=2026-08-01", "?since=2026-08-01T00:00:00Z"},
+		{"?since=2026-08-01T02:00:00%2B02:00", "?since=2026-08-01T00:00:00Z"},
+		{"?reviewed_only=1", "?reviewed_only=true"},
+		{"?reviewed_only=false", ""},
+		{"?status=open", ""},
+		{"?sort=newest", ""},
+	}
+	for _, pair := range same {
+		if a, b := key(pair[0]), key(pair[1]); a != b {
+			t.Errorf("keys differ for %s and %s: %q vs %q", pair[0], pair[1], a, b)
+		}
+	}
+
+	different := [][2]string{
+		{"?types=flood", "?types=earthquake"},
+		{"?format=json", "?format=geojson"},
+		{"?limit=10", "?limit=20"},
+		{"?bbox=0,0,10,10", "?bbox=0,0,10,11"},
+		{"?since=2026-08-01", "?since=2026-08-02"},
+		{"?min_magnitude=4", "?min_magnitude=4.5"},
+		{"?min_magnitude=0", ""},
+		{"?reviewed_only=true", ""},
+		{"?tsunami=true", "?tsunami=false"},
+		{"?tsunami=false", ""},
+		{"?status=closed", ""},
+		{"?status=all", "?status=closed"},
+		{"?min_severity=severe", ""},
+		{"?min_severity=severe", "?min_severity=extreme"},
+		{"?sort=severity", ""},
+	}
+	for _, pair := range different {
+		if a, b := key(pair[0]), key(pair[1]); a == b {
+			t.Errorf("keys equal for %s and %s: %q", pair[0], pair[1], a)
+		}
+	}
+}
+
+func TestGetEventsServesPrecompressedAndRevalidates(t *testing.T) {
+	t.Parallel()
+	f := &fakeAdapter{source: "alpha", events: makeEvents(50, "alpha")}
+	h := newTestHandler(t, f)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events?format=json", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	rec := httptest.NewRecorder()
+	h.GetEvents(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("Content-Encoding = %q, want br", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if body := decodeBody(t, "br", rec.Body.Bytes()); !bytes.Contains(body, []byte(`"alpha-0"`)) {
+		t.Errorf("decoded body lacks events: %.200s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/events?format=json", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	h.GetEvents(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("revalidation status = %d, want 304", rec.Code)
+	}
+}
+
+// swappableStore is a single-source snapshot store that a test refreshes
+// by hand, so a new snapshot version needs no TTL to pass.
+type swappableStore struct {
+	mu   sync.Mutex
+	snap *service.Snapshot
+}
+
+func (s *swappableStore) GetOrLoad(ctx context.Context, key string, load func() (service.Snapshot, error)) (service.Snapshot, cache.Freshness, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snap == nil {
+		snap, err := load()
+		if err != nil {
+			return service.Snapshot{}, 0, err
+		}
+		s.snap = &snap
+	}
+	return *s.snap, cache.Fresh, nil
+}
+
+func (s *swappableStore) Set(key string, snap service.Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snap = &snap
+}
+
+func (s *swappableStore) TryLock(key string) (func(), bool) {
+	return func() {}, true
+}
+
+// refresh replaces the snapshot with the same events fetched later.
+func (s *swappableStore) refresh(after time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := *s.snap
+	next.FetchedAt = next.FetchedAt.Add(after)
+	s.snap = &next
+}
+
+func TestGetEventsETagChangesWithSnapshot(t *testing.T) {
+	t.Parallel()
+	f := &fakeAdapter{source: "alpha", events: makeEvents(3, "alpha")}
+	store := &swappableStore{}
+	h := NewEventsHandler(service.NewEventsService([]adapters.Adapter{f}, store, 5*time.Second))
+
+	etag := func() string {
+		rec := doGet(t, h, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		return rec.Header().Get("ETag")
+	}
+
+	rec := doGet(t, h, "")
+	if fc := decodeFeatureCollection(t, rec); len(fc.Sources) != 1 || fc.Sources[0].FetchedAt.IsZero() {
+		t.Fatalf("sources = %+v, want alpha with its fetch time", fc.Sources)
+	}
+	first := rec.Header().Get("ETag")
+	if etag() != first {
+		t.Fatal("ETag changed without a snapshot change")
+	}
+	// A refreshed snapshot reports a new fetch time, even with the same
+	// events, and that alone must yield a new body.
+	store.refresh(time.Minute)
+	if etag() == first {
+		t.Error("ETag unchanged after the snapshot was refreshed")
+	}
+}
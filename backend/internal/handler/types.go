@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// typesBody is the taxonomy response. The taxonomy is fixed at build
+// time, so it is encoded once.
+var typesBody = func() []byte {
+	body, err := json.Marshal(struct {
+		Types []models.EventTypeInfo `json:"types"`
+	}{models.Taxonomy})
+	if err != nil {
+		panic(err)
+	}
+	return body
+}()
+
+// GetTypes serves the event-type taxonomy — every type with its label,
+// parent and aliases — so clients need not hard-code it.
+func GetTypes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// Changes only with a deploy.
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	w.Write(typesBody)
+}
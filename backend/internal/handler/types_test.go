@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+func TestGetTypesServesTaxonomy(t *testing.T) {
+	t.Parallel()
+	rec := httptest.NewRecorder()
+	GetTypes(rec, httptest.NewRequest(http.MethodGet, "/api/v1/types", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body struct {
+		Types []models.EventTypeInfo `json:"types"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if len(body.Types) != len(models.EventTypes) {
+		t.Fatalf("served %d types, want %d", len(body.Types), len(models.EventTypes))
+	}
+	for _, info := range body.Types {
+		if info.Type == "hurricane" && (info.Parent != "cyclone" || info.Label != "Hurricane") {
+			t.Errorf("hurricane = %+v, want label Hurricane under cyclone", info)
+		}
+		if info.Type == "cyclone" && !slices.Contains(info.Aliases, "typhoon") {
+			t.Errorf("cyclone aliases = %v, want typhoon among them", info.Aliases)
+		}
+	}
+}
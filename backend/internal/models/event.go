@@ -6,31 +6,150 @@ import (
 )
 
 type Event struct {
-	ID          string         `json:"id"`
-	Title       string         `json:"title"`
-	Description string         `json:"description,omitempty"`
-	EventType   string         `json:"event_type"`
-	Source      string         `json:"source"`
-	Geometry    Geometry       `json:"geometry"`
-	Magnitude   *float64       `json:"magnitude,omitempty"`
-	Severity    string         `json:"severity,omitempty"`
-	StartedAt   time.Time      `json:"started_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	URL         string         `json:"url,omitempty"`
-	Metadata    map[string]any `json:"metadata,omitempty"`
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	EventType   string    `json:"event_type"`
+	Source      string    `json:"source"`
+	Geometry    Geometry  `json:"geometry"`
+	Magnitude   *float64  `json:"magnitude,omitempty"`
+	Severity    string    `json:"severity,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// EndedAt is set once the source reports the event over. A scheduled
+	// end, such as an alert's expiry, counts only once it has passed, so an
+	// event is either still going or closed, whatever the time it is read.
+	EndedAt  time.Time      `json:"ended_at,omitzero"`
+	URL      string         `json:"url,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
+// Closed reports whether the event has ended.
+func (e Event) Closed() bool {
+	return !e.EndedAt.IsZero()
+}
+
+// Metadata keys that more than one source sets and that filters read.
+const (
+	// MetaReviewStatus is an earthquake solution's review status:
+	// "automatic" until a seismologist has reviewed it, then "reviewed".
+	MetaReviewStatus = "review_status"
+	// MetaTsunami is true when the source flags the event as one that may
+	// have generated a tsunami.
+	MetaTsunami = "tsunami"
+)
+
+// Geometry is an event's location. Type and Coordinates are its anchor
+// point, which filtering, the flat JSON format and the map's markers use.
+// Shapes optionally adds the event's extent — a storm's track and cone,
+// say — in which case the geometry is encoded as a GeoJSON
+// GeometryCollection whose first member is the anchor.
 type Geometry struct {
-	Type        string    `json:"type"`
-	Coordinates []float64 `json:"coordinates"`
+	Type        string
+	Coordinates []float64
+	Shapes      []Shape
+}
+
+// GlobalGeometry is the Type of an event that affects the whole planet
+// rather than a place, such as a geomagnetic storm. Such a geometry has no
+// coordinates, so the event encodes as "geometry": null like an unlocated
+// one, but carries a "global": true property, and area filters always
+// match it: a worldwide event is inside every bbox.
+const GlobalGeometry = "Global"
+
+// IsGlobal reports whether g is a GlobalGeometry.
+func (g Geometry) IsGlobal() bool {
+	return g.Type == GlobalGeometry
+}
+
+// Shape is a non-point geometry. LineString and MultiPoint use Positions;
+// Polygon uses Rings, outer ring first.
+type Shape struct {
+	Type      string
+	Positions [][]float64
+	Rings     [][][]float64
+}
+
+type geoJSONGeometry struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates,omitempty"`
+	Geometries  []geoJSONGeometry `json:"geometries,omitempty"`
+}
+
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	if len(g.Shapes) == 0 {
+		return json.Marshal(struct {
+			Type        string    `json:"type"`
+			Coordinates []float64 `json:"coordinates"`
+		}{g.Type, g.Coordinates})
+	}
+	members := make([]any, 0, len(g.Shapes)+1)
+	if len(g.Coordinates) >= 2 {
+		members = append(members, map[string]any{"type": g.Type, "coordinates": g.Coordinates})
+	}
+	for _, s := range g.Shapes {
+		members = append(members, s)
+	}
+	return json.Marshal(map[string]any{"type": "GeometryCollection", "geometries": members})
+}
+
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var raw geoJSONGeometry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*g = Geometry{}
+	if raw.Type != "GeometryCollection" {
+		g.Type = raw.Type
+		if len(raw.Coordinates) > 0 && string(raw.Coordinates) != "null" {
+			return json.Unmarshal(raw.Coordinates, &g.Coordinates)
+		}
+		return nil
+	}
+	for i, m := range raw.Geometries {
+		if i == 0 && m.Type == "Point" {
+			g.Type = m.Type
+			if err := json.Unmarshal(m.Coordinates, &g.Coordinates); err != nil {
+				return err
+			}
+			continue
+		}
+		var s Shape
+		if err := s.unmarshal(m); err != nil {
+			return err
+		}
+		g.Shapes = append(g.Shapes, s)
+	}
+	return nil
+}
+
+func (s Shape) MarshalJSON() ([]byte, error) {
+	var coords any = s.Positions
+	if s.Type == "Polygon" {
+		coords = s.Rings
+	}
+	return json.Marshal(map[string]any{"type": s.Type, "coordinates": coords})
+}
+
+func (s *Shape) unmarshal(raw geoJSONGeometry) error {
+	s.Type = raw.Type
+	if s.Type == "Polygon" {
+		return json.Unmarshal(raw.Coordinates, &s.Rings)
+	}
+	return json.Unmarshal(raw.Coordinates, &s.Positions)
 }
 
 // SourceStatus reports the outcome of one upstream source for a request,
 // so clients can distinguish "no disasters" from "a source was down".
+// Stale means the source's data outlived its cache TTL and is being
+// refreshed; it and FetchedAt, the time of the upstream fetch the events
+// came from, are set only alongside OK.
 type SourceStatus struct {
-	Source string `json:"source"`
-	OK     bool   `json:"ok"`
-	Error  string `json:"error,omitempty"`
+	Source    string    `json:"source"`
+	OK        bool      `json:"ok"`
+	Stale     bool      `json:"stale,omitempty"`
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+	Error     string    `json:"error,omitempty"`
 }
 
 // GeoJSON types
@@ -67,10 +186,13 @@ type FlatEvent struct {
 	EventType   string         `json:"event_type"`
 	Source      string         `json:"source"`
 	Coordinates *Coordinates   `json:"coordinates"`
+	Global      bool           `json:"global,omitempty"`
 	Magnitude   *float64       `json:"magnitude,omitempty"`
 	Severity    string         `json:"severity,omitempty"`
+	Impact      *int           `json:"impact,omitempty"`
 	StartedAt   time.Time      `json:"started_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+	EndedAt     time.Time      `json:"ended_at,omitzero"`
 	URL         string         `json:"url,omitempty"`
 	Metadata    map[string]any `json:"metadata,omitempty"`
 }
@@ -98,9 +220,18 @@ func (e Event) ToGeoJSONFeature() Feature {
 	if e.Severity != "" {
 		props["severity"] = e.Severity
 	}
+	if impact, ok := e.Impact(); ok {
+		props["impact"] = impact
+	}
+	if e.Closed() {
+		props["ended_at"] = e.EndedAt.Format(time.RFC3339)
+	}
 	if e.URL != "" {
 		props["url"] = e.URL
 	}
+	if e.Geometry.IsGlobal() {
+		props["global"] = true
+	}
 	if len(e.Metadata) > 0 {
 		props["metadata"] = e.Metadata
 	}
@@ -129,6 +260,11 @@ func (e Event) ToFlatEvent() FlatEvent {
 		}
 	}
 
+	var impact *int
+	if score, ok := e.Impact(); ok {
+		impact = &score
+	}
+
 	return FlatEvent{
 		ID:          e.ID,
 		Title:       e.Title,
@@ -136,10 +272,13 @@ func (e Event) ToFlatEvent() FlatEvent {
 		EventType:   e.EventType,
 		Source:      e.Source,
 		Coordinates: coords,
+		Global:      e.Geometry.IsGlobal(),
 		Magnitude:   e.Magnitude,
 		Severity:    e.Severity,
+		Impact:      impact,
 		StartedAt:   e.StartedAt,
 		UpdatedAt:   e.UpdatedAt,
+		EndedAt:     e.EndedAt,
 		URL:         e.URL,
 		Metadata:    e.Metadata,
 	}
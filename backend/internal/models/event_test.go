@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"slices"
 	"strings"
 	"testing"
@@ -54,6 +55,7 @@ func TestToGeoJSONFeatureFull(t *testing.T) {
 		"description": "A strong quake.",
 		"magnitude":   6.2,
 		"severity":    "severe",
+		"impact":      59,
 		"url":         "https://example.org/abc",
 	}
 	for k, v := range want {
@@ -82,7 +84,7 @@ func TestToGeoJSONFeatureOmissions(t *testing.T) {
 	if f.Geometry != nil {
 		t.Errorf("Geometry = %v, want nil for event without coordinates", f.Geometry)
 	}
-	for _, k := range []string{"description", "magnitude", "severity", "url", "metadata"} {
+	for _, k := range []string{"description", "magnitude", "severity", "impact", "url", "metadata"} {
 		if _, present := f.Properties[k]; present {
 			t.Errorf("Properties[%q] present, want omitted for empty value", k)
 		}
@@ -233,3 +235,108 @@ func TestMarshalEventsJSONWireFormat(t *testing.T) {
 		t.Errorf("output lacks \"total\":1: %s", data)
 	}
 }
+
+func TestGeometryWithShapesRoundTrips(t *testing.T) {
+	t.Parallel()
+	g := Geometry{
+		Type:        "Point",
+		Coordinates: []float64{-66.2, 24.1},
+		Shapes: []Shape{
+			{Type: "LineString", Positions: [][]float64{{-60, 15}, {-66.2, 24.1}}},
+			{Type: "MultiPoint", Positions: [][]float64{{-67, 27}}},
+			{Type: "Polygon", Rings: [][][]float64{{{-66, 24}, {-70, 30}, {-64, 30}, {-66, 24}}}},
+		},
+	}
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"geometries":[{"coordinates":[-66.2,24.1],"type":"Point"},` +
+		`{"coordinates":[[-60,15],[-66.2,24.1]],"type":"LineString"},` +
+		`{"coordinates":[[-67,27]],"type":"MultiPoint"},` +
+		`{"coordinates":[[[-66,24],[-70,30],[-64,30],[-66,24]]],"type":"Polygon"}],"type":"GeometryCollection"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s\nwant      %s", data, want)
+	}
+
+	var back Geometry
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if back.Type != "Point" || !slices.Equal(back.Coordinates, g.Coordinates) {
+		t.Errorf("anchor = %s %v, want Point %v", back.Type, back.Coordinates, g.Coordinates)
+	}
+	if len(back.Shapes) != 3 || back.Shapes[2].Type != "Polygon" || len(back.Shapes[2].Rings[0]) != 4 {
+		t.Errorf("shapes = %+v", back.Shapes)
+	}
+}
+
+func TestGeometryWithoutShapesStaysPoint(t *testing.T) {
+	t.Parallel()
+	data, err := json.Marshal(Geometry{Type: "Point", Coordinates: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"type":"Point","coordinates":[1,2]}` {
+		t.Errorf("Marshal = %s", data)
+	}
+	var back Geometry
+	if err := json.Unmarshal(data, &back); err != nil || back.Type != "Point" || len(back.Shapes) != 0 {
+		t.Errorf("Unmarshal = %+v, %v", back, err)
+	}
+}
+
+func TestGlobalGeometry(t *testing.T) {
+	t.Parallel()
+	e := fullEvent()
+	e.Geometry = Geometry{Type: GlobalGeometry}
+
+	f := e.ToGeoJSONFeature()
+	if f.Geometry != nil || f.Properties["global"] != true {
+		t.Errorf("feature geometry = %v, global = %v; want null and true", f.Geometry, f.Properties["global"])
+	}
+	flat := e.ToFlatEvent()
+	if flat.Coordinates != nil || !flat.Global {
+		t.Errorf("flat coordinates = %v, global = %v", flat.Coordinates, flat.Global)
+	}
+	if _, ok := fullEvent().ToGeoJSONFeature().Properties["global"]; ok {
+		t.Error("located event has a global property")
+	}
+
+	// Cached snapshots round-trip the event, global marker included.
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var back Event
+	if err := json.Unmarshal(data, &back); err != nil || !back.Geometry.IsGlobal() {
+		t.Errorf("round trip = %+v, %v", back.Geometry, err)
+	}
+}
+
+func TestClosedEvent(t *testing.T) {
+	t.Parallel()
+	if fullEvent().Closed() {
+		t.Error("event without an end is closed")
+	}
+	if _, ok := fullEvent().ToGeoJSONFeature().Properties["ended_at"]; ok {
+		t.Error("open event has an ended_at property")
+	}
+
+	e := fullEvent()
+	e.EndedAt = testTime.Add(2 * time.Hour)
+	if !e.Closed() {
+		t.Error("event with an end is open")
+	}
+	if got := e.ToGeoJSONFeature().Properties["ended_at"]; got != e.EndedAt.Format(time.RFC3339) {
+		t.Errorf("ended_at = %v", got)
+	}
+	data, err := json.Marshal(e.ToFlatEvent())
+	if err != nil || !strings.Contains(string(data), `"ended_at":"`) {
+		t.Errorf("flat = %s, %v", data, err)
+	}
+	data, err = json.Marshal(fullEvent().ToFlatEvent())
+	if err != nil || strings.Contains(string(data), "ended_at") {
+		t.Errorf("open flat = %s, %v", data, err)
+	}
+}
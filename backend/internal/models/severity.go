@@ -0,0 +1,111 @@
+package models
+
+import (
+	"math"
+	"slices"
+)
+
+// Severity levels, least to most severe. Every source maps its own scale
+// onto these four, CAP's names; an empty Severity means the source gives
+// none. Scales with fewer steps skip a level rather than invent one:
+// GDACS's Green, Orange and Red are minor, severe and extreme.
+const (
+	SeverityMinor    = "minor"
+	SeverityModerate = "moderate"
+	SeveritySevere   = "severe"
+	SeverityExtreme  = "extreme"
+)
+
+// Severities lists the severity levels in ascending order.
+var Severities = []string{SeverityMinor, SeverityModerate, SeveritySevere, SeverityExtreme}
+
+// SeverityRank orders severities: 1 for minor up to 4 for extreme, and 0
+// for an empty or unknown severity, which ranks below all of them.
+func SeverityRank(s string) int {
+	return slices.Index(Severities, s) + 1
+}
+
+// IsValidSeverity reports whether s is one of the severity levels.
+func IsValidSeverity(s string) bool {
+	return SeverityRank(s) > 0
+}
+
+// impactBand is the width of each severity's share of the impact score.
+const impactBand = 25
+
+// Impact scores the event from 0 to 100 so that events can be compared
+// across sources, whose severities are coarse and whose magnitudes are in
+// different units. Severity picks a band of 25 points (minor 0–25,
+// moderate 25–50, severe 50–75, extreme 75–100) and the source's own
+// measure places the event within it, by impactPosition's rules. Without
+// a severity the measure alone places the event on the whole 0–100 range.
+// Without either there is no score, and ok is false.
+func (e Event) Impact() (score int, ok bool) {
+	pos, measured := impactPosition(e)
+	rank := SeverityRank(e.Severity)
+	switch {
+	case rank > 0:
+		if !measured {
+			pos = 0.5
+		}
+		return int(math.Round(float64(rank-1)*impactBand + pos*impactBand)), true
+	case measured:
+		return int(math.Round(pos * 100)), true
+	default:
+		return 0, false
+	}
+}
+
+// impactPosition is where the event's own measure puts it, from 0 to 1,
+// by source:
+//
+//   - usgs, emsc: magnitude, from M4.5 to M9.
+//   - nhc: maximum sustained wind, from 34 kt (tropical storm force) to
+//     140 kt.
+//   - swpc: planetary K-index, from Kp 5 (storm level) to Kp 9.
+//   - firms: total fire radiative power, from 10 MW to 10,000 MW on a
+//     log scale.
+//   - gdacs: the alert score (the episode's, else the event's) within its
+//     alert level, each level one point: Green 0–1, Orange 1–2, Red 2–3.
+//
+// Other sources have no measure comparable across their events, and ok
+// is false.
+func impactPosition(e Event) (pos float64, ok bool) {
+	clamp := func(v, lo, hi float64) (float64, bool) {
+		return min(max((v-lo)/(hi-lo), 0), 1), true
+	}
+	switch e.Source {
+	case "usgs", "emsc":
+		if e.Magnitude != nil {
+			return clamp(*e.Magnitude, 4.5, 9)
+		}
+	case "nhc":
+		if e.Magnitude != nil {
+			return clamp(*e.Magnitude, 34, 140)
+		}
+	case "swpc":
+		if e.Magnitude != nil {
+			return clamp(*e.Magnitude, 5, 9)
+		}
+	case "firms":
+		if e.Magnitude != nil && *e.Magnitude > 0 {
+			return clamp(math.Log10(*e.Magnitude), 1, 4)
+		}
+	case "gdacs":
+		score, found := e.Metadata["episode_alert_score"].(float64)
+		if !found {
+			score, found = e.Metadata["alert_score"].(float64)
+		}
+		var floor float64
+		switch e.Severity {
+		case SeveritySevere:
+			floor = 1
+		case SeverityExtreme:
+			floor = 2
+		}
+		if found {
+			return clamp(score, floor, floor+1)
+		}
+	}
+	return 0, false
+}
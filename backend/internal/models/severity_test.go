@@ -0,0 +1,54 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSeverityRank(t *testing.T) {
+	t.Parallel()
+	var ranks []int
+	for _, s := range Severities {
+		ranks = append(ranks, SeverityRank(s))
+	}
+	if want := []int{1, 2, 3, 4}; !slices.Equal(ranks, want) {
+		t.Errorf("ranks = %v, want %v", ranks, want)
+	}
+	for _, s := range []string{"", "Severe", "unknown"} {
+		if SeverityRank(s) != 0 || IsValidSeverity(s) {
+			t.Errorf("%q ranks %d, want 0 and invalid", s, SeverityRank(s))
+		}
+	}
+}
+
+func TestImpact(t *testing.T) {
+	t.Parallel()
+	mag := func(m float64) *float64 { return &m }
+	cases := []struct {
+		name   string
+		e      Event
+		want   int
+		wantOK bool
+	}{
+		{"quake without PAGER alert, by magnitude alone", Event{Source: "usgs", Magnitude: mag(6.3)}, 40, true},
+		{"small quake floors at 0", Event{Source: "emsc", Magnitude: mag(2.1)}, 0, true},
+		{"PAGER red great quake", Event{Source: "usgs", Severity: "extreme", Magnitude: mag(9.1)}, 100, true},
+		{"PAGER green M6.3", Event{Source: "usgs", Severity: "minor", Magnitude: mag(6.3)}, 10, true},
+		{"category 5 hurricane", Event{Source: "nhc", Severity: "extreme", Magnitude: mag(140)}, 100, true},
+		{"tropical storm", Event{Source: "nhc", Severity: "moderate", Magnitude: mag(50)}, 29, true},
+		{"Kp 7 storm", Event{Source: "swpc", Severity: "severe", Magnitude: mag(7)}, 63, true},
+		{"100 MW fire", Event{Source: "firms", Magnitude: mag(100)}, 33, true},
+		{"GDACS orange with score 1.5", Event{Source: "gdacs", Severity: "severe", Metadata: map[string]any{"alert_score": 1.5}}, 63, true},
+		{"GDACS episode score wins", Event{Source: "gdacs", Severity: "extreme", Metadata: map[string]any{"alert_score": 2.0, "episode_alert_score": 3.0}}, 100, true},
+		{"GDACS green without score", Event{Source: "gdacs", Severity: "minor"}, 13, true},
+		{"severity alone is mid-band", Event{Source: "noaa", Severity: "severe"}, 63, true},
+		{"magnitude of an unscored source", Event{Source: "eonet", Magnitude: mag(65)}, 0, false},
+		{"nothing to score", Event{Source: "noaa"}, 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := tc.e.Impact()
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("%s: Impact() = %d, %v, want %d, %v", tc.name, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -1,38 +1,92 @@
 package models
 
-// EventTypes is the canonical list of event types the API can emit.
-// Adapters map upstream categories into these. Two are fallbacks that are
+// EventTypeInfo describes an event type: the label clients display, its
+// parent in the taxonomy, and other names a query may use for it.
+type EventTypeInfo struct {
+	Type    string   `json:"type"`
+	Label   string   `json:"label"`
+	Parent  string   `json:"parent,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Taxonomy is the tree of event types the API can emit. Adapters map
+// upstream categories into these and always emit the most specific type
+// they can; a query for a type also matches its descendants, so
+// types=storm includes hurricanes. Two types are fallbacks that are
 // nonetheless real, requestable types: "weather" covers NWS alerts with no
 // more specific class, and "other" covers upstream categories no adapter
 // maps yet.
-var EventTypes = []string{
-	"earthquake",
-	"wildfire",
-	"volcano",
-	"storm",
-	"flood",
-	"cyclone",
-	"tornado",
-	"hurricane",
-	"winter_storm",
-	"tsunami",
-	"drought",
-	"iceberg",
-	"landslide",
-	"weather",
-	"other",
+//
+// Aliases name the same type in another region or register, such as
+// typhoon for a cyclone; they are accepted in queries but never emitted.
+var Taxonomy = []EventTypeInfo{
+	{Type: "earthquake", Label: "Earthquake", Aliases: []string{"quake"}},
+	{Type: "wildfire", Label: "Wildfire", Aliases: []string{"fire", "bushfire"}},
+	{Type: "volcano", Label: "Volcano"},
+	{Type: "storm", Label: "Storm", Aliases: []string{"thunderstorm"}},
+	{Type: "flood", Label: "Flood"},
+	{Type: "cyclone", Label: "Cyclone", Parent: "storm", Aliases: []string{"typhoon", "tropical_cyclone"}},
+	{Type: "tornado", Label: "Tornado", Parent: "storm"},
+	{Type: "hurricane", Label: "Hurricane", Parent: "cyclone"},
+	{Type: "winter_storm", Label: "Winter Storm", Parent: "storm", Aliases: []string{"blizzard", "snowstorm"}},
+	{Type: "tsunami", Label: "Tsunami"},
+	{Type: "drought", Label: "Drought"},
+	{Type: "iceberg", Label: "Iceberg"},
+	{Type: "landslide", Label: "Landslide", Aliases: []string{"mudslide"}},
+	{Type: "geomagnetic_storm", Label: "Geomagnetic Storm"},
+	{Type: "solar_radiation", Label: "Solar Radiation"},
+	{Type: "weather", Label: "Weather"},
+	{Type: "other", Label: "Other"},
 }
 
-var validEventTypes = func() map[string]struct{} {
-	m := make(map[string]struct{}, len(EventTypes))
-	for _, t := range EventTypes {
-		m[t] = struct{}{}
+// EventTypes is the canonical list of event types, in Taxonomy order.
+var EventTypes = func() []string {
+	types := make([]string, len(Taxonomy))
+	for i, info := range Taxonomy {
+		types[i] = info.Type
 	}
-	return m
+	return types
 }()
 
-// IsValidEventType reports whether t is a known event type.
+var (
+	validEventTypes = make(map[string]struct{}, len(Taxonomy))
+	eventTypeNames  = make(map[string]string) // type or alias -> type
+	eventTypeKids   = make(map[string][]string)
+)
+
+func init() {
+	for _, info := range Taxonomy {
+		validEventTypes[info.Type] = struct{}{}
+		eventTypeNames[info.Type] = info.Type
+		for _, alias := range info.Aliases {
+			eventTypeNames[alias] = info.Type
+		}
+		if info.Parent != "" {
+			eventTypeKids[info.Parent] = append(eventTypeKids[info.Parent], info.Type)
+		}
+	}
+}
+
+// IsValidEventType reports whether t is a known event type. Aliases are
+// not event types: adapters and their configuration must name the type.
 func IsValidEventType(t string) bool {
 	_, ok := validEventTypes[t]
 	return ok
 }
+
+// ResolveEventType returns the event type name stands for, either the
+// type itself or one of its aliases, and false if it is neither.
+func ResolveEventType(name string) (string, bool) {
+	t, ok := eventTypeNames[name]
+	return t, ok
+}
+
+// EventTypeSubtree returns t followed by all its descendants, parents
+// before their children.
+func EventTypeSubtree(t string) []string {
+	types := []string{t}
+	for i := 0; i < len(types); i++ {
+		types = append(types, eventTypeKids[types[i]]...)
+	}
+	return types
+}
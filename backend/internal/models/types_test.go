@@ -1,6 +1,9 @@
 package models
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestIsValidEventTypeAcceptsAllCanonicalTypes(t *testing.T) {
 	t.Parallel()
@@ -41,3 +44,89 @@ func TestEventTypesIncludesFallbacks(t *testing.T) {
 		}
 	}
 }
+
+func TestTaxonomyIsATree(t *testing.T) {
+	t.Parallel()
+	for _, info := range Taxonomy {
+		if info.Label == "" {
+			t.Errorf("%s has no label", info.Type)
+		}
+		// Walking up from every type must reach a root without revisiting
+		// a type, or EventTypeSubtree would never end.
+		seen := map[string]bool{info.Type: true}
+		for p := info.Parent; p != ""; {
+			if !IsValidEventType(p) {
+				t.Errorf("%s has unknown ancestor %q", info.Type, p)
+				break
+			}
+			if seen[p] {
+				t.Errorf("%s is in a parent cycle through %q", info.Type, p)
+				break
+			}
+			seen[p] = true
+			p = parentOf(p)
+		}
+	}
+}
+
+func parentOf(typ string) string {
+	for _, info := range Taxonomy {
+		if info.Type == typ {
+			return info.Parent
+		}
+	}
+	return ""
+}
+
+func TestAliasesAreUnambiguous(t *testing.T) {
+	t.Parallel()
+	seen := make(map[string]string)
+	for _, info := range Taxonomy {
+		for _, alias := range info.Aliases {
+			if IsValidEventType(alias) {
+				t.Errorf("alias %q of %s shadows an event type", alias, info.Type)
+			}
+			if other, dup := seen[alias]; dup {
+				t.Errorf("alias %q names both %s and %s", alias, other, info.Type)
+			}
+			seen[alias] = info.Type
+		}
+	}
+}
+
+func TestResolveEventType(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name, want string
+		ok         bool
+	}{
+		{"cyclone", "cyclone", true},
+		{"typhoon", "cyclone", true},
+		{"quake", "earthquake", true},
+		{"blizzard", "winter_storm", true},
+		{"Typhoon", "", false},
+		{"sharknado", "", false},
+		{"", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := ResolveEventType(tc.name)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ResolveEventType(%q) = %q, %t; want %q, %t", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestEventTypeSubtree(t *testing.T) {
+	t.Parallel()
+	cases := map[string][]string{
+		"storm":      {"storm", "cyclone", "tornado", "winter_storm", "hurricane"},
+		"cyclone":    {"cyclone", "hurricane"},
+		"hurricane":  {"hurricane"},
+		"earthquake": {"earthquake"},
+	}
+	for typ, want := range cases {
+		if got := EventTypeSubtree(typ); !slices.Equal(got, want) {
+			t.Errorf("EventTypeSubtree(%q) = %v, want %v", typ, got, want)
+		}
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"math"
 	"sort"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 
@@ -21,63 +22,157 @@ import (
 var ErrAllSourcesFailed = errors.New("all upstream sources failed")
 
 // StreamBatch is one per-source delivery on the streaming path. Either
-// Events or Err is set, never both.
+// Events or Err is set, never both. Stale marks events served from an
+// expired cache entry while it is being refreshed; FetchedAt is when the
+// events were fetched upstream.
 type StreamBatch struct {
-	Source string
-	Events []models.Event
-	Err    error
+	Source    string
+	Events    []models.Event
+	Stale     bool
+	FetchedAt time.Time
+	Err       error
 }
 
+// Snapshot is what the service caches per source: one upstream fetch
+// covering every type the adapter emits.
+type Snapshot struct {
+	Events    []models.Event `json:"events"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+// indexIdleTTL is how long an index may go unused before it is dropped.
+// Snapshot indexes are replaced on refresh anyway; this mostly reclaims
+// historical windows nobody asks for any more.
+const indexIdleTTL = time.Hour
+
 type EventsService struct {
 	adapters    []adapters.Adapter
-	sourceCache *cache.Cache[[]models.Event]
-	sfGroup     singleflight.Group
+	sourceCache cache.Store[Snapshot]
 	timeout     time.Duration
+
+	indexMu     sync.Mutex
+	indexes     map[string]indexEntry
+	indexBuilds singleflight.Group
+
+	streamBackoffMin time.Duration
+	streamBackoffMax time.Duration
+	streamFlushEvery time.Duration
+	streamsMu        sync.Mutex
+	streams          map[string]*sourceStream
+
+	subMu      sync.Mutex
+	subs       map[*subscriber]struct{}
+	subsClosed bool
+}
+
+// indexEntry is the index built for one cache key. FetchedAt identifies
+// the snapshot it was built from: a snapshot read back from the cache with
+// the same FetchedAt is the same data, even as a fresh copy.
+type indexEntry struct {
+	fetchedAt time.Time
+	index     *eventIndex
+	lastUsed  time.Time
 }
 
 func NewEventsService(
 	adapterList []adapters.Adapter,
-	c *cache.Cache[[]models.Event],
+	c cache.Store[Snapshot],
 	timeout time.Duration,
 ) *EventsService {
 	return &EventsService{
 		adapters:    adapterList,
 		sourceCache: c,
 		timeout:     timeout,
+		indexes:     make(map[string]indexEntry),
+
+		streamBackoffMin: streamBackoffMin,
+		streamBackoffMax: streamBackoffMax,
+		streamFlushEvery: streamFlushEvery,
+		streams:          make(map[string]*sourceStream),
+		subs:             make(map[*subscriber]struct{}),
 	}
 }
 
-// fetchAdapter returns cached events for an adapter or fetches from upstream.
-// Uses singleflight to deduplicate concurrent requests for the same data.
-// The upstream fetch uses a detached context so that cancellation of one
-// request doesn't kill a shared in-flight call that other requests need.
-func (s *EventsService) fetchAdapter(a adapters.Adapter, params adapters.FetchParams) ([]models.Event, error) {
-	key := adapterCacheKey(a.Source(), params.Types, params.Since)
-
-	if cached, ok := s.sourceCache.Get(key); ok {
-		return cached, nil
+// fetchAdapter returns the index a request should query for an adapter:
+// that of its canonical snapshot, or for a Since older than a Windowed
+// adapter's snapshot reaches, of a historical fetch from that day on, above
+// the whole magnitude below any MinMagnitude if the adapter can filter
+// upstream. Either way the upstream call covers every type, and types,
+// Since, BBox, Near, Limit and the attribute filters are applied locally,
+// so requests differing only in those share one fetch.
+//
+// The cache deduplicates concurrent loads of the same key and serves stale
+// entries while a single background refresh runs; stale reports that the
+// events came from such an entry. The upstream fetch uses a detached context
+// so that cancellation of one request doesn't kill a shared in-flight call
+// that other requests need; ctx only bounds how long this request waits.
+func (s *EventsService) fetchAdapter(ctx context.Context, a adapters.Adapter, params adapters.FetchParams) (*eventIndex, bool, error) {
+	key := snapshotKey(a.Source())
+	load := s.snapshotLoader(a)
+	if w, ok := a.(adapters.Windowed); ok && !params.Since.IsZero() &&
+		params.Since.Before(time.Now().Add(-w.Window())) {
+		// Truncated to the day so that historical keys stay bounded; the
+		// exact Since is still applied locally.
+		day := params.Since.UTC().Truncate(24 * time.Hour)
+		upstreamParams := adapters.FetchParams{Since: day}
+		if _, ok := a.(adapters.MinMagnitudeFilterer); ok && params.MinMagnitude != nil {
+			floor := math.Floor(*params.MinMagnitude)
+			upstreamParams.MinMagnitude = &floor
+		}
+		key = historyKey(a.Source(), day, upstreamParams.MinMagnitude)
+		load = s.loader(a, upstreamParams)
 	}
 
-	upstreamParams := adapters.FetchParams{
-		Types: params.Types,
-		Since: params.Since,
+	snap, freshness, err := s.sourceCache.GetOrLoad(ctx, key, load)
+	if err != nil {
+		return nil, false, err
 	}
+	return s.indexFor(key, snap), freshness == cache.Stale, nil
+}
 
-	result, err, _ := s.sfGroup.Do(key, func() (any, error) {
+// loader returns the cache load function fetching a's snapshot.
+func (s *EventsService) loader(a adapters.Adapter, params adapters.FetchParams) func() (Snapshot, error) {
+	return func() (Snapshot, error) {
 		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 		defer cancel()
-		events, err := a.FetchEvents(ctx, upstreamParams)
+		events, err := a.FetchEvents(ctx, params)
 		if err != nil {
-			return nil, err
+			return Snapshot{}, err
 		}
-		s.sourceCache.Set(key, events)
-		return events, nil
-	})
-	if err != nil {
-		return nil, err
+		return Snapshot{Events: events, FetchedAt: time.Now()}, nil
 	}
+}
 
-	return result.([]models.Event), nil
+// indexFor returns the index of snap, building it only when the snapshot
+// under key has changed since the last call.
+func (s *EventsService) indexFor(key string, snap Snapshot) *eventIndex {
+	now := time.Now()
+	s.indexMu.Lock()
+	if e, ok := s.indexes[key]; ok && e.fetchedAt.Equal(snap.FetchedAt) {
+		e.lastUsed = now
+		s.indexes[key] = e
+		s.indexMu.Unlock()
+		return e.index
+	}
+	s.indexMu.Unlock()
+
+	// Concurrent requests for a just-refreshed snapshot share one build.
+	buildKey := key + "@" + snap.FetchedAt.Format(time.RFC3339Nano)
+	result, _, _ := s.indexBuilds.Do(buildKey, func() (any, error) {
+		ix := newEventIndex(snap.Events)
+		ix.fetchedAt = snap.FetchedAt
+
+		s.indexMu.Lock()
+		defer s.indexMu.Unlock()
+		s.indexes[key] = indexEntry{fetchedAt: snap.FetchedAt, index: ix, lastUsed: now}
+		for k, e := range s.indexes {
+			if now.Sub(e.lastUsed) > indexIdleTTL {
+				delete(s.indexes, k)
+			}
+		}
+		return ix, nil
+	})
+	return result.(*eventIndex)
 }
 
 func (s *EventsService) GetEvents(ctx context.Context, params adapters.FetchParams) ([]models.Event, []models.SourceStatus, error) {
@@ -87,15 +182,21 @@ func (s *EventsService) GetEvents(ctx context.Context, params adapters.FetchPara
 	}
 
 	var mu sync.Mutex
-	var allEvents []models.Event
+	// Indexed by adapter, not appended on completion, so that ties between
+	// sources merge in a stable order.
+	batches := make([][]models.Event, len(relevant))
 	statuses := make([]models.SourceStatus, 0, len(relevant))
 	var wg sync.WaitGroup
 
-	for _, a := range relevant {
+	for i, a := range relevant {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			events, err := s.fetchAdapter(a, params)
+			ix, stale, err := s.fetchAdapter(ctx, a, params)
+			var events []models.Event
+			if err == nil {
+				events = ix.query(params)
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -111,10 +212,12 @@ func (s *EventsService) GetEvents(ctx context.Context, params adapters.FetchPara
 				return
 			}
 			statuses = append(statuses, models.SourceStatus{
-				Source: a.Source(),
-				OK:     true,
+				Source:    a.Source(),
+				OK:        true,
+				Stale:     stale,
+				FetchedAt: ix.fetchedAt,
 			})
-			allEvents = append(allEvents, events...)
+			batches[i] = events
 		}()
 	}
 
@@ -132,6 +235,12 @@ func (s *EventsService) GetEvents(ctx context.Context, params adapters.FetchPara
 		return nil, nil, ctx.Err()
 	}
 
+	// Completion order is arbitrary; a fixed order keeps identical results
+	// byte-identical, which the response cache's ETags rely on.
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Source < statuses[j].Source
+	})
+
 	failed := 0
 	for _, st := range statuses {
 		if !st.OK {
@@ -142,19 +251,14 @@ func (s *EventsService) GetEvents(ctx context.Context, params adapters.FetchPara
 		return nil, statuses, ErrAllSourcesFailed
 	}
 
-	allEvents = filterEvents(allEvents, params)
-	sortEventsByDate(allEvents)
-
-	if params.Limit > 0 && len(allEvents) > params.Limit {
-		allEvents = allEvents[:params.Limit]
-	}
-
-	return allEvents, statuses, nil
+	// Each batch is already filtered, sorted and within Limit, so merging
+	// their heads yields the global result without a full sort.
+	return mergeOrdered(batches, params.Limit, eventOrder(params.Sort)), statuses, nil
 }
 
 // StreamEvents delivers one batch per source as each upstream fetch
 // completes, preserving the progressive-loading UX that SSE exists for.
-// Batches are date-sorted internally; a global sort across sources would
+// Batches are sorted internally; a global sort across sources would
 // require waiting for every adapter, defeating the streaming. Limit is
 // enforced across the whole stream: once the cap is reached, later batches
 // are trimmed or dropped.
@@ -172,7 +276,7 @@ func (s *EventsService) StreamEvents(ctx context.Context, params adapters.FetchP
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			events, err := s.fetchAdapter(a, params)
+			ix, stale, err := s.fetchAdapter(ctx, a, params)
 			if err != nil {
 				slog.Warn("adapter stream failed",
 					"source", a.Source(),
@@ -184,8 +288,7 @@ func (s *EventsService) StreamEvents(ctx context.Context, params adapters.FetchP
 				}
 				return
 			}
-			filtered := filterEvents(events, params)
-			sortEventsByDate(filtered)
+			filtered := ix.query(params)
 
 			if params.Limit > 0 {
 				limitMu.Lock()
@@ -201,7 +304,7 @@ func (s *EventsService) StreamEvents(ctx context.Context, params adapters.FetchP
 			// Sent even when empty so the consumer can report the source
 			// as reachable.
 			select {
-			case ch <- StreamBatch{Source: a.Source(), Events: filtered}:
+			case ch <- StreamBatch{Source: a.Source(), Events: filtered, Stale: stale, FetchedAt: ix.fetchedAt}:
 			case <-ctx.Done():
 			}
 		}()
@@ -220,66 +323,17 @@ func (s *EventsService) selectAdapters(types []string) []adapters.Adapter {
 	return result
 }
 
-func filterEvents(events []models.Event, params adapters.FetchParams) []models.Event {
-	filtered := make([]models.Event, 0, len(events))
-
-	typeSet := make(map[string]struct{})
-	for _, t := range params.Types {
-		typeSet[t] = struct{}{}
-	}
-
-	for _, e := range events {
-		if len(typeSet) > 0 {
-			if _, ok := typeSet[e.EventType]; !ok {
-				continue
-			}
-		}
-
-		if !params.Since.IsZero() && e.StartedAt.Before(params.Since) {
-			continue
-		}
-
-		if params.BBox != nil {
-			// Events without coordinates cannot be inside any bounding box.
-			// They used to bypass this filter, so every bbox query returned
-			// all unlocated events worldwide.
-			if len(e.Geometry.Coordinates) < 2 {
-				continue
-			}
-			lon, lat := e.Geometry.Coordinates[0], e.Geometry.Coordinates[1]
-			if lon < params.BBox.MinLon || lon > params.BBox.MaxLon ||
-				lat < params.BBox.MinLat || lat > params.BBox.MaxLat {
-				continue
-			}
-		}
-
-		filtered = append(filtered, e)
-	}
-
-	return filtered
-}
-
-func sortEventsByDate(events []models.Event) {
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].StartedAt.After(events[j].StartedAt)
-	})
+// snapshotKey is the cache key of a source's canonical snapshot.
+func snapshotKey(source string) string {
+	return "snapshot:" + source
 }
 
-// adapterCacheKey builds a cache key from source identity and the parameters
-// that actually change upstream results. BBox and Limit are excluded because
-// adapters either don't support them or we want the full dataset for local filtering.
-func adapterCacheKey(source string, types []string, since time.Time) string {
-	sorted := make([]string, len(types))
-	copy(sorted, types)
-	sort.Strings(sorted)
-
-	var b strings.Builder
-	b.WriteString(source)
-	b.WriteByte(':')
-	b.WriteString(strings.Join(sorted, ","))
-	if !since.IsZero() {
-		b.WriteByte(':')
-		b.WriteString(since.Format(time.RFC3339))
+// historyKey is the cache key of a source's historical window starting on
+// day, above minMag if it was fetched with a magnitude floor.
+func historyKey(source string, day time.Time, minMag *float64) string {
+	key := "history:" + source + ":" + day.Format("2006-01-02")
+	if minMag != nil {
+		key += ":m" + strconv.FormatFloat(*minMag, 'f', -1, 64)
 	}
-	return b.String()
+	return key
 }
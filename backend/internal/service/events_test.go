@@ -31,11 +31,12 @@ func (f *fakeAdapter) FetchEvents(ctx context.Context, params adapters.FetchPara
 	f.mu.Lock()
 	f.calls++
 	f.got = append(f.got, params)
+	gate := f.gate
 	f.mu.Unlock()
 
-	if f.gate != nil {
+	if gate != nil {
 		select {
-		case <-f.gate:
+		case <-gate:
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		}
@@ -68,7 +69,7 @@ func (f *fakeAdapter) lastParams(t *testing.T) adapters.FetchParams {
 
 func newTestService(t *testing.T, adps ...adapters.Adapter) *EventsService {
 	t.Helper()
-	c := cache.New[[]models.Event](time.Minute)
+	c := cache.New[Snapshot](time.Minute)
 	t.Cleanup(c.Close)
 	return NewEventsService(adps, c, 5*time.Second)
 }
@@ -269,6 +270,43 @@ func TestGetEventsCacheHitSkipsUpstream(t *testing.T) {
 	}
 }
 
+func TestGetEventsReportsStaleSource(t *testing.T) {
+	t.Parallel()
+	gate := make(chan struct{})
+	a := &fakeAdapter{source: "alpha", types: []string{"earthquake"}, events: []models.Event{
+		evt("a-1", "earthquake", baseTime, 1, 1),
+	}}
+	c := cache.NewWithStale[Snapshot](20*time.Millisecond, time.Minute)
+	t.Cleanup(c.Close)
+	s := NewEventsService([]adapters.Adapter{a}, c, 5*time.Second)
+
+	if _, statuses, err := s.GetEvents(context.Background(), adapters.FetchParams{}); err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	} else if statusBySource(t, statuses, "alpha").Stale {
+		t.Error("first fetch reported stale")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// Hold the background refresh so the stale entry is still what the
+	// request sees.
+	a.mu.Lock()
+	a.gate = gate
+	a.mu.Unlock()
+	defer close(gate)
+
+	events, statuses, err := s.GetEvents(context.Background(), adapters.FetchParams{})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("got %d events, want the stale one", len(events))
+	}
+	st := statusBySource(t, statuses, "alpha")
+	if !st.OK || !st.Stale {
+		t.Errorf("status = %+v, want OK and stale", st)
+	}
+}
+
 func TestGetEventsSingleflightDedup(t *testing.T) {
 	t.Parallel()
 	gate := make(chan struct{})
@@ -317,19 +355,20 @@ func TestGetEventsSingleflightDedup(t *testing.T) {
 	}
 }
 
-func TestGetEventsStripsBBoxAndLimitFromUpstream(t *testing.T) {
+func TestGetEventsFetchesCanonicalSnapshot(t *testing.T) {
 	t.Parallel()
-	a := &fakeAdapter{source: "alpha", types: []string{"earthquake"}, events: []models.Event{
+	a := &fakeAdapter{source: "alpha", types: []string{"earthquake", "flood"}, events: []models.Event{
 		evt("in", "earthquake", baseTime, 10, 10),
 		evt("out", "earthquake", baseTime.Add(time.Minute), 50, 50),
+		evt("old", "earthquake", baseTime.Add(-48*time.Hour), 10, 10),
+		evt("flood", "flood", baseTime, 10, 10),
 	}}
 	s := newTestService(t, a)
 
-	since := baseTime.Add(-24 * time.Hour)
 	params := adapters.FetchParams{
 		Types: []string{"earthquake"},
 		BBox:  &adapters.BBox{MinLon: 0, MinLat: 0, MaxLon: 20, MaxLat: 20},
-		Since: since,
+		Since: baseTime.Add(-24 * time.Hour),
 		Limit: 5,
 	}
 	events, _, err := s.GetEvents(context.Background(), params)
@@ -337,22 +376,154 @@ func TestGetEventsStripsBBoxAndLimitFromUpstream(t *testing.T) {
 		t.Fatalf("GetEvents: %v", err)
 	}
 
+	// The adapter has no window, so nothing about the request reaches it.
 	got := a.lastParams(t)
-	if got.BBox != nil {
-		t.Errorf("upstream received BBox %+v, want nil (applied service-side)", got.BBox)
+	if got.BBox != nil || got.Limit != 0 || got.Types != nil || !got.Since.IsZero() {
+		t.Errorf("upstream received %+v, want empty params (filtered locally)", got)
 	}
-	if got.Limit != 0 {
-		t.Errorf("upstream received Limit %d, want 0 (applied service-side)", got.Limit)
+	if len(events) != 1 || events[0].ID != "in" {
+		t.Errorf("events = %v, want only [in] after local filtering", ids(events))
 	}
-	if len(got.Types) != 1 || got.Types[0] != "earthquake" {
-		t.Errorf("upstream received Types %v, want [earthquake]", got.Types)
+}
+
+func TestGetEventsTypeSetsShareOneSnapshot(t *testing.T) {
+	t.Parallel()
+	a := &fakeAdapter{source: "alpha", types: []string{"earthquake", "flood"}, events: []models.Event{
+		evt("quake", "earthquake", baseTime, 1, 1),
+		evt("flood", "flood", baseTime, 1, 1),
+	}}
+	s := newTestService(t, a)
+
+	requests := []struct {
+		params adapters.FetchParams
+		want   int
+	}{
+		{adapters.FetchParams{Types: []string{"earthquake"}}, 1},
+		{adapters.FetchParams{Types: []string{"earthquake", "flood"}}, 2},
+		{adapters.FetchParams{Since: baseTime.Add(-time.Hour)}, 2},
+		{adapters.FetchParams{Since: baseTime.Add(time.Hour)}, 0},
 	}
-	if !got.Since.Equal(since) {
-		t.Errorf("upstream received Since %v, want %v", got.Since, since)
+	for _, r := range requests {
+		events, _, err := s.GetEvents(context.Background(), r.params)
+		if err != nil {
+			t.Fatalf("GetEvents(%+v): %v", r.params, err)
+		}
+		if len(events) != r.want {
+			t.Errorf("GetEvents(%+v) = %v, want %d events", r.params, ids(events), r.want)
+		}
 	}
-	// The bbox is still enforced on the merged result.
-	if len(events) != 1 || events[0].ID != "in" {
-		t.Errorf("events = %v, want only [in] after bbox filtering", ids(events))
+	if got := a.callCount(); got != 1 {
+		t.Errorf("upstream called %d times, want 1 (one snapshot for every type set and since)", got)
+	}
+}
+
+// windowedAdapter is a fakeAdapter whose snapshot only reaches back window.
+type windowedAdapter struct {
+	*fakeAdapter
+	window time.Duration
+}
+
+func (w *windowedAdapter) Window() time.Duration { return w.window }
+
+func TestGetEventsHistoricalWindowBeyondSnapshot(t *testing.T) {
+	t.Parallel()
+	now := time.Now().UTC()
+	a := &windowedAdapter{
+		fakeAdapter: &fakeAdapter{source: "alpha", types: []string{"earthquake"}, events: []models.Event{
+			evt("recent", "earthquake", now.Add(-time.Hour), 1, 1),
+			evt("ancient", "earthquake", now.Add(-40*24*time.Hour), 1, 1),
+		}},
+		window: 7 * 24 * time.Hour,
+	}
+	s := newTestService(t, a)
+
+	// Within the window: served from the snapshot, no upstream Since.
+	if _, _, err := s.GetEvents(context.Background(), adapters.FetchParams{Since: now.Add(-24 * time.Hour)}); err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if got := a.lastParams(t).Since; !got.IsZero() {
+		t.Errorf("upstream Since = %v, want zero for a Since inside the window", got)
+	}
+
+	// Beyond it: a historical fetch from the start of that day.
+	since := now.Add(-30 * 24 * time.Hour)
+	events, _, err := s.GetEvents(context.Background(), adapters.FetchParams{Since: since})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	wantDay := since.Truncate(24 * time.Hour)
+	if got := a.lastParams(t).Since; !got.Equal(wantDay) {
+		t.Errorf("upstream Since = %v, want day start %v", got, wantDay)
+	}
+	if len(events) != 1 || events[0].ID != "recent" {
+		t.Errorf("events = %v, want [recent] (exact Since applied locally)", ids(events))
+	}
+
+	// A later Since on the same day reuses the historical window.
+	if _, _, err := s.GetEvents(context.Background(), adapters.FetchParams{Since: since.Add(time.Minute)}); err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if got := a.callCount(); got != 2 {
+		t.Errorf("upstream called %d times, want 2 (snapshot + one historical window)", got)
+	}
+}
+
+// magnitudeAdapter is a windowedAdapter that filters by magnitude upstream.
+type magnitudeAdapter struct {
+	*windowedAdapter
+}
+
+func (m *magnitudeAdapter) FiltersMinMagnitude() {}
+
+func TestGetEventsHistoricalMagnitudeFloor(t *testing.T) {
+	t.Parallel()
+	now := time.Now().UTC()
+	big, small := evt("big", "earthquake", now.Add(-20*24*time.Hour), 1, 1), evt("small", "earthquake", now.Add(-20*24*time.Hour), 1, 1)
+	m1, m2 := 5.7, 4.2
+	big.Magnitude, small.Magnitude = &m1, &m2
+	a := &magnitudeAdapter{&windowedAdapter{
+		fakeAdapter: &fakeAdapter{source: "alpha", types: []string{"earthquake"}, events: []models.Event{big, small}},
+		window:      7 * 24 * time.Hour,
+	}}
+	s := newTestService(t, a)
+	since := now.Add(-30 * 24 * time.Hour)
+
+	floor := 5.5
+	events, _, err := s.GetEvents(context.Background(), adapters.FetchParams{Since: since, MinMagnitude: &floor})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if got := a.lastParams(t).MinMagnitude; got == nil || *got != 5 {
+		t.Errorf("upstream MinMagnitude = %v, want the whole-number floor 5", got)
+	}
+	if len(events) != 1 || events[0].ID != "big" {
+		t.Errorf("events = %v, want [big] (exact floor applied locally)", ids(events))
+	}
+
+	// A floor in the same whole magnitude shares the fetch; none at all
+	// needs its own.
+	floor = 5.1
+	if _, _, err := s.GetEvents(context.Background(), adapters.FetchParams{Since: since, MinMagnitude: &floor}); err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if got := a.callCount(); got != 1 {
+		t.Errorf("upstream called %d times, want 1", got)
+	}
+	events, _, err = s.GetEvents(context.Background(), adapters.FetchParams{Since: since})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if a.lastParams(t).MinMagnitude != nil || len(events) != 2 {
+		t.Errorf("unfloored fetch: upstream MinMagnitude = %v, events = %v", a.lastParams(t).MinMagnitude, ids(events))
+	}
+
+	// Adapters that can't filter upstream get no floor.
+	plain := &windowedAdapter{fakeAdapter: &fakeAdapter{source: "beta", types: []string{"earthquake"}}, window: 7 * 24 * time.Hour}
+	if _, _, err := newTestService(t, plain).GetEvents(context.Background(), adapters.FetchParams{Since: since, MinMagnitude: &floor}); err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if got := plain.lastParams(t).MinMagnitude; got != nil {
+		t.Errorf("upstream MinMagnitude = %v for an adapter without upstream filtering", *got)
 	}
 }
 
@@ -392,125 +563,25 @@ func TestSelectAdaptersHonorsSupportedTypes(t *testing.T) {
 	}
 }
 
-func TestFilterEvents(t *testing.T) {
+func TestCacheKeys(t *testing.T) {
 	t.Parallel()
-	events := []models.Event{
-		evt("quake-in", "earthquake", baseTime, 10, 10),
-		evt("quake-edge", "earthquake", baseTime, 0, 0),
-		evt("quake-out", "earthquake", baseTime, 50, 50),
-		evt("quake-unlocated", "earthquake", baseTime),
-		evt("flood-old", "flood", baseTime.Add(-48*time.Hour), 10, 10),
-		evt("flood-new", "flood", baseTime.Add(time.Hour), 12, 12),
-	}
-	box := &adapters.BBox{MinLon: 0, MinLat: 0, MaxLon: 20, MaxLat: 20}
+	day := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
 
-	cases := []struct {
-		name   string
-		params adapters.FetchParams
-		want   []string
-	}{
-		{
-			name:   "no filters keep everything",
-			params: adapters.FetchParams{},
-			want:   []string{"quake-in", "quake-edge", "quake-out", "quake-unlocated", "flood-old", "flood-new"},
-		},
-		{
-			name:   "type filter",
-			params: adapters.FetchParams{Types: []string{"flood"}},
-			want:   []string{"flood-old", "flood-new"},
-		},
-		{
-			name:   "since filter drops older events",
-			params: adapters.FetchParams{Since: baseTime.Add(-time.Hour)},
-			want:   []string{"quake-in", "quake-edge", "quake-out", "quake-unlocated", "flood-new"},
-		},
-		{
-			name:   "bbox excludes out-of-box and unlocated, keeps boundary",
-			params: adapters.FetchParams{BBox: box},
-			want:   []string{"quake-in", "quake-edge", "flood-old", "flood-new"},
-		},
-		{
-			name: "combined filters",
-			params: adapters.FetchParams{
-				Types: []string{"earthquake"},
-				Since: baseTime.Add(-time.Hour),
-				BBox:  box,
-			},
-			want: []string{"quake-in", "quake-edge"},
-		},
+	if snapshotKey("usgs") == snapshotKey("noaa") {
+		t.Error("snapshot keys equal for different sources")
 	}
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			got := ids(filterEvents(events, tc.params))
-			if len(got) != len(tc.want) {
-				t.Fatalf("got %v, want %v", got, tc.want)
-			}
-			for i := range tc.want {
-				if got[i] != tc.want[i] {
-					t.Fatalf("got %v, want %v", got, tc.want)
-				}
-			}
-		})
+	if historyKey("usgs", day, nil) == historyKey("usgs", day.AddDate(0, 0, 1), nil) {
+		t.Error("history keys equal for different days")
 	}
-}
-
-func TestSortEventsByDate(t *testing.T) {
-	t.Parallel()
-	events := []models.Event{
-		evt("old", "earthquake", baseTime.Add(-time.Hour)),
-		evt("newest", "earthquake", baseTime.Add(time.Hour)),
-		evt("mid", "earthquake", baseTime),
+	floor := 5.0
+	if historyKey("usgs", day, nil) == historyKey("usgs", day, &floor) {
+		t.Error("history keys equal with and without a magnitude floor")
 	}
-	sortEventsByDate(events)
-	got := ids(events)
-	want := []string{"newest", "mid", "old"}
-	for i := range want {
-		if got[i] != want[i] {
-			t.Fatalf("order = %v, want %v", got, want)
-		}
+	if historyKey("usgs", day, nil) == snapshotKey("usgs") {
+		t.Error("history key collides with the snapshot key")
 	}
 }
 
-func TestAdapterCacheKey(t *testing.T) {
-	t.Parallel()
-	since := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
-
-	t.Run("type order insensitive", func(t *testing.T) {
-		k1 := adapterCacheKey("usgs", []string{"flood", "earthquake"}, since)
-		k2 := adapterCacheKey("usgs", []string{"earthquake", "flood"}, since)
-		if k1 != k2 {
-			t.Errorf("keys differ for same type set: %q vs %q", k1, k2)
-		}
-	})
-
-	t.Run("input slice not mutated", func(t *testing.T) {
-		types := []string{"flood", "earthquake"}
-		adapterCacheKey("usgs", types, since)
-		if types[0] != "flood" || types[1] != "earthquake" {
-			t.Errorf("input slice mutated: %v", types)
-		}
-	})
-
-	t.Run("since changes the key", func(t *testing.T) {
-		k1 := adapterCacheKey("usgs", nil, since)
-		k2 := adapterCacheKey("usgs", nil, since.Add(time.Hour))
-		k3 := adapterCacheKey("usgs", nil, time.Time{})
-		if k1 == k2 {
-			t.Error("keys equal for different since values")
-		}
-		if k1 == k3 {
-			t.Error("keys equal for set vs zero since")
-		}
-	})
-
-	t.Run("source changes the key", func(t *testing.T) {
-		if adapterCacheKey("usgs", nil, time.Time{}) == adapterCacheKey("noaa", nil, time.Time{}) {
-			t.Error("keys equal for different sources")
-		}
-	})
-}
-
 func collectBatches(t *testing.T, s *EventsService, ctx context.Context, params adapters.FetchParams) []StreamBatch {
 	t.Helper()
 	ch := make(chan StreamBatch, 8)
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// gridCellDeg is the edge length of a spatial grid cell in degrees. One
+// degree keeps a viewport-sized bbox to a few hundred cells while a dense
+// cluster (a fire complex, an aftershock sequence) still spreads over
+// several of them.
+const gridCellDeg = 1.0
+
+const (
+	gridCols = int(360 / gridCellDeg)
+	gridRows = int(180 / gridCellDeg)
+)
+
+const earthRadiusKm = 6371.0
+
+type gridCell struct {
+	col, row int
+}
+
+// eventIndex answers filter queries over one snapshot without scanning it.
+// Events are stored newest first, so a position doubles as a time rank:
+// Since becomes a binary-searched cutoff and Limit an early stop. The grid
+// and type lists hold ascending positions, which a k-way merge walks in
+// time order, stopping once Limit matches are found.
+//
+// An index is immutable once built; a changed snapshot gets a new one.
+type eventIndex struct {
+	// fetchedAt is the FetchedAt of the snapshot the index was built from.
+	fetchedAt time.Time
+
+	events []models.Event
+	cells  map[gridCell][]int32
+	byType map[string][]int32
+	// global lists the events with a models.GlobalGeometry, which every
+	// area query includes.
+	global []int32
+}
+
+func newEventIndex(events []models.Event) *eventIndex {
+	sorted := make([]models.Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt.After(sorted[j].StartedAt)
+	})
+
+	ix := &eventIndex{
+		events: sorted,
+		cells:  make(map[gridCell][]int32),
+		byType: make(map[string][]int32),
+	}
+	for i, e := range sorted {
+		pos := int32(i)
+		ix.byType[e.EventType] = append(ix.byType[e.EventType], pos)
+		if lon, lat, ok := eventPoint(e); ok {
+			c := cellOf(lon, lat)
+			ix.cells[c] = append(ix.cells[c], pos)
+		} else if e.Geometry.IsGlobal() {
+			ix.global = append(ix.global, pos)
+		}
+	}
+	return ix
+}
+
+// query returns the events matching every filter in params, in Sort order
+// and at most Limit of them (0 means all).
+func (ix *eventIndex) query(params adapters.FetchParams) []models.Event {
+	if params.Sort == adapters.SortSeverity {
+		// The index is in time order, so Limit can't stop the walk early:
+		// every match is sorted, then cut.
+		unlimited := params
+		unlimited.Limit, unlimited.Sort = 0, ""
+		out := ix.query(unlimited)
+		slices.SortStableFunc(out, mostSevereFirst)
+		if params.Limit > 0 && len(out) > params.Limit {
+			out = out[:params.Limit]
+		}
+		return out
+	}
+
+	end := len(ix.events)
+	if !params.Since.IsZero() {
+		end = sort.Search(len(ix.events), func(i int) bool {
+			return ix.events[i].StartedAt.Before(params.Since)
+		})
+	}
+
+	var typeSet map[string]struct{}
+	if len(params.Types) > 0 {
+		typeSet = make(map[string]struct{}, len(params.Types))
+		for _, t := range params.Types {
+			typeSet[t] = struct{}{}
+		}
+	}
+
+	accept := func(e models.Event) bool {
+		if typeSet != nil {
+			if _, ok := typeSet[e.EventType]; !ok {
+				return false
+			}
+		}
+		return matchesAttributes(e, params) && matchesArea(e, params)
+	}
+
+	var lists [][]int32
+	switch {
+	case params.BBox != nil || params.Near != nil:
+		lists = ix.cellLists(params)
+	case typeSet != nil:
+		for t := range typeSet {
+			if l := ix.byType[t]; len(l) > 0 {
+				lists = append(lists, l)
+			}
+		}
+	default:
+		return ix.scan(end, params.Limit, accept)
+	}
+
+	out := make([]models.Event, 0, min(max(params.Limit, 0), end))
+	mergeAscending(lists, end, func(pos int32) bool {
+		e := ix.events[pos]
+		if !accept(e) {
+			return true
+		}
+		out = append(out, e)
+		return params.Limit <= 0 || len(out) < params.Limit
+	})
+	return out
+}
+
+func (ix *eventIndex) scan(end, limit int, accept func(models.Event) bool) []models.Event {
+	out := make([]models.Event, 0, min(max(limit, 0), end))
+	for _, e := range ix.events[:end] {
+		if !accept(e) {
+			continue
+		}
+		out = append(out, e)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// cellLists returns the position lists of every grid cell the query area
+// touches, and of the global events. Cells only bound the area;
+// matchesArea does the exact test.
+func (ix *eventIndex) cellLists(params adapters.FetchParams) [][]int32 {
+	var lists [][]int32
+	if len(ix.global) > 0 {
+		lists = append(lists, ix.global)
+	}
+	minCol, minRow, maxCol, maxRow := 0, 0, gridCols-1, gridRows-1
+	if b := params.BBox; b != nil {
+		lo, hi := cellOf(b.MinLon, b.MinLat), cellOf(b.MaxLon, b.MaxLat)
+		minCol, minRow, maxCol, maxRow = lo.col, lo.row, hi.col, hi.row
+	}
+	// A radius query narrows rows directly; its columns are only narrowed
+	// away from the poles and the antimeridian, where a lon range would wrap.
+	if c := params.Near; c != nil {
+		dLat := c.RadiusKm / earthRadiusKm * 180 / math.Pi
+		lo, hi := cellOf(c.Lon, c.Lat-dLat), cellOf(c.Lon, c.Lat+dLat)
+		minRow, maxRow = max(minRow, lo.row), min(maxRow, hi.row)
+		if maxLat := math.Abs(c.Lat) + dLat; maxLat < 89 {
+			dLon := dLat / math.Cos(maxLat*math.Pi/180)
+			if c.Lon-dLon >= -180 && c.Lon+dLon <= 180 {
+				loC, hiC := cellOf(c.Lon-dLon, c.Lat), cellOf(c.Lon+dLon, c.Lat)
+				minCol, maxCol = max(minCol, loC.col), min(maxCol, hiC.col)
+			}
+		}
+	}
+	if minCol > maxCol || minRow > maxRow {
+		return lists
+	}
+
+	if area := (maxCol - minCol + 1) * (maxRow - minRow + 1); area > len(ix.cells) {
+		// Fewer populated cells than cells in the area: walk those instead.
+		for c, l := range ix.cells {
+			if c.col >= minCol && c.col <= maxCol && c.row >= minRow && c.row <= maxRow {
+				lists = append(lists, l)
+			}
+		}
+		return lists
+	}
+	for col := minCol; col <= maxCol; col++ {
+		for row := minRow; row <= maxRow; row++ {
+			if l := ix.cells[gridCell{col, row}]; len(l) > 0 {
+				lists = append(lists, l)
+			}
+		}
+	}
+	return lists
+}
+
+// matchesArea applies the exact BBox and Near tests. Events without
+// coordinates cannot be inside any area, unless they are global and so
+// inside all of them.
+func matchesArea(e models.Event, params adapters.FetchParams) bool {
+	if params.BBox == nil && params.Near == nil || e.Geometry.IsGlobal() {
+		return true
+	}
+	lon, lat, ok := eventPoint(e)
+	if !ok {
+		return false
+	}
+	if b := params.BBox; b != nil {
+		if lon < b.MinLon || lon > b.MaxLon || lat < b.MinLat || lat > b.MaxLat {
+			return false
+		}
+	}
+	if c := params.Near; c != nil {
+		if haversineKm(c.Lon, c.Lat, lon, lat) > c.RadiusKm {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAttributes applies the MinMagnitude, MinSeverity, ReviewedOnly,
+// Tsunami and Status tests, which no index narrows.
+func matchesAttributes(e models.Event, params adapters.FetchParams) bool {
+	switch params.Status {
+	case "", adapters.StatusOpen:
+		if e.Closed() {
+			return false
+		}
+	case adapters.StatusClosed:
+		if !e.Closed() {
+			return false
+		}
+	}
+	if m := params.MinMagnitude; m != nil && (e.Magnitude == nil || *e.Magnitude < *m) {
+		return false
+	}
+	if params.MinSeverity != "" && models.SeverityRank(e.Severity) < models.SeverityRank(params.MinSeverity) {
+		return false
+	}
+	if params.ReviewedOnly && e.Metadata[models.MetaReviewStatus] == "automatic" {
+		return false
+	}
+	if t := params.Tsunami; t != nil {
+		flagged, _ := e.Metadata[models.MetaTsunami].(bool)
+		if flagged != *t {
+			return false
+		}
+	}
+	return true
+}
+
+func eventPoint(e models.Event) (float64, float64, bool) {
+	if len(e.Geometry.Coordinates) < 2 {
+		return 0, 0, false
+	}
+	return e.Geometry.Coordinates[0], e.Geometry.Coordinates[1], true
+}
+
+func cellOf(lon, lat float64) gridCell {
+	col := int(math.Floor((lon + 180) / gridCellDeg))
+	row := int(math.Floor((lat + 90) / gridCellDeg))
+	return gridCell{
+		col: min(max(col, 0), gridCols-1),
+		row: min(max(row, 0), gridRows-1),
+	}
+}
+
+func haversineKm(lon1, lat1, lon2, lat2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// mergeAscending visits the positions below end from every ascending list
+// in ascending order, until visit returns false.
+func mergeAscending(lists [][]int32, end int, visit func(int32) bool) {
+	h := make(positionHeap, 0, len(lists))
+	for _, l := range lists {
+		if len(l) > 0 && int(l[0]) < end {
+			h = append(h, l)
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		pos := h[0][0]
+		if int(pos) >= end || !visit(pos) {
+			return
+		}
+		if rest := h[0][1:]; len(rest) > 0 {
+			h[0] = rest
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+}
+
+// positionHeap orders non-empty position lists by their head.
+type positionHeap [][]int32
+
+func (h positionHeap) Len() int           { return len(h) }
+func (h positionHeap) Less(i, j int) bool { return h[i][0] < h[j][0] }
+func (h positionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *positionHeap) Push(x any)        { *h = append(*h, x.([]int32)) }
+func (h *positionHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// mergeOrdered merges per-source results, each already in cmp order, into
+// one slice in that order of at most limit events (0 means all). Ties go
+// to the earlier batch.
+func mergeOrdered(batches [][]models.Event, limit int, cmp func(a, b models.Event) int) []models.Event {
+	total := 0
+	for _, b := range batches {
+		total += len(b)
+	}
+	if limit > 0 && total > limit {
+		total = limit
+	}
+	out := make([]models.Event, 0, total)
+	heads := make([]int, len(batches))
+	for len(out) < total {
+		best := -1
+		for i, b := range batches {
+			if heads[i] >= len(b) {
+				continue
+			}
+			if best < 0 || cmp(b[heads[i]], batches[best][heads[best]]) < 0 {
+				best = i
+			}
+		}
+		out = append(out, batches[best][heads[best]])
+		heads[best]++
+	}
+	return out
+}
+
+// eventOrder returns the comparison results are sorted by for sort.
+func eventOrder(sort string) func(a, b models.Event) int {
+	if sort == adapters.SortSeverity {
+		return mostSevereFirst
+	}
+	return newestFirst
+}
+
+func newestFirst(a, b models.Event) int {
+	return b.StartedAt.Compare(a.StartedAt)
+}
+
+// mostSevereFirst orders by severity, then impact, then time, most
+// severe and newest first; events without a severity or an impact score
+// come after those with one.
+func mostSevereFirst(a, b models.Event) int {
+	if c := models.SeverityRank(b.Severity) - models.SeverityRank(a.Severity); c != 0 {
+		return c
+	}
+	if c := impactOrNone(b) - impactOrNone(a); c != 0 {
+		return c
+	}
+	return newestFirst(a, b)
+}
+
+// impactOrNone is e's impact score, or -1 without one, below any score.
+func impactOrNone(e models.Event) int {
+	if score, ok := e.Impact(); ok {
+		return score
+	}
+	return -1
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+func TestEventIndexQuery(t *testing.T) {
+	t.Parallel()
+	events := []models.Event{
+		evt("quake-in", "earthquake", baseTime, 10, 10),
+		evt("quake-edge", "earthquake", baseTime, 0, 0),
+		evt("quake-out", "earthquake", baseTime, 50, 50),
+		evt("quake-unlocated", "earthquake", baseTime),
+		evt("flood-old", "flood", baseTime.Add(-48*time.Hour), 10, 10),
+		evt("flood-new", "flood", baseTime.Add(time.Hour), 12, 12),
+	}
+	ix := newEventIndex(events)
+	box := &adapters.BBox{MinLon: 0, MinLat: 0, MaxLon: 20, MaxLat: 20}
+
+	cases := []struct {
+		name   string
+		params adapters.FetchParams
+		want   []string
+	}{
+		{
+			name:   "no filters keep everything, newest first",
+			params: adapters.FetchParams{},
+			want:   []string{"flood-new", "quake-in", "quake-edge", "quake-out", "quake-unlocated", "flood-old"},
+		},
+		{
+			name:   "type filter",
+			params: adapters.FetchParams{Types: []string{"flood"}},
+			want:   []string{"flood-new", "flood-old"},
+		},
+		{
+			name:   "unknown type matches nothing",
+			params: adapters.FetchParams{Types: []string{"volcano"}},
+			want:   []string{},
+		},
+		{
+			name:   "since filter drops older events",
+			params: adapters.FetchParams{Since: baseTime.Add(-time.Hour)},
+			want:   []string{"flood-new", "quake-in", "quake-edge", "quake-out", "quake-unlocated"},
+		},
+		{
+			name:   "bbox excludes out-of-box and unlocated, keeps boundary",
+			params: adapters.FetchParams{BBox: box},
+			want:   []string{"flood-new", "quake-in", "quake-edge", "flood-old"},
+		},
+		{
+			name: "combined filters",
+			params: adapters.FetchParams{
+				Types: []string{"earthquake"},
+				Since: baseTime.Add(-time.Hour),
+				BBox:  box,
+			},
+			want: []string{"quake-in", "quake-edge"},
+		},
+		{
+			name:   "limit keeps the newest",
+			params: adapters.FetchParams{Limit: 2},
+			want:   []string{"flood-new", "quake-in"},
+		},
+		{
+			name:   "limit applies after area filtering",
+			params: adapters.FetchParams{BBox: box, Limit: 3},
+			want:   []string{"flood-new", "quake-in", "quake-edge"},
+		},
+		{
+			// (10,10) to (12,12) is about 312 km; (0,0) is about 1570 km.
+			name:   "radius",
+			params: adapters.FetchParams{Near: &adapters.Circle{Lon: 10, Lat: 10, RadiusKm: 400}},
+			want:   []string{"flood-new", "quake-in", "flood-old"},
+		},
+		{
+			name: "radius and bbox intersect",
+			params: adapters.FetchParams{
+				BBox: &adapters.BBox{MinLon: 11, MinLat: 11, MaxLon: 20, MaxLat: 20},
+				Near: &adapters.Circle{Lon: 10, Lat: 10, RadiusKm: 400},
+			},
+			want: []string{"flood-new"},
+		},
+		{
+			name:   "whole-world bbox",
+			params: adapters.FetchParams{BBox: &adapters.BBox{MinLon: -180, MinLat: -90, MaxLon: 180, MaxLat: 90}},
+			want:   []string{"flood-new", "quake-in", "quake-edge", "quake-out", "flood-old"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ids(ix.query(tc.params)); !slices.Equal(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEventIndexRadiusAcrossAntimeridianAndPole(t *testing.T) {
+	t.Parallel()
+	ix := newEventIndex([]models.Event{
+		evt("fiji-east", "earthquake", baseTime, 179.5, -17),
+		evt("fiji-west", "earthquake", baseTime.Add(-time.Minute), -179.5, -17),
+		evt("far", "earthquake", baseTime.Add(-2*time.Minute), 170, -17),
+		evt("near-pole", "earthquake", baseTime.Add(-3*time.Minute), 90, 89.5),
+		evt("other-side-of-pole", "earthquake", baseTime.Add(-4*time.Minute), -90, 89.5),
+	})
+
+	got := ids(ix.query(adapters.FetchParams{Near: &adapters.Circle{Lon: 179.9, Lat: -17, RadiusKm: 200}}))
+	if want := []string{"fiji-east", "fiji-west"}; !slices.Equal(got, want) {
+		t.Errorf("antimeridian radius = %v, want %v", got, want)
+	}
+
+	// The two polar points are about 111 km apart, across the pole.
+	got = ids(ix.query(adapters.FetchParams{Near: &adapters.Circle{Lon: 90, Lat: 89.5, RadiusKm: 150}}))
+	if want := []string{"near-pole", "other-side-of-pole"}; !slices.Equal(got, want) {
+		t.Errorf("polar radius = %v, want %v", got, want)
+	}
+}
+
+func TestEventIndexGlobalEventsMatchEveryArea(t *testing.T) {
+	t.Parallel()
+	storm := evt("storm", "geomagnetic_storm", baseTime.Add(-time.Hour))
+	storm.Geometry = models.Geometry{Type: models.GlobalGeometry}
+	ix := newEventIndex([]models.Event{
+		evt("quake", "earthquake", baseTime, 10, 10),
+		storm,
+		evt("unlocated", "earthquake", baseTime.Add(-2*time.Hour)),
+	})
+
+	for _, params := range []adapters.FetchParams{
+		{BBox: &adapters.BBox{MinLon: 0, MinLat: 0, MaxLon: 20, MaxLat: 20}},
+		{Near: &adapters.Circle{Lon: 10, Lat: 10, RadiusKm: 50}},
+	} {
+		if got, want := ids(ix.query(params)), []string{"quake", "storm"}; !slices.Equal(got, want) {
+			t.Errorf("%+v: got %v, want %v", params, got, want)
+		}
+	}
+	empty := adapters.FetchParams{BBox: &adapters.BBox{MinLon: -100, MinLat: -50, MaxLon: -90, MaxLat: -40}}
+	if got := ids(ix.query(empty)); !slices.Equal(got, []string{"storm"}) {
+		t.Errorf("area without events = %v, want only the global one", got)
+	}
+	if !matchesParams(storm, empty) {
+		t.Error("live filter rejects the global event")
+	}
+}
+
+func TestEventIndexStableForEqualTimes(t *testing.T) {
+	t.Parallel()
+	events := []models.Event{
+		evt("old", "earthquake", baseTime.Add(-time.Hour)),
+		evt("a", "earthquake", baseTime),
+		evt("newest", "earthquake", baseTime.Add(time.Hour)),
+		evt("b", "earthquake", baseTime),
+	}
+	got := ids(newEventIndex(events).query(adapters.FetchParams{}))
+	if want := []string{"newest", "a", "b", "old"}; !slices.Equal(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+	if events[0].ID != "old" {
+		t.Error("newEventIndex reordered the caller's slice")
+	}
+}
+
+func TestEventIndexSeveritySort(t *testing.T) {
+	t.Parallel()
+	rated := func(id, severity string, hours int) models.Event {
+		e := evt(id, "flood", baseTime.Add(time.Duration(hours)*time.Hour), 10, 10)
+		e.Severity = severity
+		return e
+	}
+	ix := newEventIndex([]models.Event{
+		rated("minor", "minor", 5),
+		rated("none", "", 6),
+		rated("severe-old", "severe", 1),
+		rated("severe-new", "severe", 2),
+		rated("extreme", "extreme", 0),
+		rated("moderate", "moderate", 4),
+	})
+
+	// Limit cuts after sorting, not in time order, and equal severities
+	// stay newest first.
+	got := ids(ix.query(adapters.FetchParams{Sort: adapters.SortSeverity, Limit: 3}))
+	if want := []string{"extreme", "severe-new", "severe-old"}; !slices.Equal(got, want) {
+		t.Errorf("severity sort = %v, want %v", got, want)
+	}
+	got = ids(ix.query(adapters.FetchParams{MinSeverity: "moderate", BBox: &adapters.BBox{MinLon: 0, MinLat: 0, MaxLon: 20, MaxLat: 20}}))
+	if want := []string{"moderate", "severe-new", "severe-old", "extreme"}; !slices.Equal(got, want) {
+		t.Errorf("min_severity = %v, want %v", got, want)
+	}
+
+	// Each source's batch is sorted the same way, so merging keeps the
+	// order across sources.
+	a := []models.Event{rated("a-extreme", "extreme", 0), rated("a-minor", "minor", 9)}
+	b := []models.Event{rated("b-severe", "severe", 3), rated("b-none", "", 8)}
+	got = ids(mergeOrdered([][]models.Event{a, b}, 0, eventOrder(adapters.SortSeverity)))
+	if want := []string{"a-extreme", "b-severe", "a-minor", "b-none"}; !slices.Equal(got, want) {
+		t.Errorf("merged = %v, want %v", got, want)
+	}
+}
+
+func TestMergeNewestFirst(t *testing.T) {
+	t.Parallel()
+	a := []models.Event{
+		evt("a3", "earthquake", baseTime.Add(3*time.Hour)),
+		evt("a1", "earthquake", baseTime.Add(time.Hour)),
+	}
+	b := []models.Event{
+		evt("b4", "flood", baseTime.Add(4*time.Hour)),
+		evt("b2", "flood", baseTime.Add(2*time.Hour)),
+		evt("b0", "flood", baseTime),
+	}
+
+	got := ids(mergeOrdered([][]models.Event{a, nil, b}, 0, newestFirst))
+	if want := []string{"b4", "a3", "b2", "a1", "b0"}; !slices.Equal(got, want) {
+		t.Errorf("unlimited merge = %v, want %v", got, want)
+	}
+	got = ids(mergeOrdered([][]models.Event{a, b}, 3, newestFirst))
+	if want := []string{"b4", "a3", "b2"}; !slices.Equal(got, want) {
+		t.Errorf("limited merge = %v, want %v", got, want)
+	}
+	if got := mergeOrdered(nil, 10, newestFirst); got == nil || len(got) != 0 {
+		t.Errorf("empty merge = %#v, want an empty non-nil slice", got)
+	}
+}
+
+func TestIndexForReusesIndexUntilSnapshotChanges(t *testing.T) {
+	t.Parallel()
+	s := newTestService(t)
+	snap := Snapshot{
+		Events:    []models.Event{evt("q1", "earthquake", baseTime, 1, 1)},
+		FetchedAt: baseTime,
+	}
+
+	first := s.indexFor("snapshot:test", snap)
+	// A copy read back from a shared cache carries the same FetchedAt.
+	copied := Snapshot{Events: slices.Clone(snap.Events), FetchedAt: snap.FetchedAt}
+	if s.indexFor("snapshot:test", copied) != first {
+		t.Error("index rebuilt for an unchanged snapshot")
+	}
+
+	refreshed := Snapshot{
+		Events:    []models.Event{evt("q2", "earthquake", baseTime, 2, 2)},
+		FetchedAt: baseTime.Add(time.Minute),
+	}
+	second := s.indexFor("snapshot:test", refreshed)
+	if second == first {
+		t.Fatal("index not rebuilt after the snapshot changed")
+	}
+	if got := ids(second.query(adapters.FetchParams{})); !slices.Equal(got, []string{"q2"}) {
+		t.Errorf("rebuilt index = %v, want [q2]", got)
+	}
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+const (
+	streamBackoffMin = time.Second
+	streamBackoffMax = 2 * time.Minute
+	// streamHealthyAfter is how long a connection must have lasted for its
+	// drop to reconnect at once rather than back off further.
+	streamHealthyAfter = time.Minute
+	// subscriberBuffer is how many pushed batches a live subscriber may
+	// fall behind by before it starts missing them.
+	subscriberBuffer = 64
+	// streamFlushEvery is how often pushes are merged into the snapshot.
+	// Every merge is a new snapshot version, which rebuilds the index,
+	// invalidates every cached response of the source and, with Redis,
+	// rewrites the whole snapshot, so a burst of pushes makes one.
+	streamFlushEvery = time.Second
+)
+
+// RunStreams connects every StreamingAdapter, hands what it pushes to live
+// subscribers at once and merges it into the source's cached snapshot,
+// where requests see it, within streamFlushEvery. Alongside, each source's
+// snapshot is re-pulled every reconcileEvery, which should be shorter than
+// the cache TTL so that the entry never goes stale and the pull is what
+// refreshes it. RunStreams returns once ctx ends and every stream has
+// closed.
+func (s *EventsService) RunStreams(ctx context.Context, reconcileEvery time.Duration) {
+	var wg sync.WaitGroup
+	for _, a := range s.adapters {
+		sa, ok := a.(adapters.StreamingAdapter)
+		if !ok {
+			continue
+		}
+		st := s.newSourceStream(sa)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			st.connect(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			st.flushEvery(ctx, s.streamFlushEvery)
+		}()
+		go func() {
+			defer wg.Done()
+			st.reconcileEvery(ctx, reconcileEvery)
+		}()
+	}
+	wg.Wait()
+}
+
+// sourceStream is one streaming source's connection and reconciliation.
+type sourceStream struct {
+	svc     *EventsService
+	adapter adapters.StreamingAdapter
+
+	// mu guards the push bookkeeping and is never held across I/O, as apply
+	// takes it on the stream's read loop. pending holds the pushes not yet
+	// merged into the snapshot. While pulls of the snapshot are running,
+	// pushed logs every push since the first began, which a pull's result
+	// may predate; it is nil otherwise.
+	mu      sync.Mutex
+	pending []models.Event
+	pulls   int
+	pushed  []models.Event
+
+	// writeMu serializes the stream's own snapshot writes, so that a flush
+	// and a reconciliation never overwrite each other's merge.
+	writeMu sync.Mutex
+}
+
+// newSourceStream returns the stream for sa, registered so that every
+// load of its snapshot, including the cache's refreshes, goes through
+// pull.
+func (s *EventsService) newSourceStream(sa adapters.StreamingAdapter) *sourceStream {
+	st := &sourceStream{svc: s, adapter: sa}
+	s.streamsMu.Lock()
+	s.streams[sa.Source()] = st
+	s.streamsMu.Unlock()
+	return st
+}
+
+// snapshotLoader returns the cache load function for a's canonical
+// snapshot: for a streaming source, a pull that keeps the events pushed
+// while it ran.
+func (s *EventsService) snapshotLoader(a adapters.Adapter) func() (Snapshot, error) {
+	s.streamsMu.Lock()
+	st := s.streams[a.Source()]
+	s.streamsMu.Unlock()
+	if st == nil {
+		return s.loader(a, adapters.FetchParams{})
+	}
+	return func() (Snapshot, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		return st.pull(ctx)
+	}
+}
+
+// connect runs the stream, reconnecting with exponential backoff. A
+// connection that lasted streamHealthyAfter resets the backoff.
+func (st *sourceStream) connect(ctx context.Context) {
+	source := st.adapter.Source()
+	backoff := st.svc.streamBackoffMin
+	for {
+		started := time.Now()
+		err := st.adapter.Stream(ctx, st.apply)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) >= streamHealthyAfter {
+			backoff = st.svc.streamBackoffMin
+		}
+		slog.Warn("stream disconnected", "source", source, "error", err, "retry_in", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, st.svc.streamBackoffMax)
+	}
+}
+
+// apply hands a pushed event to live subscribers and queues it for the
+// next flush into the snapshot.
+func (st *sourceStream) apply(e models.Event) {
+	st.mu.Lock()
+	st.pending = append(st.pending, e)
+	if st.pushed != nil {
+		st.pushed = append(st.pushed, e)
+	}
+	st.mu.Unlock()
+
+	st.svc.publish(st.adapter.Source(), e)
+}
+
+func (st *sourceStream) flushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			st.flush()
+		case <-ctx.Done():
+			st.flush()
+			return
+		}
+	}
+}
+
+// flush merges the pending pushes into the snapshot as one new version.
+// Without a snapshot yet, one is loaded first, so the pushes amend the
+// source's full set rather than replacing it. Pushes arriving meanwhile
+// wait for the next flush.
+func (st *sourceStream) flush() {
+	s := st.svc
+	key := snapshotKey(st.adapter.Source())
+
+	st.mu.Lock()
+	pending := st.pending
+	st.pending = nil
+	st.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+	snap, _, err := s.sourceCache.GetOrLoad(context.Background(), key, s.snapshotLoader(st.adapter))
+	if err != nil {
+		slog.Warn("stream: snapshot unavailable, pushes not cached", "source", st.adapter.Source(), "count", len(pending), "error", err)
+		return
+	}
+	s.sourceCache.Set(key, Snapshot{Events: mergeEvents(snap.Events, pending...), FetchedAt: time.Now()})
+}
+
+// pull fetches the source's snapshot, keeping events pushed during the
+// fetch over the fetched versions. It backs both reconciliation and the
+// cache's own loads, so that neither drops pushes a flush has merged.
+func (st *sourceStream) pull(ctx context.Context) (Snapshot, error) {
+	st.mu.Lock()
+	if st.pulls == 0 {
+		st.pushed = []models.Event{}
+	}
+	st.pulls++
+	from := len(st.pushed)
+	st.mu.Unlock()
+
+	events, err := st.adapter.FetchEvents(ctx, adapters.FetchParams{})
+
+	st.mu.Lock()
+	pushed := slices.Clone(st.pushed[from:])
+	st.pulls--
+	if st.pulls == 0 {
+		st.pushed = nil
+	}
+	st.mu.Unlock()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Events: mergeEvents(events, pushed...), FetchedAt: time.Now()}, nil
+}
+
+func (st *sourceStream) reconcileEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			st.reconcile(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reconcile re-pulls the snapshot, keeping events pushed during the pull
+// over the pulled versions. The pull runs under the snapshot's fill lock,
+// so that replicas sharing a cache make one pull per tick between them,
+// and skips a tick while another holder, such as a refresh, is filling.
+func (st *sourceStream) reconcile(ctx context.Context) {
+	key := snapshotKey(st.adapter.Source())
+	unlock, ok := st.svc.sourceCache.TryLock(key)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, st.svc.timeout)
+	snap, err := st.pull(fetchCtx)
+	cancel()
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("stream reconciliation failed", "source", st.adapter.Source(), "error", err)
+		}
+		return
+	}
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+	st.svc.sourceCache.Set(key, snap)
+}
+
+// mergeEvents returns a copy of base with each update replacing the event
+// of the same ID, or appended.
+func mergeEvents(base []models.Event, updates ...models.Event) []models.Event {
+	out := slices.Clone(base)
+	pos := make(map[string]int, len(out))
+	for i, e := range out {
+		pos[e.ID] = i
+	}
+	for _, e := range updates {
+		if i, ok := pos[e.ID]; ok {
+			out[i] = e
+			continue
+		}
+		pos[e.ID] = len(out)
+		out = append(out, e)
+	}
+	return out
+}
+
+// subscriber is a live consumer of pushed events matching params.
+type subscriber struct {
+	params adapters.FetchParams
+	ch     chan StreamBatch
+}
+
+// Subscribe returns pushed events matching params' filters, all but Limit,
+// as they arrive, one batch per event, and a function ending the
+// subscription. A subscriber that falls subscriberBuffer batches behind
+// misses pushes rather than holding up the stream. The channel is closed
+// by CloseSubscriptions.
+func (s *EventsService) Subscribe(params adapters.FetchParams) (<-chan StreamBatch, func()) {
+	sub := &subscriber{params: params, ch: make(chan StreamBatch, subscriberBuffer)}
+	s.subMu.Lock()
+	if s.subsClosed {
+		close(sub.ch)
+	} else {
+		s.subs[sub] = struct{}{}
+	}
+	s.subMu.Unlock()
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			s.subMu.Lock()
+			delete(s.subs, sub)
+			s.subMu.Unlock()
+		})
+	}
+}
+
+// CloseSubscriptions closes every subscription channel, present and
+// future, so that live streams end and the server can shut down.
+func (s *EventsService) CloseSubscriptions() {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subsClosed = true
+	for sub := range s.subs {
+		close(sub.ch)
+		delete(s.subs, sub)
+	}
+}
+
+func (s *EventsService) publish(source string, e models.Event) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for sub := range s.subs {
+		if !matchesParams(e, sub.params) {
+			continue
+		}
+		select {
+		case sub.ch <- StreamBatch{Source: source, Events: []models.Event{e}, FetchedAt: time.Now()}:
+		default:
+			slog.Warn("live subscriber behind, push dropped", "source", source, "event", e.ID)
+		}
+	}
+}
+
+// matchesParams is eventIndex.query's filter for a single event.
+func matchesParams(e models.Event, params adapters.FetchParams) bool {
+	if len(params.Types) > 0 && !slices.Contains(params.Types, e.EventType) {
+		return false
+	}
+	if !params.Since.IsZero() && e.StartedAt.Before(params.Since) {
+		return false
+	}
+	return matchesAttributes(e, params) && matchesArea(e, params)
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/adapters"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/cache"
+	"github.com/KOHANTIC/SentryAtlas/backend/internal/models"
+)
+
+// fakeStreamer is a fakeAdapter that also streams whatever is sent on
+// pushes. Its first fail connections are refused.
+type fakeStreamer struct {
+	*fakeAdapter
+	pushes   chan models.Event
+	fail     int
+	connects int // guarded by fakeAdapter.mu
+}
+
+func (f *fakeStreamer) Stream(ctx context.Context, emit func(models.Event)) error {
+	f.mu.Lock()
+	f.connects++
+	n := f.connects
+	f.mu.Unlock()
+	if n <= f.fail {
+		return errors.New("connection refused")
+	}
+	for {
+		select {
+		case e := <-f.pushes:
+			emit(e)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (f *fakeStreamer) connectCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connects
+}
+
+func newFakeStreamer(events ...models.Event) *fakeStreamer {
+	return &fakeStreamer{
+		fakeAdapter: &fakeAdapter{source: "live", types: []string{"earthquake"}, events: events},
+		pushes:      make(chan models.Event),
+	}
+}
+
+// runStreams starts RunStreams and stops it when the test ends.
+func runStreams(t *testing.T, svc *EventsService, reconcileEvery time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunStreams(ctx, reconcileEvery)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func receive(t *testing.T, ch <-chan StreamBatch) StreamBatch {
+	t.Helper()
+	select {
+	case b := <-ch:
+		return b
+	case <-time.After(2 * time.Second):
+		t.Fatal("no batch received")
+		return StreamBatch{}
+	}
+}
+
+func TestRunStreamsMergesPushesIntoSnapshot(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer(
+		evt("live-1", "earthquake", baseTime, 10, 10),
+		evt("live-2", "earthquake", baseTime.Add(time.Hour), 20, 20),
+	)
+	svc := newTestService(t, fs)
+	svc.streamFlushEvery = 5 * time.Millisecond
+	runStreams(t, svc, time.Hour)
+
+	all, cancelAll := svc.Subscribe(adapters.FetchParams{})
+	defer cancelAll()
+	far, cancelFar := svc.Subscribe(adapters.FetchParams{BBox: &adapters.BBox{MinLon: 100, MinLat: 0, MaxLon: 110, MaxLat: 10}})
+	defer cancelFar()
+
+	updated := evt("live-1", "earthquake", baseTime, 10, 10)
+	updated.Title = "revised"
+	fs.pushes <- updated
+	fs.pushes <- evt("live-3", "earthquake", baseTime.Add(2*time.Hour), 30, 30)
+
+	if b := receive(t, all); b.Source != "live" || b.Events[0].ID != "live-1" {
+		t.Errorf("first push = %+v", b)
+	}
+	if b := receive(t, all); b.Events[0].ID != "live-3" {
+		t.Errorf("second push = %+v", b)
+	}
+	select {
+	case b := <-far:
+		t.Errorf("subscriber outside the bbox got %+v", b)
+	default:
+	}
+
+	// Pushes reach the snapshot at the next flush.
+	var events []models.Event
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		var err error
+		events, _, err = svc.GetEvents(context.Background(), adapters.FetchParams{})
+		if err != nil {
+			t.Fatalf("GetEvents: %v", err)
+		}
+		if len(events) == 3 || time.Now().After(deadline) {
+			break
+		}
+	}
+	if len(events) != 3 || events[0].ID != "live-3" {
+		t.Fatalf("events = %v", ids(events))
+	}
+	for _, e := range events {
+		if e.ID == "live-1" && e.Title != "revised" {
+			t.Errorf("live-1 title = %q, want the pushed revision", e.Title)
+		}
+	}
+	if n := fs.callCount(); n != 1 {
+		t.Errorf("FetchEvents called %d times, want once for the snapshot pushes amend", n)
+	}
+}
+
+func TestRunStreamsReconnectsWithBackoff(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer()
+	fs.fail = 3
+	svc := newTestService(t, fs)
+	svc.streamBackoffMin = time.Millisecond
+	svc.streamBackoffMax = 4 * time.Millisecond
+	runStreams(t, svc, time.Hour)
+
+	sub, cancel := svc.Subscribe(adapters.FetchParams{})
+	defer cancel()
+	// Only the fourth connection takes pushes.
+	fs.pushes <- evt("live-1", "earthquake", baseTime)
+	receive(t, sub)
+	if n := fs.connectCount(); n != 4 {
+		t.Errorf("connects = %d, want 4", n)
+	}
+}
+
+func TestReconcileKeepsEventsPushedDuringPull(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer(evt("live-1", "earthquake", baseTime))
+	svc := newTestService(t, fs)
+	st := svc.newSourceStream(fs)
+
+	// Seed the snapshot, then hold the reconciliation pull open.
+	st.apply(evt("live-0", "earthquake", baseTime.Add(-time.Hour)))
+	st.flush()
+	gate := make(chan struct{})
+	fs.mu.Lock()
+	fs.gate = gate
+	fs.events = []models.Event{evt("live-1", "earthquake", baseTime), evt("live-2", "earthquake", baseTime.Add(time.Hour))}
+	fs.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		st.reconcile(context.Background())
+		close(done)
+	}()
+	for fs.callCount() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	pushed := evt("live-2", "earthquake", baseTime.Add(time.Hour))
+	pushed.Title = "pushed mid-pull"
+	st.apply(pushed)
+	close(gate)
+	<-done
+
+	events, _, err := svc.GetEvents(context.Background(), adapters.FetchParams{})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	// live-0 was only ever pushed and the pull no longer has it.
+	if got := ids(events); len(got) != 2 || got[0] != "live-2" || got[1] != "live-1" {
+		t.Fatalf("events = %v, want [live-2 live-1]", got)
+	}
+	if events[0].Title != "pushed mid-pull" {
+		t.Errorf("live-2 title = %q, want the push over the pull", events[0].Title)
+	}
+}
+
+func TestFlushMergesPushesAsOneVersion(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer(evt("live-0", "earthquake", baseTime))
+	svc := newTestService(t, fs)
+	st := svc.newSourceStream(fs)
+
+	_, statuses, err := svc.GetEvents(context.Background(), adapters.FetchParams{})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	loaded := statuses[0].FetchedAt
+
+	for i := 1; i <= 5; i++ {
+		st.apply(evt(fmt.Sprintf("live-%d", i), "earthquake", baseTime.Add(time.Duration(i)*time.Minute)))
+	}
+	events, statuses, _ := svc.GetEvents(context.Background(), adapters.FetchParams{})
+	if len(events) != 1 || !statuses[0].FetchedAt.Equal(loaded) {
+		t.Fatalf("before a flush: %d events fetched at %v, want the loaded snapshot untouched", len(events), statuses[0].FetchedAt)
+	}
+
+	st.flush()
+	events, statuses, _ = svc.GetEvents(context.Background(), adapters.FetchParams{})
+	if len(events) != 6 || statuses[0].FetchedAt.Equal(loaded) {
+		t.Fatalf("after a flush: %d events fetched at %v, want all 6 in a new version", len(events), statuses[0].FetchedAt)
+	}
+	flushed := statuses[0].FetchedAt
+
+	// Nothing pending, nothing written.
+	st.flush()
+	if _, statuses, _ = svc.GetEvents(context.Background(), adapters.FetchParams{}); !statuses[0].FetchedAt.Equal(flushed) {
+		t.Error("an empty flush made a new snapshot version")
+	}
+}
+
+func TestApplyDoesNotWaitForFlushLoad(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer(evt("live-0", "earthquake", baseTime))
+	gate := make(chan struct{})
+	fs.gate = gate
+	svc := newTestService(t, fs)
+	st := svc.newSourceStream(fs)
+	sub, cancel := svc.Subscribe(adapters.FetchParams{})
+	defer cancel()
+
+	// With no snapshot yet, the flush loads one and hangs on the upstream.
+	st.apply(evt("live-1", "earthquake", baseTime))
+	receive(t, sub)
+	flushed := make(chan struct{})
+	go func() {
+		st.flush()
+		close(flushed)
+	}()
+	for fs.callCount() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	applied := make(chan struct{})
+	go func() {
+		st.apply(evt("live-2", "earthquake", baseTime.Add(time.Minute)))
+		close(applied)
+	}()
+	select {
+	case <-applied:
+	case <-time.After(time.Second):
+		t.Fatal("apply blocked behind the flush's snapshot load")
+	}
+	receive(t, sub)
+
+	close(gate)
+	<-flushed
+	st.flush()
+	events, _, err := svc.GetEvents(context.Background(), adapters.FetchParams{})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("events = %v, want live-0 with both pushes", ids(events))
+	}
+}
+
+func TestRefreshKeepsEventsPushedDuringPull(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer(evt("live-0", "earthquake", baseTime))
+	c := cache.NewWithStale[Snapshot](100*time.Millisecond, time.Minute)
+	t.Cleanup(c.Close)
+	svc := NewEventsService([]adapters.Adapter{fs}, c, 5*time.Second)
+	st := svc.newSourceStream(fs)
+
+	_, statuses, err := svc.GetEvents(context.Background(), adapters.FetchParams{})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	loaded := statuses[0].FetchedAt
+
+	// Let the entry go stale, then hold the background refresh's pull open.
+	gate := make(chan struct{})
+	fs.mu.Lock()
+	fs.gate = gate
+	fs.mu.Unlock()
+	time.Sleep(150 * time.Millisecond)
+	if _, statuses, _ = svc.GetEvents(context.Background(), adapters.FetchParams{}); !statuses[0].Stale {
+		t.Fatal("snapshot not stale, no refresh started")
+	}
+	for fs.callCount() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	st.apply(evt("live-1", "earthquake", baseTime.Add(time.Minute)))
+	close(gate)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		events, statuses, err := svc.GetEvents(context.Background(), adapters.FetchParams{})
+		if err != nil {
+			t.Fatalf("GetEvents: %v", err)
+		}
+		if !statuses[0].FetchedAt.Equal(loaded) {
+			if got := ids(events); len(got) != 2 || got[0] != "live-1" {
+				t.Fatalf("refreshed events = %v, want the push kept: [live-1 live-0]", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("refresh never replaced the snapshot")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReconcileSkipsWhileLockHeld(t *testing.T) {
+	t.Parallel()
+	fs := newFakeStreamer(evt("live-1", "earthquake", baseTime))
+	svc := newTestService(t, fs)
+	st := svc.newSourceStream(fs)
+
+	// As while another replica holds the fill lock.
+	unlock, ok := svc.sourceCache.TryLock(snapshotKey("live"))
+	if !ok {
+		t.Fatal("TryLock failed")
+	}
+	st.reconcile(context.Background())
+	if n := fs.callCount(); n != 0 {
+		t.Errorf("FetchEvents called %d times under another holder's lock, want 0", n)
+	}
+
+	unlock()
+	st.reconcile(context.Background())
+	if n := fs.callCount(); n != 1 {
+		t.Errorf("FetchEvents called %d times once the lock was free, want 1", n)
+	}
+}
+
+func TestSubscribeCancelStopsDelivery(t *testing.T) {
+	t.Parallel()
+	svc := newTestService(t)
+	ch, cancel := svc.Subscribe(adapters.FetchParams{Types: []string{"earthquake"}})
+	svc.publish("live", evt("a", "flood", baseTime))
+	svc.publish("live", evt("b", "earthquake", baseTime))
+	if b := receive(t, ch); b.Events[0].ID != "b" {
+		t.Errorf("got %s, want only the matching type", b.Events[0].ID)
+	}
+	cancel()
+	cancel() // idempotent
+	svc.publish("live", evt("c", "earthquake", baseTime))
+	select {
+	case b := <-ch:
+		t.Errorf("got %+v after cancel", b)
+	default:
+	}
+}
+
+func TestMatchesParamsSince(t *testing.T) {
+	t.Parallel()
+	params := adapters.FetchParams{Since: baseTime}
+	if matchesParams(evt("old", "earthquake", baseTime.Add(-time.Second)), params) {
+		t.Error("event before Since should not match")
+	}
+	if !matchesParams(evt("new", "earthquake", baseTime), params) {
+		t.Error("event at Since should match")
+	}
+}
+
+func TestCloseSubscriptions(t *testing.T) {
+	t.Parallel()
+	svc := newTestService(t)
+	before, cancel := svc.Subscribe(adapters.FetchParams{})
+	defer cancel()
+	svc.CloseSubscriptions()
+	svc.publish("live", evt("a", "earthquake", baseTime))
+	if _, ok := <-before; ok {
+		t.Error("open subscription not closed")
+	}
+	after, cancel := svc.Subscribe(adapters.FetchParams{})
+	defer cancel()
+	if _, ok := <-after; ok {
+		t.Error("subscription after close not closed")
+	}
+}
@@ -0,0 +1,103 @@
+package ugc
+
+import "math"
+
+// Simplify reduces a ring with the Douglas-Peucker algorithm, dropping
+// vertices within tolerance degrees of the line between those kept, and
+// rounds coordinates to digits decimals. Zone boundaries are surveyed to
+// the meter; a map of alerts needs a fraction of that, and the dataset is
+// built into the binary. A ring that would collapse below a triangle is
+// returned rounded but otherwise whole.
+func Simplify(ring [][]float64, tolerance float64, digits int) [][]float64 {
+	keep := make([]bool, len(ring))
+	if len(ring) > 0 {
+		keep[0], keep[len(ring)-1] = true, true
+		douglasPeucker(ring, 0, len(ring)-1, tolerance, keep)
+	}
+	kept := 0
+	for _, k := range keep {
+		if k {
+			kept++
+		}
+	}
+	// A closed ring needs 4 positions: 3 corners and the closing one.
+	if kept < 4 {
+		for i := range keep {
+			keep[i] = true
+		}
+	}
+
+	scale := math.Pow(10, float64(digits))
+	out := make([][]float64, 0, len(ring))
+	for i, p := range ring {
+		if !keep[i] || len(p) < 2 {
+			continue
+		}
+		q := []float64{math.Round(p[0]*scale) / scale, math.Round(p[1]*scale) / scale}
+		if n := len(out); n > 0 && out[n-1][0] == q[0] && out[n-1][1] == q[1] {
+			continue
+		}
+		out = append(out, q)
+	}
+	return out
+}
+
+func douglasPeucker(ring [][]float64, first, last int, tolerance float64, keep []bool) {
+	if last <= first+1 {
+		return
+	}
+	farthest, maxDist := -1, tolerance
+	for i := first + 1; i < last; i++ {
+		if d := segmentDistance(ring[i], ring[first], ring[last]); d > maxDist {
+			farthest, maxDist = i, d
+		}
+	}
+	if farthest < 0 {
+		return
+	}
+	keep[farthest] = true
+	douglasPeucker(ring, first, farthest, tolerance, keep)
+	douglasPeucker(ring, farthest, last, tolerance, keep)
+}
+
+// segmentDistance is the planar distance from p to the segment a-b, in
+// degrees.
+func segmentDistance(p, a, b []float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p[0]-a[0], p[1]-a[1])
+	}
+	t := ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p[0]-(a[0]+t*dx), p[1]-(a[1]+t*dy))
+}
+
+// Centroid is the area-weighted centroid of polygons' outer rings, by the
+// shoelace formula, or the mean of their vertices if they enclose no area.
+func Centroid(polygons [][][][]float64) []float64 {
+	var area, cx, cy, sumLon, sumLat float64
+	var n int
+	for _, poly := range polygons {
+		if len(poly) == 0 {
+			continue
+		}
+		ring := poly[0]
+		for i := 0; i+1 < len(ring); i++ {
+			p, q := ring[i], ring[i+1]
+			cross := p[0]*q[1] - q[0]*p[1]
+			area += cross
+			cx += (p[0] + q[0]) * cross
+			cy += (p[1] + q[1]) * cross
+			sumLon += p[0]
+			sumLat += p[1]
+			n++
+		}
+	}
+	if math.Abs(area) > 1e-12 {
+		return []float64{cx / (3 * area), cy / (3 * area)}
+	}
+	if n == 0 {
+		return nil
+	}
+	return []float64{sumLon / float64(n), sumLat / float64(n)}
+}
@@ -0,0 +1,41 @@
+package ugc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	t.Parallel()
+	// A square with a vertex on each side's midpoint, one slightly off.
+	ring := [][]float64{
+		{0, 0}, {0.5, 0.001}, {1, 0}, {1, 0.5}, {1, 1}, {0.5, 1}, {0, 1}, {0, 0.5}, {0, 0},
+	}
+	got := Simplify(ring, 0.01, 3)
+	want := [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Simplify = %v, want the corners %v", got, want)
+	}
+
+	// Rounding to 1 decimal, and a ring simplification would collapse is
+	// only rounded.
+	thin := [][]float64{{0, 0}, {10, 0.04}, {20, 0}, {0, 0}}
+	got = Simplify(thin, 0.1, 1)
+	if len(got) != 4 || !slices.Equal(got[1], []float64{10, 0}) {
+		t.Errorf("Simplify(thin) = %v, want all 4 vertices, rounded", got)
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	t.Parallel()
+	square := [][][]float64{{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}}
+	// Area-weighted: the 4x4 square outweighs the 2x2 one 4:1.
+	big := [][][]float64{{{10, 0}, {14, 0}, {14, 4}, {10, 4}, {10, 0}}}
+	got := Centroid([][][][]float64{square, big})
+	if want := []float64{(1*4 + 12*16) / 20.0, (1*4 + 2*16) / 20.0}; !slices.Equal(got, want) {
+		t.Errorf("Centroid = %v, want %v", got, want)
+	}
+	if got := Centroid(nil); got != nil {
+		t.Errorf("Centroid(nil) = %v", got)
+	}
+}
@@ -0,0 +1,183 @@
+package ugc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// zones.json.gz is built by cmd/ugczones from the NWS zone boundaries and
+// committed, so a build needs no network; go generate refreshes it.
+//
+//go:generate go run ../../cmd/ugczones -o zones.json.gz
+//go:embed zones.json.gz
+var embedded []byte
+
+// Zone is one NWS zone: a forecast or fire weather zone (TXZ119) or a
+// county (TXC439), keyed by its UGC code.
+type Zone struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state,omitempty"`
+	// Centroid is the zone's [lon, lat] anchor.
+	Centroid []float64 `json:"centroid"`
+	// Polygons are the zone's simplified boundary, each outer ring first.
+	Polygons [][][][]float64 `json:"polygons"`
+}
+
+// Dataset maps UGC codes to zones.
+type Dataset struct {
+	Generated time.Time
+	zones     map[string]Zone
+}
+
+// file is a dataset's encoding, gzipped or not.
+type file struct {
+	Generated time.Time `json:"generated,omitzero"`
+	Zones     []Zone    `json:"zones"`
+}
+
+// Load reads a dataset in the format cmd/ugczones writes, gzipped or not.
+func Load(r io.Reader) (*Dataset, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("ugc: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+	var f file
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("ugc: decode dataset: %w", err)
+	}
+	d := &Dataset{Generated: f.Generated, zones: make(map[string]Zone, len(f.Zones))}
+	for _, z := range f.Zones {
+		if len(z.Centroid) < 2 {
+			return nil, fmt.Errorf("ugc: zone %q has no centroid", z.ID)
+		}
+		d.zones[strings.ToUpper(z.ID)] = z
+	}
+	return d, nil
+}
+
+// Write encodes zones as a gzipped dataset.
+func Write(w io.Writer, generated time.Time, zones []Zone) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(file{Generated: generated, Zones: zones}); err != nil {
+		return fmt.Errorf("ugc: encode dataset: %w", err)
+	}
+	return gz.Close()
+}
+
+// Embedded returns the dataset built into the binary.
+var Embedded = sync.OnceValue(func() *Dataset {
+	d, err := Load(bytes.NewReader(embedded))
+	if err != nil {
+		// Only a broken go:generate run gets here; an alert left
+		// unlocated beats a server that won't start.
+		slog.Error("ugc: embedded dataset unreadable", "error", err)
+		return &Dataset{zones: map[string]Zone{}}
+	}
+	return d
+})
+
+// Lookup returns the zone of a UGC code.
+func (d *Dataset) Lookup(code string) (Zone, bool) {
+	z, ok := d.zones[strings.ToUpper(code)]
+	return z, ok
+}
+
+// Len returns the number of zones.
+func (d *Dataset) Len() int {
+	return len(d.zones)
+}
+
+// Source supplies the dataset to resolve codes with, which may change
+// while the server runs.
+type Source interface {
+	Dataset() *Dataset
+}
+
+type static struct{ d *Dataset }
+
+func (s static) Dataset() *Dataset { return s.d }
+
+// Static returns a Source that always supplies d.
+func Static(d *Dataset) Source {
+	return static{d}
+}
+
+// FileSource supplies a dataset read from a file, re-read when the file
+// changes, so boundaries can be refreshed by regenerating the file without
+// a rebuild or restart. The file is checked at most once per interval.
+type FileSource struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	d       *Dataset
+	modTime time.Time
+	checked time.Time
+}
+
+// NewFileSource loads the dataset at path. An unreadable file is an error
+// here, where it is a misconfiguration; later reload failures keep the
+// dataset already loaded.
+func NewFileSource(path string, interval time.Duration) (*FileSource, error) {
+	s := &FileSource{path: path, interval: interval, now: time.Now}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("ugc: %w", err)
+	}
+	if err := s.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	s.checked = s.now()
+	return s, nil
+}
+
+func (s *FileSource) Dataset() *Dataset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := s.now(); now.Sub(s.checked) >= s.interval {
+		s.checked = now
+		info, err := os.Stat(s.path)
+		switch {
+		case err != nil:
+			slog.Warn("ugc: dataset file unavailable", "path", s.path, "error", err)
+		case !info.ModTime().Equal(s.modTime):
+			if err := s.load(info.ModTime()); err != nil {
+				slog.Warn("ugc: dataset reload failed", "path", s.path, "error", err)
+			}
+		}
+	}
+	return s.d
+}
+
+func (s *FileSource) load(modTime time.Time) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("ugc: %w", err)
+	}
+	defer f.Close()
+	d, err := Load(f)
+	if err != nil {
+		return err
+	}
+	s.d, s.modTime = d, modTime
+	return nil
+}
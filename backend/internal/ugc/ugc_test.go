@@ -0,0 +1,134 @@
+package ugc
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDataset = `{"generated":"2026-08-01T00:00:00Z","zones":[
+	{"id":"TXZ119","name":"Tarrant","state":"TX","centroid":[-97.29,32.77],"polygons":[[[[-97.55,32.55],[-97.03,32.55],[-97.03,33.0],[-97.55,33.0],[-97.55,32.55]]]]},
+	{"id":"txc439","name":"Tarrant County","state":"TX","centroid":[-97.29,32.77],"polygons":[]}
+]}`
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	var gz bytes.Buffer
+	d, err := Load(strings.NewReader(testDataset))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var zones []Zone
+	for _, id := range []string{"TXZ119", "TXC439"} {
+		z, ok := d.Lookup(id)
+		if !ok {
+			t.Fatalf("Lookup(%s) not found", id)
+		}
+		zones = append(zones, z)
+	}
+	if err := Write(&gz, d.Generated, zones); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	// Gzipped or not, and codes in any case.
+	back, err := Load(&gz)
+	if err != nil {
+		t.Fatalf("Load gzipped: %v", err)
+	}
+	if back.Len() != 2 || !back.Generated.Equal(time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Len = %d, Generated = %v", back.Len(), back.Generated)
+	}
+	z, ok := back.Lookup("txz119")
+	if !ok || z.Name != "Tarrant" || len(z.Polygons) != 1 || len(z.Polygons[0][0]) != 5 {
+		t.Errorf("Lookup(txz119) = %+v, %v", z, ok)
+	}
+	if _, ok := back.Lookup("TXZ999"); ok {
+		t.Error("unknown code found")
+	}
+
+	if _, err := Load(strings.NewReader(`{"zones":[{"id":"TXZ1"}]}`)); err == nil || !strings.Contains(err.Error(), "no centroid") {
+		t.Errorf("zone without centroid: err = %v", err)
+	}
+}
+
+func TestEmbeddedDatasetLoads(t *testing.T) {
+	t.Parallel()
+	if _, err := Load(bytes.NewReader(embedded)); err != nil {
+		t.Fatalf("embedded dataset: %v", err)
+	}
+}
+
+func TestEmbeddedDatasetResolvesKnownZones(t *testing.T) {
+	t.Parallel()
+	d := Embedded()
+	if d.Len() == 0 {
+		t.Fatal("embedded dataset is empty; run go generate ./internal/ugc")
+	}
+	// Tarrant County, Texas (Fort Worth), as a forecast zone and a county.
+	for _, code := range []string{"TXZ119", "TXC439"} {
+		z, ok := d.Lookup(code)
+		if !ok {
+			t.Errorf("Lookup(%s) not found", code)
+			continue
+		}
+		if len(z.Polygons) == 0 {
+			t.Errorf("%s has no boundary", code)
+		}
+		if lon, lat := z.Centroid[0], z.Centroid[1]; math.Abs(lon+97.29) > 0.5 || math.Abs(lat-32.77) > 0.5 {
+			t.Errorf("%s centroid = %v, want near Fort Worth [-97.29 32.77]", code, z.Centroid)
+		}
+	}
+}
+
+func TestFileSourceReloadsChangedFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "zones.json")
+	if err := os.WriteFile(path, []byte(testDataset), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewFileSource(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileSource: %v", err)
+	}
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	if n := s.Dataset().Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"zones":[{"id":"OKZ025","centroid":[-97.5,35.5],"polygons":[]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Dataset().Len(); n != 2 {
+		t.Errorf("Len = %d before the interval, want the old 2", n)
+	}
+	now = now.Add(time.Hour)
+	if _, ok := s.Dataset().Lookup("OKZ025"); !ok {
+		t.Error("changed file not reloaded after the interval")
+	}
+
+	// A broken file keeps the dataset already loaded.
+	if err := os.WriteFile(path, []byte("{broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later = later.Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	if _, ok := s.Dataset().Lookup("OKZ025"); !ok {
+		t.Error("dataset lost on a failed reload")
+	}
+
+	if _, err := NewFileSource(filepath.Join(t.TempDir(), "missing.json"), time.Hour); err == nil {
+		t.Error("missing file accepted")
+	}
+}
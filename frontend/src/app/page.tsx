@@ -1,13 +1,17 @@
 "use client";
 
-import { useState, useCallback, useRef } from "react";
+import { useState, useCallback, useMemo, useRef } from "react";
 import dynamic from "next/dynamic";
 import { useEvents } from "@/hooks/useEvents";
+import { useEventTypes } from "@/hooks/useEventTypes";
 import FilterPanel from "@/components/FilterPanel";
 import Legend from "@/components/Legend";
-import { EVENT_TYPES, type FetchParams, type EventType } from "@/lib/types";
+import type { FetchParams, EventType, EventTypeInfo } from "@/lib/types";
+import { allTypes } from "@/lib/taxonomy";
 import { getSinceDate } from "@/lib/time";
 
+const NO_TYPES: EventTypeInfo[] = [];
+
 const MapView = dynamic(() => import("@/components/MapView"), { ssr: false });
 
 function relativeAge(from: Date): string {
@@ -18,12 +22,27 @@ function relativeAge(from: Date): string {
 }
 
 export default function Home() {
-  // All types visible by default: an empty selection renders a blank world
-  // map with "0 events", which reads as broken to a first-time visitor.
-  const [fetchParams, setFetchParams] = useState<FetchParams>({
-    types: [...EVENT_TYPES],
+  const [query, setQuery] = useState<Omit<FetchParams, "types">>({
     since: getSinceDate("7d"),
   });
+  // The selection is kept as the types the user hid, so every type is
+  // visible by default — an empty selection renders a blank world map with
+  // "0 events", which reads as broken to a first-time visitor. Nothing is
+  // fetched, and no filters are shown, until the taxonomy has loaded.
+  const [hiddenTypes, setHiddenTypes] = useState<EventType[]>([]);
+  const taxonomy = useEventTypes();
+  const knownTypes = useMemo(() => allTypes(taxonomy ?? NO_TYPES), [taxonomy]);
+  const visibleTypes = useMemo(
+    () => knownTypes.filter((t) => !hiddenTypes.includes(t)),
+    [knownTypes, hiddenTypes]
+  );
+  // Keyed by content, so that a re-render with the same selection does not
+  // restart the stream.
+  const typesKey = visibleTypes.join(",");
+  const fetchParams = useMemo<FetchParams>(
+    () => ({ ...query, types: typesKey ? typesKey.split(",") : [] }),
+    [query, typesKey]
+  );
   const { data, isLoading, error, sources, lastUpdated, refetch } =
     useEvents(fetchParams);
 
@@ -32,50 +51,64 @@ export default function Home() {
     (bbox: [number, number, number, number]) => {
       if (boundsTimer.current) clearTimeout(boundsTimer.current);
       boundsTimer.current = setTimeout(() => {
-        setFetchParams((prev) => ({ ...prev, bbox }));
+        setQuery((prev) => ({ ...prev, bbox }));
       }, 300);
     },
     []
   );
 
-  const handleTypesChange = useCallback((types: EventType[]) => {
-    setFetchParams((prev) => ({ ...prev, types }));
-  }, []);
+  const handleTypesChange = useCallback(
+    (types: EventType[]) => {
+      setHiddenTypes(knownTypes.filter((t) => !types.includes(t)));
+    },
+    [knownTypes]
+  );
 
   const handleSinceChange = useCallback((since?: string) => {
-    setFetchParams((prev) => ({ ...prev, since }));
+    setQuery((prev) => ({ ...prev, since }));
   }, []);
 
   const eventCount = data?.features?.length ?? 0;
   const downSources = (sources ?? []).filter((s) => !s.ok);
-  const nothingSelected = fetchParams.types.length === 0;
+  const typesLoading = taxonomy === null;
+  const loading = isLoading || typesLoading;
+  const nothingSelected = !typesLoading && fetchParams.types.length === 0;
 
   return (
     <main className="relative h-dvh w-full overflow-hidden">
       <h1 className="sr-only">SentryAtlas — real-time disaster monitoring</h1>
-      <MapView data={data} onBoundsChange={handleBoundsChange} />
+      <MapView
+        data={data}
+        taxonomy={taxonomy ?? NO_TYPES}
+        onBoundsChange={handleBoundsChange}
+      />
 
       {/* One column for both left-hand panels: absolutely positioning them
           independently let the expanded legend overlap the filter panel and
           run off short viewports. */}
       <div className="absolute top-4 bottom-6 left-4 z-10 flex flex-col items-start justify-between gap-3 max-w-[calc(100vw-6.5rem)] pointer-events-none">
-        <div className="min-h-0 flex-shrink pointer-events-auto">
-          <FilterPanel
-            visibleTypes={fetchParams.types}
-            onVisibleTypesChange={handleTypesChange}
-            since={fetchParams.since}
-            onSinceChange={handleSinceChange}
-          />
-        </div>
+        {taxonomy && (
+          <div className="min-h-0 flex-shrink pointer-events-auto">
+            <FilterPanel
+              taxonomy={taxonomy}
+              visibleTypes={fetchParams.types}
+              onVisibleTypesChange={handleTypesChange}
+              since={fetchParams.since}
+              onSinceChange={handleSinceChange}
+            />
+          </div>
+        )}
 
-        <div className="min-h-0 flex-shrink-0 pointer-events-auto">
-          <Legend />
-        </div>
+        {taxonomy && (
+          <div className="min-h-0 flex-shrink-0 pointer-events-auto">
+            <Legend taxonomy={taxonomy} />
+          </div>
+        )}
       </div>
 
       <div className="absolute top-4 right-4 z-10 flex flex-col items-end gap-2">
         <div className="bg-surface-raised/95 backdrop-blur-sm border border-border shadow-lg px-4 py-2 flex items-center gap-3">
-          {isLoading && (
+          {loading && (
             <div
               className="flex items-center gap-2 text-xs text-foreground-muted"
               role="status"
@@ -124,7 +157,7 @@ export default function Home() {
               </button>
             </div>
           )}
-          {!isLoading && !error && (
+          {!loading && !error && (
             <span className="text-xs text-foreground-muted font-medium whitespace-nowrap">
               {eventCount} {eventCount === 1 ? "event" : "events"}
               {lastUpdated && (
@@ -137,11 +170,11 @@ export default function Home() {
           )}
         </div>
 
-        {!isLoading && !error && nothingSelected && (
+        {!loading && !error && nothingSelected && (
           <div className="bg-surface-raised/95 backdrop-blur-sm border border-border shadow-lg px-3 py-2 text-xs text-foreground-muted max-w-56">
             No event types selected — choose some in Filters, or{" "}
             <button
-              onClick={() => handleTypesChange([...EVENT_TYPES])}
+              onClick={() => setHiddenTypes([])}
               className="font-semibold text-accent-400 hover:underline cursor-pointer"
             >
               show all
@@ -150,7 +183,7 @@ export default function Home() {
           </div>
         )}
 
-        {!isLoading && !error && !nothingSelected && eventCount === 0 && (
+        {!loading && !error && !nothingSelected && eventCount === 0 && (
           <div className="bg-surface-raised/95 backdrop-blur-sm border border-border shadow-lg px-3 py-2 text-xs text-foreground-muted max-w-56">
             No events match the current filters in this view. Try a wider time
             range or zoom out.
@@ -1,11 +1,13 @@
 "use client";
 
-import { useState } from "react";
-import { EVENT_TYPES, EventType } from "@/lib/types";
-import { EVENT_TYPE_COLORS, EVENT_TYPE_LABELS } from "@/lib/mapStyles";
+import { useMemo, useState } from "react";
+import type { EventType, EventTypeInfo } from "@/lib/types";
+import { typeColor } from "@/lib/mapStyles";
+import { allTypes, taxonomyTree, typeSubtree } from "@/lib/taxonomy";
 import { TIME_PRESETS, getSinceDate, getActivePreset } from "@/lib/time";
 
 interface FilterPanelProps {
+  taxonomy: EventTypeInfo[];
   visibleTypes: EventType[];
   onVisibleTypesChange: (types: EventType[]) => void;
   since?: string;
@@ -13,6 +15,7 @@ interface FilterPanelProps {
 }
 
 export default function FilterPanel({
+  taxonomy,
   visibleTypes,
   onVisibleTypesChange,
   since,
@@ -20,15 +23,19 @@ export default function FilterPanel({
 }: FilterPanelProps) {
   const [collapsed, setCollapsed] = useState(false);
   const activePreset = getActivePreset(since);
+  const tree = useMemo(() => taxonomyTree(taxonomy), [taxonomy]);
 
+  // A type toggles with its descendants, as the API's types= filter reads
+  // it: hiding storm hides hurricanes too. Each can still be toggled alone.
   const toggleType = (type: EventType) => {
+    const subtree = typeSubtree(taxonomy, type);
     const next = visibleTypes.includes(type)
-      ? visibleTypes.filter((t) => t !== type)
-      : [...visibleTypes, type];
+      ? visibleTypes.filter((t) => !subtree.includes(t))
+      : [...visibleTypes, ...subtree.filter((t) => !visibleTypes.includes(t))];
     onVisibleTypesChange(next);
   };
 
-  const selectAll = () => onVisibleTypesChange([...EVENT_TYPES]);
+  const selectAll = () => onVisibleTypesChange(allTypes(taxonomy));
   const clearAll = () => onVisibleTypesChange([]);
 
   const setTimePreset = (preset: (typeof TIME_PRESETS)[number]["value"]) => {
@@ -141,8 +148,9 @@ export default function FilterPanel({
         </div>
 
         <div className="space-y-0.5">
-          {EVENT_TYPES.map((type) => {
+          {tree.map(({ type, label, depth }) => {
             const active = visibleTypes.includes(type);
+            const color = typeColor(taxonomy, type);
             return (
               <button
                 key={type}
@@ -153,17 +161,18 @@ export default function FilterPanel({
                     ? "text-foreground hover:bg-surface-overlay"
                     : "text-foreground-muted hover:bg-surface-overlay"
                 }`}
+                style={{ paddingLeft: `${0.5 + depth}rem` }}
               >
                 <span
                   className="w-3 h-3 flex-shrink-0 border border-surface-raised"
                   style={{
                     backgroundColor: active
-                      ? EVENT_TYPE_COLORS[type]
+                      ? color
                       : "var(--color-border-strong)",
-                    boxShadow: active ? `0 0 0 1px ${EVENT_TYPE_COLORS[type]}40` : "none",
+                    boxShadow: active ? `0 0 0 1px ${color}40` : "none",
                   }}
                 />
-                <span className="truncate">{EVENT_TYPE_LABELS[type]}</span>
+                <span className="truncate">{label}</span>
               </button>
             );
           })}
@@ -1,11 +1,17 @@
 "use client";
 
-import { useState } from "react";
-import { EVENT_TYPES } from "@/lib/types";
-import { EVENT_TYPE_COLORS, EVENT_TYPE_LABELS, SEVERITY_RAMP_GRADIENT } from "@/lib/mapStyles";
+import { useMemo, useState } from "react";
+import type { EventTypeInfo } from "@/lib/types";
+import { SEVERITY_RAMP_GRADIENT, typeColor } from "@/lib/mapStyles";
+import { taxonomyTree } from "@/lib/taxonomy";
 
-export default function Legend() {
+interface LegendProps {
+  taxonomy: EventTypeInfo[];
+}
+
+export default function Legend({ taxonomy }: LegendProps) {
   const [collapsed, setCollapsed] = useState(true);
+  const tree = useMemo(() => taxonomyTree(taxonomy), [taxonomy]);
 
   if (collapsed) {
     return (
@@ -84,14 +90,14 @@ export default function Legend() {
       </div>
 
       <div className="px-3 py-2 grid grid-cols-2 gap-x-3 gap-y-1">
-        {EVENT_TYPES.map((type) => (
+        {tree.map(({ type, label }) => (
           <div key={type} className="flex items-center gap-2">
             <span
               className="w-2.5 h-2.5 flex-shrink-0"
-              style={{ backgroundColor: EVENT_TYPE_COLORS[type] }}
+              style={{ backgroundColor: typeColor(taxonomy, type) }}
             />
             <span className="text-[11px] text-foreground-muted whitespace-nowrap">
-              {EVENT_TYPE_LABELS[type]}
+              {label}
             </span>
           </div>
         ))}
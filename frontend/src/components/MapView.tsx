@@ -7,15 +7,20 @@ import {
   UNCLUSTERED_POINT_LAYER,
   MAP_STYLE_URL,
   INITIAL_VIEW,
-  EVENT_TYPE_COLORS,
-  EVENT_TYPE_LABELS,
   SEVERITY_COLORS,
-  FALLBACK_COLOR,
+  typeColor,
+  typeColorExpression,
 } from "@/lib/mapStyles";
-import type { EventsGeoJSON, EventProperties, Severity } from "@/lib/types";
+import type {
+  EventsGeoJSON,
+  EventProperties,
+  EventTypeInfo,
+  Severity,
+} from "@/lib/types";
 
 interface MapViewProps {
   data: EventsGeoJSON | null;
+  taxonomy: EventTypeInfo[];
   onBoundsChange: (bbox: [number, number, number, number]) => void;
 }
 
@@ -29,9 +34,14 @@ function el(tag: string, style: string, text?: string): HTMLElement {
   return node;
 }
 
-function buildPopupContent(props: EventProperties): HTMLElement {
-  const color = EVENT_TYPE_COLORS[props.event_type] ?? FALLBACK_COLOR;
-  const label = EVENT_TYPE_LABELS[props.event_type] ?? props.event_type;
+function buildPopupContent(
+  props: EventProperties,
+  taxonomy: EventTypeInfo[]
+): HTMLElement {
+  const color = typeColor(taxonomy, props.event_type);
+  const label =
+    taxonomy.find((t) => t.type === props.event_type)?.label ??
+    props.event_type;
   const date = new Date(props.started_at).toLocaleString();
 
   const root = el("div", "max-width:280px;font-family:var(--font-sans)");
@@ -114,7 +124,11 @@ function buildPopupContent(props: EventProperties): HTMLElement {
   return root;
 }
 
-export default function MapView({ data, onBoundsChange }: MapViewProps) {
+export default function MapView({
+  data,
+  taxonomy,
+  onBoundsChange,
+}: MapViewProps) {
   const containerRef = useRef<HTMLDivElement>(null);
   const mapRef = useRef<maplibregl.Map | null>(null);
   const popupRef = useRef<maplibregl.Popup | null>(null);
@@ -124,6 +138,11 @@ export default function MapView({ data, onBoundsChange }: MapViewProps) {
   useEffect(() => {
     onBoundsChangeRef.current = onBoundsChange;
   }, [onBoundsChange]);
+  // Read by the map's handlers, which are bound once per map.
+  const taxonomyRef = useRef(taxonomy);
+  useEffect(() => {
+    taxonomyRef.current = taxonomy;
+  }, [taxonomy]);
 
   const setupLayers = useCallback((map: maplibregl.Map) => {
     if (map.getSource("events")) return;
@@ -135,6 +154,11 @@ export default function MapView({ data, onBoundsChange }: MapViewProps) {
 
     map.addLayer(HEATMAP_LAYER);
     map.addLayer(UNCLUSTERED_POINT_LAYER);
+    map.setPaintProperty(
+      "unclustered-point",
+      "circle-color",
+      typeColorExpression(taxonomyRef.current)
+    );
   }, []);
 
   useEffect(() => {
@@ -215,7 +239,7 @@ export default function MapView({ data, onBoundsChange }: MapViewProps) {
         offset: 12,
       })
         .setLngLat(coords)
-        .setDOMContent(buildPopupContent(parsed))
+        .setDOMContent(buildPopupContent(parsed, taxonomyRef.current))
         .addTo(map);
     });
 
@@ -236,6 +260,18 @@ export default function MapView({ data, onBoundsChange }: MapViewProps) {
     };
   }, [setupLayers]);
 
+  // The layer may predate the taxonomy, whose loading is what gives the
+  // points their types' colors.
+  useEffect(() => {
+    const map = mapRef.current;
+    if (!map || !map.getLayer("unclustered-point")) return;
+    map.setPaintProperty(
+      "unclustered-point",
+      "circle-color",
+      typeColorExpression(taxonomy)
+    );
+  }, [taxonomy]);
+
   useEffect(() => {
     const map = mapRef.current;
     if (!map || !data) return;
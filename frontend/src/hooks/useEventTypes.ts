@@ -0,0 +1,42 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { fetchEventTypes } from "@/lib/api";
+import type { EventTypeInfo } from "@/lib/types";
+
+// How long to wait before asking for the taxonomy again after a failure.
+const RETRY_INTERVAL_MS = 10_000;
+
+/**
+ * The event-type taxonomy from GET /api/v1/types: which types exist, their
+ * labels and their parents. It is null until it has loaded — the frontend
+ * keeps no copy of its own to go out of date — and a failed fetch is
+ * retried until one succeeds.
+ */
+export function useEventTypes(): EventTypeInfo[] | null {
+  const [taxonomy, setTaxonomy] = useState<EventTypeInfo[] | null>(null);
+
+  useEffect(() => {
+    const controller = new AbortController();
+    let retry: ReturnType<typeof setTimeout> | undefined;
+    const load = () => {
+      fetchEventTypes(controller.signal)
+        .then((types) => {
+          if (types.length === 0) throw new Error("empty taxonomy");
+          setTaxonomy(types);
+        })
+        .catch(() => {
+          if (!controller.signal.aborted) {
+            retry = setTimeout(load, RETRY_INTERVAL_MS);
+          }
+        });
+    };
+    load();
+    return () => {
+      controller.abort();
+      clearTimeout(retry);
+    };
+  }, []);
+
+  return taxonomy;
+}
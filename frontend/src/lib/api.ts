@@ -1,4 +1,9 @@
-import { GeoJSONFeature, FetchParams, StreamSummary } from "./types";
+import {
+  GeoJSONFeature,
+  FetchParams,
+  StreamSummary,
+  EventTypeInfo,
+} from "./types";
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL ?? "http://localhost:8080";
 
@@ -10,6 +15,17 @@ function buildParams(params: FetchParams): URLSearchParams {
   return qs;
 }
 
+export async function fetchEventTypes(
+  signal?: AbortSignal
+): Promise<EventTypeInfo[]> {
+  const res = await fetch(`${API_URL}/api/v1/types`, { signal });
+  if (!res.ok) {
+    throw new Error(`API error: ${res.status}`);
+  }
+  const body = (await res.json()) as { types: EventTypeInfo[] };
+  return body.types;
+}
+
 export async function streamEvents(
   params: FetchParams,
   onChunk: (features: GeoJSONFeature[]) => void,
@@ -34,6 +50,10 @@ export async function streamEvents(
     throw new Error("API response has no body");
   }
 
+  // types= also matches descendants (storm brings hurricanes along), so
+  // keep only the selected types: unchecking a subtype must hide it.
+  const selected = new Set<string>(params.types);
+
   const reader = res.body.getReader();
   const decoder = new TextDecoder();
   let buffer = "";
@@ -57,8 +77,14 @@ export async function streamEvents(
         const geojson = JSON.parse(data);
         // Unlocated events (e.g. NOAA alerts without a polygon) arrive with
         // geometry: null and cannot be drawn on the map.
-        const drawable = ((geojson.features ?? []) as { geometry: unknown }[])
-          .filter((f) => f.geometry != null);
+        const drawable = (
+          (geojson.features ?? []) as {
+            geometry: unknown;
+            properties: { event_type: string };
+          }[]
+        ).filter(
+          (f) => f.geometry != null && selected.has(f.properties.event_type)
+        );
         if (drawable.length) {
           onChunk(drawable as GeoJSONFeature[]);
         }
@@ -1,4 +1,5 @@
-import type { EventType, Severity } from "./types";
+import type { EventType, EventTypeInfo, Severity } from "./types";
+import { typeAncestors } from "./taxonomy";
 import type {
   CircleLayerSpecification,
   HeatmapLayerSpecification,
@@ -6,21 +7,26 @@ import type {
 } from "maplibre-gl";
 
 /*
- * Event-type palette — the single source of truth. The MapLibre match
- * expression, the legend, and the filter panel all derive from this record;
- * nothing else may restate a type color.
+ * Event-type palette — the single source of truth for type colors. The
+ * MapLibre match expression, the legend, and the filter panel all derive
+ * from this record through typeColor; nothing else may restate a type
+ * color. It names only top-level categories, and never decides which types
+ * exist: that is the backend's taxonomy. A subtype takes its nearest
+ * ancestor's color (hurricanes are drawn as storms), and a category added
+ * in the backend without a color here is drawn in FALLBACK_COLOR.
  *
  * Designed for the dark surface (#161616) with semantic hue families
- * (fire/earth warm, water blue, wind violet, ice cyan/teal, dry gold) and
- * optimized so the worst of all 78 chromatic pairs keeps OKLab ΔE ≥ 8.7
+ * (fire/earth warm, water blue, wind violet, ice cyan/teal, dry gold,
+ * space weather aurora green and solar yellow) and optimized so the
+ * worst of all 55 chromatic pairs keeps OKLab ΔE ≥ 8.7
  * under normal vision, with every color ≥ 3:1 contrast on the surface.
- * Full pairwise CVD distinctness is unreachable at 13 chromatic categories
+ * Full pairwise CVD distinctness is unreachable at 11 chromatic categories
  * (collapses stay within a hue family); identity is therefore never
  * color-alone — the legend, filter panel, and popups all carry the type
  * name, and the filter can isolate any single type.
  * "weather" and "other" are deliberately low-chroma fallback slots.
  */
-export const EVENT_TYPE_COLORS: Record<EventType, string> = {
+const CATEGORY_COLORS: Record<EventType, string> = {
   earthquake: "#d17714",
   wildfire: "#fd4812",
   volcano: "#d0374c",
@@ -29,34 +35,36 @@ export const EVENT_TYPE_COLORS: Record<EventType, string> = {
   storm: "#1f9dd4",
   flood: "#1962f0",
   tsunami: "#0092a4",
-  winter_storm: "#0683df",
   iceberg: "#1baa86",
-  hurricane: "#6f5bbd",
-  cyclone: "#9f3bbb",
-  tornado: "#d94e9a",
+  geomagnetic_storm: "#3fbf5f",
+  solar_radiation: "#f2c811",
   weather: "#93a1b0",
   other: "#8c8c8c",
 };
 
 export const FALLBACK_COLOR = "#8c8c8c";
 
-export const EVENT_TYPE_LABELS: Record<EventType, string> = {
-  earthquake: "Earthquake",
-  wildfire: "Wildfire",
-  volcano: "Volcano",
-  storm: "Storm",
-  flood: "Flood",
-  cyclone: "Cyclone",
-  tornado: "Tornado",
-  hurricane: "Hurricane",
-  winter_storm: "Winter Storm",
-  tsunami: "Tsunami",
-  drought: "Drought",
-  iceberg: "Iceberg",
-  landslide: "Landslide",
-  weather: "Weather",
-  other: "Other",
-};
+/** The color for type: its category's, found through its ancestors. */
+export function typeColor(taxonomy: EventTypeInfo[], type: EventType): string {
+  for (const t of [type, ...typeAncestors(taxonomy, type)]) {
+    if (Object.hasOwn(CATEGORY_COLORS, t)) return CATEGORY_COLORS[t];
+  }
+  return FALLBACK_COLOR;
+}
+
+/** The MapLibre circle color for every type in taxonomy. */
+export function typeColorExpression(
+  taxonomy: EventTypeInfo[]
+): DataDrivenPropertyValueSpecification<string> {
+  // A match needs at least one branch.
+  if (taxonomy.length === 0) return FALLBACK_COLOR;
+  return [
+    "match",
+    ["get", "event_type"],
+    ...taxonomy.flatMap((t) => [t.type, typeColor(taxonomy, t.type)]),
+    FALLBACK_COLOR,
+  ] as unknown as DataDrivenPropertyValueSpecification<string>;
+}
 
 /*
  * Severity badge colors (status scale, not categorical): brand feedback
@@ -87,13 +95,6 @@ export const SEVERITY_RAMP_GRADIENT = `linear-gradient(to right, ${SEVERITY_RAMP
   (s) => s.color
 ).join(", ")})`;
 
-const colorMatchExpression = [
-  "match",
-  ["get", "event_type"],
-  ...Object.entries(EVENT_TYPE_COLORS).flat(),
-  FALLBACK_COLOR,
-] as unknown as DataDrivenPropertyValueSpecification<string>;
-
 export const HEATMAP_LAYER = {
   id: "events-heat",
   type: "heatmap",
@@ -136,7 +137,8 @@ export const UNCLUSTERED_POINT_LAYER: CircleLayerSpecification = {
   type: "circle",
   source: "events",
   paint: {
-    "circle-color": colorMatchExpression,
+    // MapView colors points by type once the taxonomy has loaded.
+    "circle-color": FALLBACK_COLOR,
     "circle-radius": [
       "interpolate",
       ["linear"],
@@ -0,0 +1,76 @@
+import type { EventType, EventTypeInfo } from "./types";
+
+// A taxonomy node placed in the tree: depth 0 for top-level types.
+export interface TaxonomyEntry extends EventTypeInfo {
+  depth: number;
+}
+
+/**
+ * The taxonomy in display order: each top-level type in the backend's
+ * order, followed by its descendants depth-first. A type whose parent is
+ * missing, or that sits on a parent cycle, is shown at the top level
+ * rather than dropped.
+ */
+export function taxonomyTree(taxonomy: EventTypeInfo[]): TaxonomyEntry[] {
+  const known = new Set(taxonomy.map((t) => t.type));
+  const children = new Map<EventType, EventTypeInfo[]>();
+  const roots: EventTypeInfo[] = [];
+  for (const info of taxonomy) {
+    if (info.parent && known.has(info.parent) && info.parent !== info.type) {
+      const kids = children.get(info.parent) ?? [];
+      kids.push(info);
+      children.set(info.parent, kids);
+    } else {
+      roots.push(info);
+    }
+  }
+
+  const out: TaxonomyEntry[] = [];
+  const seen = new Set<EventType>();
+  const visit = (info: EventTypeInfo, depth: number) => {
+    if (seen.has(info.type)) return;
+    seen.add(info.type);
+    out.push({ ...info, depth });
+    for (const kid of children.get(info.type) ?? []) visit(kid, depth + 1);
+  };
+  for (const root of roots) visit(root, 0);
+  for (const info of taxonomy) visit(info, 0); // unreachable: on a cycle
+  return out;
+}
+
+/** Every type in the taxonomy, in the backend's order. */
+export function allTypes(taxonomy: EventTypeInfo[]): EventType[] {
+  return taxonomy.map((t) => t.type);
+}
+
+/** type followed by all its descendants. */
+export function typeSubtree(
+  taxonomy: EventTypeInfo[],
+  type: EventType
+): EventType[] {
+  const out = [type];
+  for (let i = 0; i < out.length; i++) {
+    for (const info of taxonomy) {
+      if (info.parent === out[i] && !out.includes(info.type)) {
+        out.push(info.type);
+      }
+    }
+  }
+  return out;
+}
+
+/**
+ * type's ancestors, nearest first, stopping at a missing parent or a
+ * cycle.
+ */
+export function typeAncestors(
+  taxonomy: EventTypeInfo[],
+  type: EventType
+): EventType[] {
+  const parents = new Map(taxonomy.map((t) => [t.type, t.parent]));
+  const out: EventType[] = [];
+  for (let p = parents.get(type); p && p !== type && !out.includes(p); p = parents.get(p)) {
+    out.push(p);
+  }
+  return out;
+}
@@ -1,25 +1,17 @@
-// Mirrors the backend's canonical registry (backend/internal/models/types.go).
-// "weather" is the NOAA fallback for alerts with no more specific class;
-// "other" covers upstream categories no adapter maps yet.
-export const EVENT_TYPES = [
-  "earthquake",
-  "wildfire",
-  "volcano",
-  "storm",
-  "flood",
-  "cyclone",
-  "tornado",
-  "hurricane",
-  "winter_storm",
-  "tsunami",
-  "drought",
-  "iceberg",
-  "landslide",
-  "weather",
-  "other",
-] as const;
+// Event types come from the backend's taxonomy (GET /api/v1/types), so a
+// type added there reaches the map, filters and legend without a frontend
+// change. "weather" is the NOAA fallback for alerts with no more specific
+// class; "other" covers upstream categories no adapter maps yet.
+export type EventType = string;
 
-export type EventType = (typeof EVENT_TYPES)[number];
+// One node of the backend's event-type taxonomy. Querying a type also
+// returns its descendants (storm includes hurricane).
+export interface EventTypeInfo {
+  type: EventType;
+  label: string;
+  parent?: EventType;
+  aliases?: string[];
+}
 
 export type Severity = "extreme" | "severe" | "moderate" | "minor";
 
@@ -30,10 +22,16 @@ export interface EventProperties {
   source: string;
   severity?: Severity;
   magnitude?: number;
+  // 0–100, comparable across sources: severity band plus the source's measure.
+  impact?: number;
   started_at: string;
   updated_at: string;
+  // Set once the source reports the event over (status=closed or all).
+  ended_at?: string;
   url?: string;
   description?: string;
+  // Set on worldwide events, such as space weather, whose geometry is null.
+  global?: boolean;
   metadata?: Record<string, unknown>;
 }
 